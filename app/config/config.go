@@ -1,6 +1,7 @@
 package config
 
 import (
+	"os"
 	"path"
 	"strings"
 
@@ -9,14 +10,18 @@ import (
 	"go.uber.org/fx"
 
 	"gohome.4gophers.ru/getapp/gohome/app/server"
+	"gohome.4gophers.ru/getapp/gohome/appv2/database"
 	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
 type Config struct {
 	fx.Out
 
-	Logger logger.Config
-	Server server.Config
+	Logger   logger.Config
+	Server   server.Config
+	Database database.Config
+	Tracing  tracing.Config
 }
 
 func New(env string, cfg string) Config {
@@ -43,5 +48,17 @@ func New(env string, cfg string) Config {
 		panic(err)
 	}
 
+	// password is not kept in shared configs, it is passed by environment or secret file
+	if p, ok := os.LookupEnv("GOHOME_DATABASE_PASSWORD"); ok {
+		c.Database.Password = p
+	}
+	if file, ok := os.LookupEnv("GOHOME_DATABASE_PASSWORD_FILE"); ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		c.Database.Password = strings.TrimSpace(string(data))
+	}
+
 	return c
 }
@@ -0,0 +1,314 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/qor5/x/v3/i18n"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/badge"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/home"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/sitemap"
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// stateFile keeps update times of exported packages for incremental export
+	stateFile = ".gohome-export.json"
+	// nginxFile is included into the server block of nginx
+	nginxFile = "gohome.nginx.conf"
+)
+
+// Exporter renders public pages into files served by nginx without the public server
+type Exporter struct {
+	logger  *logger.Logger
+	db      *gorm.DB
+	handler http.Handler
+}
+
+func New(logger *logger.Logger, db *gorm.DB, home *home.Handler, badge *badge.Handler, sitemap *sitemap.Handler, i18n *i18n.Builder) *Exporter {
+	// the same handlers as public server has, search is dynamic and is not exported,
+	// documentation and changelogs are not exported either, so sitemap skips them
+	sitemap = sitemap.Static()
+
+	r := chi.NewRouter()
+	r.Get("/badge/*", badge.Badge)
+	r.Get("/robots.txt", sitemap.Robots)
+	r.Get("/sitemap.xml", sitemap.Sitemap)
+	r.Get("/sitemap-{page:[0-9]+}.xml", sitemap.Page)
+	r.With(locales.Middleware(i18n)).Get("/*", home.Home)
+
+	return &Exporter{
+		logger:  logger,
+		db:      db,
+		handler: r,
+	}
+}
+
+// Export writes index.html of every active package, badges, catalog pages and nginx include into dir.
+// Packages not changed since the previous export are skipped unless full is set
+func (e *Exporter) Export(ctx context.Context, dir string, full bool) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	state := map[string]time.Time{}
+	if !full {
+		if state, err = e.state(dir); err != nil {
+			return err
+		}
+	}
+
+	var packages []models.Package
+	err = e.db.WithContext(ctx).
+		Select("package", "updated_at").
+		Where("active = ?", true).
+		Order("package").
+		Find(&packages).Error
+	if err != nil {
+		return err
+	}
+
+	exported := make(map[string]time.Time, len(packages))
+	rendered := 0
+	for _, p := range packages {
+		exported[p.Package] = p.UpdatedAt
+
+		rel := strings.TrimPrefix(p.Package, models.Domain)
+		if t, ok := state[p.Package]; ok && t.Equal(p.UpdatedAt) && exists(filepath.Join(dir, rel, "index.html")) {
+			continue
+		}
+
+		if err := e.render(ctx, dir, rel, filepath.Join(rel, "index.html")); err != nil {
+			return err
+		}
+		for _, path := range badges(p.Package) {
+			if err := e.render(ctx, dir, path, path); err != nil {
+				return err
+			}
+		}
+		rendered++
+	}
+
+	// packages deleted or deactivated since the previous export
+	removed := 0
+	for pkg := range state {
+		if _, ok := exported[pkg]; ok {
+			continue
+		}
+		if err := e.remove(dir, pkg); err != nil {
+			return err
+		}
+		removed++
+	}
+
+	// catalog pages list all packages and are always rendered
+	if err := e.catalog(ctx, dir); err != nil {
+		return err
+	}
+
+	if err := write(filepath.Join(dir, nginxFile), nginx(dir, packages)); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := write(filepath.Join(dir, stateFile), data); err != nil {
+		return err
+	}
+
+	e.logger.Info("static export finished",
+		zap.String("dir", dir),
+		zap.Int("packages", len(packages)),
+		zap.Int("rendered", rendered),
+		zap.Int("removed", removed))
+
+	return nil
+}
+
+// state of the previous export, empty for the first one
+func (e *Exporter) state(dir string) (map[string]time.Time, error) {
+	state := map[string]time.Time{}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("broken %s, run full export: %w", stateFile, err)
+	}
+
+	return state, nil
+}
+
+// catalog renders landing page, robots.txt and sitemap with its pages
+func (e *Exporter) catalog(ctx context.Context, dir string) error {
+	for _, p := range []struct{ target, file string }{
+		{"/", "index.html"},
+		{"/robots.txt", "robots.txt"},
+		{"/sitemap.xml", "sitemap.xml"},
+	} {
+		if err := e.render(ctx, dir, p.target, p.file); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
+	if err != nil {
+		return err
+	}
+
+	// sitemap becomes an index of pages when there are too many packages
+	var index struct {
+		XMLName  xml.Name
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	if err := xml.Unmarshal(data, &index); err != nil {
+		return err
+	}
+	if index.XMLName.Local != "sitemapindex" {
+		return nil
+	}
+
+	for _, s := range index.Sitemaps {
+		path := strings.TrimPrefix(s.Loc, "https://"+models.Domain)
+		if err := e.render(ctx, dir, path, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// render requests target from public handlers and stores the response in file relative to dir
+func (e *Exporter) render(ctx context.Context, dir, target, file string) error {
+	r := httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	e.handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("render %s: status %d", target, w.Code)
+	}
+
+	file = filepath.Join(dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	return write(file, w.Body.Bytes())
+}
+
+// remove deletes files of the package, directories are kept while nested modules live in them
+func (e *Exporter) remove(dir, pkg string) error {
+	rel := strings.TrimPrefix(pkg, models.Domain)
+
+	files := []string{filepath.Join(rel, "index.html")}
+	files = append(files, badges(pkg)...)
+	for _, f := range files {
+		f = filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		// empty parents up to the export root
+		for d := filepath.Dir(f); d != dir && strings.HasPrefix(d, dir); d = filepath.Dir(d) {
+			if os.Remove(d) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// badges are paths of all badges of the package
+func badges(pkg string) []string {
+	var paths []string
+	for _, path := range models.Paths(pkg) {
+		if strings.HasPrefix(path, "/badge/") {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// nginx maps package paths and paths of packages inside modules to exported pages,
+// the go command (?go-get=1) and browsers get the same page. The longest prefix wins,
+// so nested modules get their own pages
+func nginx(dir string, packages []models.Package) []byte {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# generated by gohome export-static, include into the server block\n")
+	fmt.Fprintf(b, "root %s;\n\n", dir)
+	fmt.Fprintf(b, "location = / {\n\ttry_files /index.html =404;\n}\n\n")
+	fmt.Fprintf(b, "location ^~ /badge/ {\n\tadd_header Cache-Control \"public, max-age=300\";\n\ttry_files $uri =404;\n}\n\n")
+	fmt.Fprintf(b, "location ~ ^/(robots\\.txt|sitemap(-[0-9]+)?\\.xml)$ {\n\ttry_files $uri =404;\n}\n")
+
+	paths := make([]string, 0, len(packages))
+	for _, p := range packages {
+		paths = append(paths, strings.TrimPrefix(p.Package, models.Domain))
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		for _, location := range []string{"= " + path, "^~ " + path + "/"} {
+			fmt.Fprintf(b, "\nlocation %s {\n\tdefault_type text/html;\n\ttry_files %s/index.html =404;\n}\n", location, path)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// write replaces the file atomically, nginx never serves a half written page
+func write(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func exists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
@@ -0,0 +1,183 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/badge"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/home"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/sitemap"
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestNginx(t *testing.T) {
+	conf := string(nginx("/var/www/gohome", []models.Package{
+		{Package: models.Domain + "/kovardin/example/v2"},
+		{Package: models.Domain + "/kovardin/example"},
+	}))
+
+	for _, s := range []string{
+		"root /var/www/gohome;",
+		"location = / {",
+		"location ^~ /badge/ {",
+		"location = /kovardin/example {\n\tdefault_type text/html;\n\ttry_files /kovardin/example/index.html =404;",
+		"location ^~ /kovardin/example/ {\n\tdefault_type text/html;\n\ttry_files /kovardin/example/index.html =404;",
+		"location ^~ /kovardin/example/v2/ {\n\tdefault_type text/html;\n\ttry_files /kovardin/example/v2/index.html =404;",
+	} {
+		if !strings.Contains(conf, s) {
+			t.Errorf("config has no %q:\n%s", s, conf)
+		}
+	}
+
+	// locations are sorted, the output is stable between exports
+	if strings.Index(conf, "/kovardin/example/v2/") < strings.Index(conf, "= /kovardin/example {") {
+		t.Errorf("locations are not sorted:\n%s", conf)
+	}
+}
+
+func TestState(t *testing.T) {
+	e := &Exporter{}
+	dir := t.TempDir()
+
+	state, err := e.state(dir)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("first export: %v %v", state, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, stateFile), []byte(`{"gohome.4gophers.ru/kovardin/example": "2025-01-01T00:00:00Z"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	state, err = e.state(dir)
+	if err != nil || !state[models.Domain+"/kovardin/example"].Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("stored state: %v %v", state, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, stateFile), []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.state(dir); err == nil || !strings.Contains(err.Error(), "run full export") {
+		t.Errorf("broken state: %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dir := t.TempDir()
+	pkg := models.Domain + "/kovardin/example"
+
+	files := append([]string{"kovardin/example/index.html", "kovardin/example/v2/index.html"}, badges(pkg)...)
+	for _, f := range files {
+		f = filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("page"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := (&Exporter{}).remove(dir, pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nested module keeps its page and directories
+	if !exists(filepath.Join(dir, "kovardin/example/v2/index.html")) {
+		t.Error("page of nested module is removed")
+	}
+	if exists(filepath.Join(dir, "kovardin/example/index.html")) {
+		t.Error("page of the package is kept")
+	}
+	if exists(filepath.Join(dir, "badge")) {
+		t.Error("empty badge directories are kept")
+	}
+}
+
+// lifecycle drops hooks, the tracker of the home handler is not started
+type lifecycle struct{}
+
+func (lifecycle) Append(fx.Hook) {}
+
+func TestExport(t *testing.T) {
+	db := dbtest.Open(t, &models.Package{}, &models.Version{})
+	log := zap.NewNop()
+	e := New(log, db, home.New(lifecycle{}, log, db), badge.New(log, db), sitemap.New(log, db), locales.New())
+
+	pkgs := []models.Package{
+		{Package: models.Domain + "/kovardin/one", Repo: "https://gitflic.ru/project/kovardin/one.git", VCS: models.VCSGit, Active: true},
+		{Package: models.Domain + "/kovardin/two", Repo: "https://gitflic.ru/project/kovardin/two.git", VCS: models.VCSGit, Active: true},
+	}
+	if err := db.Create(&pkgs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	page := func(rel string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, rel, "index.html"))
+		return string(data)
+	}
+	mark := func(rel string) {
+		if err := os.WriteFile(filepath.Join(dir, rel, "index.html"), []byte("stale"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.Export(ctx, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"index.html", "robots.txt", "sitemap.xml", nginxFile, stateFile, "badge/kovardin/one/version.svg"} {
+		if !exists(filepath.Join(dir, f)) {
+			t.Errorf("%s is not exported", f)
+		}
+	}
+	if !strings.Contains(page("kovardin/one"), `content="`+models.Domain+`/kovardin/one git`) {
+		t.Errorf("page has no go-import:\n%s", page("kovardin/one"))
+	}
+
+	// unchanged packages are skipped, changed ones are rendered again
+	mark("kovardin/one")
+	mark("kovardin/two")
+	if err := db.Model(&pkgs[1]).UpdateColumn("updated_at", time.Now().Add(time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Export(ctx, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if page("kovardin/one") != "stale" {
+		t.Error("unchanged package is rendered")
+	}
+	if page("kovardin/two") == "stale" {
+		t.Error("changed package is not rendered")
+	}
+
+	// deactivated packages are removed from files and nginx config
+	if err := db.Model(&pkgs[1]).UpdateColumn("active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Export(ctx, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if exists(filepath.Join(dir, "kovardin/two")) {
+		t.Error("files of deactivated package are kept")
+	}
+	conf, _ := os.ReadFile(filepath.Join(dir, nginxFile))
+	if strings.Contains(string(conf), "/kovardin/two") {
+		t.Errorf("nginx config has deactivated package:\n%s", conf)
+	}
+
+	// full export renders everything
+	if err := e.Export(ctx, dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if page("kovardin/one") == "stale" {
+		t.Error("full export skipped the package")
+	}
+}
@@ -0,0 +1,9 @@
+package export
+
+import "go.uber.org/fx"
+
+var Export = fx.Module("export",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,9 @@
+package badge
+
+import "go.uber.org/fx"
+
+var Badge = fx.Module("badge",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,139 @@
+package badge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/mod/semver"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/badge"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	cacheControl        = "public, max-age=300"
+	cacheControlUnknown = "public, max-age=60"
+)
+
+type Handler struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	return &Handler{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Badge render badge by url like /badge/kovardin/example/version.svg
+func (h *Handler) Badge(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/badge/")
+
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	kind, svg := strings.CutSuffix(path[i+1:], ".svg")
+	path = path[:i]
+
+	label, ok := labels[kind]
+	if !svg || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	b, found := h.badge(r.Context(), kind, path)
+	if !found {
+		b = badge.Unknown(label)
+	}
+
+	h.write(w, r, b, found)
+}
+
+var labels = map[string]string{
+	"version": "latest",
+	"goget":   "go get",
+	"license": "license",
+	"go":      "go",
+}
+
+func (h *Handler) badge(ctx context.Context, kind, path string) (badge.Badge, bool) {
+	pkg := models.Package{}
+	err := h.db.WithContext(ctx).
+		Where("package = ? AND active = ?", models.Domain+"/"+path, true).
+		First(&pkg).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.For(ctx, h.logger).Error("error on load package", zap.String("path", path), zap.Error(err))
+		}
+		return badge.Badge{}, false
+	}
+
+	if kind == "goget" {
+		return badge.Badge{Label: labels[kind], Message: pkg.Package, Color: badge.ColorBlue}, true
+	}
+
+	versions := []models.Version{}
+	err = h.db.WithContext(ctx).
+		Where("package_id = ?", pkg.ID).
+		Find(&versions).Error
+	if err != nil {
+		logger.For(ctx, h.logger).Error("error on load versions", zap.String("path", path), zap.Error(err))
+		return badge.Badge{}, false
+	}
+
+	latest, ok := models.Latest(versions)
+	if !ok {
+		return badge.Badge{}, false
+	}
+
+	switch kind {
+	case "version":
+		color := badge.ColorBlue
+		if semver.Prerelease(latest.Version) != "" {
+			color = badge.ColorOrange
+		}
+		return badge.Badge{Label: labels[kind], Message: latest.Version, Color: color}, true
+	case "license":
+		if latest.License == "" {
+			return badge.Badge{}, false
+		}
+		return badge.Badge{Label: labels[kind], Message: latest.License, Color: badge.ColorGreen}, true
+	case "go":
+		if latest.GoVersion == "" {
+			return badge.Badge{}, false
+		}
+		return badge.Badge{Label: labels[kind], Message: latest.GoVersion, Color: badge.ColorBlue}, true
+	}
+
+	return badge.Badge{}, false
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, b badge.Badge, found bool) {
+	body := b.SVG()
+
+	cc := cacheControl
+	if !found {
+		cc = cacheControlUnknown
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml;charset=utf-8")
+	if httpcache.Check(w, r, cc, httpcache.ETag(body), time.Time{}) {
+		return
+	}
+
+	if _, err := w.Write(body); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on write badge", zap.Error(err))
+	}
+}
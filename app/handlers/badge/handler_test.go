@@ -0,0 +1,45 @@
+package badge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestBadgeURL(t *testing.T) {
+	// dry run finds no packages, known badges are rendered as unknown
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(zap.NewNop(), db)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/badge/kovardin/example/version.svg", http.StatusOK},
+		{"/badge/kovardin/example/go.svg", http.StatusOK},
+		{"/badge/kovardin/example/version", http.StatusNotFound},
+		{"/badge/kovardin/example/version.png", http.StatusNotFound},
+		{"/badge/kovardin/example/size.svg", http.StatusNotFound},
+		{"/badge/version.svg", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.Badge(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, tt.code)
+		}
+		if tt.code == http.StatusOK && !strings.Contains(w.Body.String(), "unknown") {
+			t.Errorf("%s: expected unknown badge, got %s", tt.path, w.Body.String())
+		}
+	}
+}
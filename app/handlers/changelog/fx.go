@@ -0,0 +1,9 @@
+package changelog
+
+import "go.uber.org/fx"
+
+var Changelog = fx.Module("changelog",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,183 @@
+package changelog
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// cacheControl is the same as of package pages, admin purges changelogs on refresh
+	cacheControl        = "public, max-age=300"
+	cacheControlUnknown = "public, max-age=60"
+)
+
+type Handler struct {
+	logger   *logger.Logger
+	db       *gorm.DB
+	template *template.Template
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	t, err := template.New("changelog").Parse(tmpl)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Handler{
+		logger:   logger,
+		db:       db,
+		template: t,
+	}
+}
+
+// Changelog renders versions of the package by url like /changelog/kovardin/example,
+// one version is rendered by url like /changelog/kovardin/example@v1.5.0
+func (h *Handler) Changelog(w http.ResponseWriter, r *http.Request) {
+	path, version, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/changelog"), "@")
+
+	var p models.Package
+	err := h.db.WithContext(r.Context()).
+		Where("package = ? AND active = ?", models.Domain+strings.TrimSuffix(path, "/"), true).
+		Limit(1).
+		Find(&p).Error
+	if err != nil {
+		logger.For(r.Context(), h.logger).Error("error on find package", zap.String("path", path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var versions []models.Version
+	if p.ID != 0 {
+		if err := h.db.WithContext(r.Context()).Where("package_id = ?", p.ID).Find(&versions).Error; err != nil {
+			logger.For(r.Context(), h.logger).Error("error on find versions", zap.String("path", path), zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	releases := models.Changelog(versions)
+	if version != "" {
+		releases = find(releases, version)
+	}
+
+	status := http.StatusOK
+	if p.ID == 0 || (version != "" && len(releases) == 0) {
+		status = http.StatusNotFound
+	}
+
+	data := struct {
+		Package  models.Package
+		Path     string
+		Version  string
+		Releases []models.Release
+		T        *locales.Messages
+	}{
+		Package:  p,
+		Path:     strings.TrimPrefix(p.Package, models.Domain),
+		Version:  version,
+		Releases: releases,
+		T:        locales.Get(r),
+	}
+
+	buf := &bytes.Buffer{}
+	if err := h.template.Execute(buf, data); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on render template", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if status != http.StatusOK {
+		w.Header().Set("Cache-Control", cacheControlUnknown)
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	// refresh moves updated_at of the package when its versions are changed
+	if httpcache.Check(w, r, cacheControl, httpcache.ETag(buf.Bytes()), p.UpdatedAt) {
+		return
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on write page", zap.Error(err))
+	}
+}
+
+func find(releases []models.Release, version string) []models.Release {
+	for _, r := range releases {
+		if r.Version.Version == version {
+			return []models.Release{r}
+		}
+	}
+	return nil
+}
+
+var tmpl = `<!doctype html>
+<html lang="{{.T.Lang}}">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{if .Package.ID}}{{.Package.Package}}{{with .Version}}@{{.}}{{end}} · {{end}}{{.T.ChangelogTitle}} · Go Home</title>
+    <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-EVSTQN3/azprG1Anm3QDgpJLIm9Nao0Yz1ztcQTwFspd3yD65VohhpuuCOmLASjC" crossorigin="anonymous">
+    {{if .Package.ID}}<link rel="alternate" type="application/atom+xml" title="{{.Package.Package}}" href="/feed{{.Path}}.atom">{{end}}
+  </head>
+  <body>
+<div class="col-lg-8 mx-auto p-3 py-md-5">
+  <header class="d-flex align-items-center pb-3 mb-5 border-bottom">
+    <a href="/" class="d-flex align-items-center text-dark text-decoration-none">
+      <span class="fs-4">Go Home</span>
+    </a>
+    <a href="?lang={{.T.SwitchTo}}" class="ms-auto text-muted">{{.T.Switch}}</a>
+  </header>
+
+  <main>
+    {{if not .Package.ID}}
+    <p class="fs-5">{{.T.ChangelogNotFound}}</p>
+    {{else}}
+    <h1 class="h3">{{if .Version}}<a href="/changelog{{.Path}}" class="text-decoration-none">{{.Package.Package}}</a>@{{.Version}}{{else}}{{.Package.Package}}{{end}}</h1>
+    {{if .Package.Title}}<p class="fw-bold">{{.Package.Title}}</p>{{end}}
+    <p class="text-muted">{{.T.ChangelogTitle}}</p>
+
+    {{if not .Releases}}
+    <p class="fs-5">{{if .Version}}{{.T.ChangelogVersionNotFound}}{{else}}{{.T.ChangelogEmpty}}{{end}}</p>
+    {{end}}
+
+    {{$path := .Path}}
+    {{$pkg := .Package.Package}}
+    {{$single := .Version}}
+    {{$t := .T}}
+    {{range .Releases}}
+    <section class="mb-5">
+      <h2 class="h4">
+        <a href="/changelog{{$path}}@{{.Version.Version}}" class="text-decoration-none">{{.Version.Version}}</a>
+        {{if .Major}}<span class="badge bg-danger">{{$t.ChangelogMajor}}</span>{{end}}
+        {{if .Prerelease}}<span class="badge bg-warning text-dark">{{$t.ChangelogPrerelease}}</span>{{end}}
+      </h2>
+      <div class="text-muted mb-2">{{.Time.Format "2006-01-02"}}{{with .GoVersion}} · go {{.}}{{end}}{{with .License}} · {{.}}{{end}}</div>
+      {{if $single}}<pre>go get {{$pkg}}@{{.Version.Version}}</pre>{{end}}
+      {{with .Message}}<pre style="white-space: pre-wrap">{{.}}</pre>{{end}}
+      {{with .Subjects}}
+      <ul>
+        {{range .}}<li>{{.}}</li>{{end}}
+      </ul>
+      {{end}}
+      {{if $single}}{{with .Previous}}<p><a href="/changelog{{$path}}@{{.}}">{{$t.ChangelogPrevious}} {{.}}</a></p>{{end}}{{end}}
+    </section>
+    {{end}}
+    {{end}}
+  </main>
+</div>
+  </body>
+</html>
+`
@@ -0,0 +1,9 @@
+package doc
+
+import "go.uber.org/fx"
+
+var Doc = fx.Module("doc",
+	fx.Provide(
+		New,
+	),
+)
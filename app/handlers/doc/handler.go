@@ -0,0 +1,156 @@
+package doc
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// cacheControl is the same as of package pages, admin purges docs on refresh
+	cacheControl        = "public, max-age=300"
+	cacheControlUnknown = "public, max-age=60"
+)
+
+type Handler struct {
+	logger   *logger.Logger
+	db       *gorm.DB
+	template *template.Template
+	policy   *bluemonday.Policy
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	t, err := template.New("doc").Parse(tmpl)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Handler{
+		logger:   logger,
+		db:       db,
+		template: t,
+		policy:   bluemonday.UGCPolicy(),
+	}
+}
+
+// Doc renders readme and exported symbols of the package by url like /doc/kovardin/example
+func (h *Handler) Doc(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/doc"), "/")
+
+	var p models.Package
+	err := h.db.WithContext(r.Context()).
+		Where("package = ? AND active = ?", models.Domain+path, true).
+		Limit(1).
+		Find(&p).Error
+	if err != nil {
+		logger.For(r.Context(), h.logger).Error("error on find package", zap.String("path", path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Package models.Package
+		Path    string
+		Readme  template.HTML
+		Symbols []string
+		T       *locales.Messages
+	}{
+		Package: p,
+		Path:    strings.TrimPrefix(p.Package, models.Domain),
+		Readme:  h.readme(p.Readme),
+		Symbols: strings.Fields(p.Symbols),
+		T:       locales.Get(r),
+	}
+
+	buf := &bytes.Buffer{}
+	if err := h.template.Execute(buf, data); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on render template", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if p.ID == 0 {
+		w.Header().Set("Cache-Control", cacheControlUnknown)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	// refresh changes updated_at only when readme or symbols are changed
+	if httpcache.Check(w, r, cacheControl, httpcache.ETag(buf.Bytes()), p.UpdatedAt) {
+		return
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on write page", zap.Error(err))
+	}
+}
+
+// readme is markdown from the repository, rendered html is sanitized as user content
+func (h *Handler) readme(src string) template.HTML {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+
+	return template.HTML(h.policy.SanitizeBytes(blackfriday.Run([]byte(src))))
+}
+
+var tmpl = `<!doctype html>
+<html lang="{{.T.Lang}}">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{if .Package.ID}}{{.Package.Package}} · {{end}}{{.T.DocTitle}} · Go Home</title>
+    <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-EVSTQN3/azprG1Anm3QDgpJLIm9Nao0Yz1ztcQTwFspd3yD65VohhpuuCOmLASjC" crossorigin="anonymous">
+    {{if .Package.ID}}<link rel="alternate" type="application/atom+xml" title="{{.Package.Package}}" href="/feed{{.Path}}.atom">{{end}}
+  </head>
+  <body>
+<div class="col-lg-8 mx-auto p-3 py-md-5">
+  <header class="d-flex align-items-center pb-3 mb-5 border-bottom">
+    <a href="/" class="d-flex align-items-center text-dark text-decoration-none">
+      <span class="fs-4">Go Home</span>
+    </a>
+    <a href="?lang={{.T.SwitchTo}}" class="ms-auto text-muted">{{.T.Switch}}</a>
+  </header>
+
+  <main>
+    {{if not .Package.ID}}
+    <p class="fs-5">{{.T.DocNotFound}}</p>
+    {{else}}
+    <h1 class="h3">{{.Package.Package}}</h1>
+    {{if .Package.Title}}<p class="fw-bold">{{.Package.Title}}</p>{{end}}
+    <p><a href="/changelog{{.Path}}">{{.T.SearchChangelog}}</a></p>
+    <pre>go get {{.Package.Package}}</pre>
+
+    {{if .Readme}}
+    <section class="mb-5">{{.Readme}}</section>
+    {{else}}
+    <p class="text-muted">{{.T.DocEmpty}}</p>
+    {{end}}
+
+    {{with .Symbols}}
+    <section class="mb-5">
+      <h2 class="h4">{{$.T.DocSymbols}}</h2>
+      <ul class="list-unstyled">
+        {{range .}}<li><code>{{.}}</code></li>{{end}}
+      </ul>
+    </section>
+    {{end}}
+    {{end}}
+  </main>
+</div>
+  </body>
+</html>
+`
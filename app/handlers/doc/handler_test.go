@@ -0,0 +1,93 @@
+package doc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestReadme(t *testing.T) {
+	h := New(zap.NewNop(), nil)
+
+	tests := []struct {
+		src      string
+		contains []string
+		excludes []string
+	}{
+		{"", nil, []string{"<"}},
+		{"# Example\n\nSmall `client`", []string{"<h1>Example</h1>", "<code>client</code>"}, nil},
+		{"<script>alert(1)</script>[x](javascript:alert(1))", nil, []string{"<script", "javascript:"}},
+	}
+
+	for _, tt := range tests {
+		html := string(h.readme(tt.src))
+		for _, s := range tt.contains {
+			if !strings.Contains(html, s) {
+				t.Errorf("%q: %q has no %q", tt.src, html, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(html, s) {
+				t.Errorf("%q: %q has %q", tt.src, html, s)
+			}
+		}
+	}
+}
+
+func TestDocNotFound(t *testing.T) {
+	// dry run finds no packages
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	New(zap.NewNop(), db).Doc(w, httptest.NewRequest(http.MethodGet, "/doc/kovardin/unknown", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestDoc(t *testing.T) {
+	db := dbtest.Open(t, &models.Package{})
+
+	p := models.Package{
+		Package: models.Domain + "/kovardin/example",
+		Title:   "Example",
+		Active:  true,
+		Readme:  "# Example\n\nSmall client",
+		Symbols: "Client NewClient Client.Do",
+	}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := New(zap.NewNop(), db)
+
+	w := httptest.NewRecorder()
+	h.Doc(w, httptest.NewRequest(http.MethodGet, "/doc/kovardin/example", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	for _, s := range []string{"<h1>Example</h1>", "<code>NewClient</code>", `href="/changelog/kovardin/example"`} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("page has no %q", s)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/doc/kovardin/example", nil)
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	h.Doc(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("got %d, want %d", w.Code, http.StatusNotModified)
+	}
+}
@@ -0,0 +1,9 @@
+package feed
+
+import "go.uber.org/fx"
+
+var Feed = fx.Module("feed",
+	fx.Provide(
+		New,
+	),
+)
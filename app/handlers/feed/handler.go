@@ -0,0 +1,310 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// limit of entries in one feed, readers keep older entries themselves
+	limit = 50
+	// excerpt limits lines of the changelog in the entry
+	excerpt = 10
+
+	base         = "https://" + models.Domain
+	cacheControl = "public, max-age=300"
+	// unknown namespaces and packages may be published soon
+	cacheControlUnknown = "public, max-age=60"
+	// tagDate is the date of tag uris of entries, it must never change
+	tagDate = "2024"
+)
+
+type Handler struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	return &Handler{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// scope selects packages of the feed
+type scope struct {
+	// Path is the feed path, e.g. /feed/kovardin.atom
+	Path  string
+	Title string
+	// Namespace or Package limits the feed, both are empty for the whole site
+	Namespace string
+	Package   string
+}
+
+// Site renders /feed.atom with new packages and versions of all packages
+func (h *Handler) Site(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, scope{Path: "/feed.atom", Title: locales.Get(r).FeedTitle})
+}
+
+// Feed renders feed of the namespace by url like /feed/kovardin.atom
+// or of the package by url like /feed/kovardin/example.atom
+func (h *Handler) Feed(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/feed/"), ".atom")
+	if !ok || path == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s := scope{Path: r.URL.Path, Title: fmt.Sprintf(locales.Get(r).FeedScope, path)}
+	if strings.Contains(path, "/") {
+		s.Package = models.Domain + "/" + path
+	} else {
+		s.Namespace = path
+	}
+
+	var count int64
+	if err := h.packages(r.Context(), s).Count(&count).Error; err != nil {
+		logger.For(r.Context(), h.logger).Error("error on find packages", zap.String("path", path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if count == 0 {
+		w.Header().Set("Cache-Control", cacheControlUnknown)
+		http.NotFound(w, r)
+		return
+	}
+
+	h.render(w, r, s)
+}
+
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, s scope) {
+	ctx := r.Context()
+
+	entries, err := h.entries(ctx, r, s)
+	if err != nil {
+		logger.For(ctx, h.logger).Error("error on build feed", zap.String("path", s.Path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	f := atom{
+		Xmlns: "http://www.w3.org/2005/Atom",
+		ID:    base + s.Path,
+		Title: s.Title,
+		Links: []link{
+			{Rel: "self", Href: base + s.Path},
+			{Rel: "alternate", Href: base + "/"},
+		},
+		Entries: entries,
+	}
+
+	// the feed is dated by its newest entry, empty feed is dated by the site start
+	modified, err := h.modified(ctx, s)
+	if err != nil {
+		logger.For(ctx, h.logger).Error("error on find feed update", zap.String("path", s.Path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	f.Updated = date(modified)
+
+	body, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		logger.For(ctx, h.logger).Error("error on encode feed", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if httpcache.Check(w, r, cacheControl, httpcache.ETag(body), modified) {
+		return
+	}
+
+	if _, err := w.Write(body); err != nil {
+		logger.For(ctx, h.logger).Error("error on write feed", zap.Error(err))
+	}
+}
+
+// entries are the newest published packages and versions, newest first
+func (h *Handler) entries(ctx context.Context, r *http.Request, s scope) ([]entry, error) {
+	t := locales.Get(r)
+
+	packages := h.packages(ctx, s)
+	var ps []models.Package
+	if err := packages.Order("created_at DESC").Limit(limit).Find(&ps).Error; err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, p := range ps {
+		rel := strings.TrimPrefix(p.Package, models.Domain)
+		content := []string{p.Title, p.Info, "go get " + p.Package}
+
+		entries = append(entries, entry{
+			ID:      tag(p.Package),
+			Title:   fmt.Sprintf(t.FeedPackage, p.Package),
+			Links:   []link{{Rel: "alternate", Href: base + "/changelog" + rel}},
+			Updated: date(p.CreatedAt),
+			Content: text(content),
+			time:    p.CreatedAt,
+		})
+	}
+
+	var versions []struct {
+		models.Version
+		Package string
+	}
+	err := h.db.WithContext(ctx).
+		Table("versions").
+		Select("versions.*, packages.package").
+		Joins("JOIN packages ON packages.id = versions.package_id").
+		Where("versions.deleted_at IS NULL AND packages.id IN (?)", packages.Select("id")).
+		Order("versions.time DESC").
+		Limit(limit).
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		rel := strings.TrimPrefix(v.Package, models.Domain)
+		content := []string{changes(v.Version), "go get " + v.Package + "@" + v.Version.Version}
+
+		entries = append(entries, entry{
+			ID:      tag(v.Package + "@" + v.Version.Version),
+			Title:   fmt.Sprintf(t.FeedVersion, v.Package, v.Version.Version),
+			Links:   []link{{Rel: "alternate", Href: base + "/changelog" + rel + "@" + v.Version.Version}},
+			Updated: date(v.Time),
+			Content: text(content),
+			time:    v.Time,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time.After(entries[j].time)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+// modified is the date of the newest entry: the tag commit of a version or the publication of a package.
+// Refreshes, checks and docs bump updated_at of packages without changing the feed,
+// edits of titles change the ETag only
+func (h *Handler) modified(ctx context.Context, s scope) (time.Time, error) {
+	versions := h.db.WithContext(ctx).
+		Model(&models.Version{}).
+		Select("MAX(time)").
+		Where("package_id IN (?)", h.packages(ctx, s).Select("id"))
+
+	var modified sql.NullTime
+	err := h.db.WithContext(ctx).
+		Raw("SELECT GREATEST((?), (?))", h.packages(ctx, s).Select("MAX(created_at)"), versions).
+		Row().
+		Scan(&modified)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return modified.Time, nil
+}
+
+// packages are active packages of the scope
+func (h *Handler) packages(ctx context.Context, s scope) *gorm.DB {
+	db := h.db.WithContext(ctx).Model(&models.Package{}).Where("active = ?", true)
+	switch {
+	case s.Package != "":
+		db = db.Where("package = ?", s.Package)
+	case s.Namespace != "":
+		ns := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s.Namespace)
+		db = db.Where(`package LIKE ? ESCAPE '\'`, models.Domain+"/"+ns+"/%")
+	}
+	return db
+}
+
+// changes is the excerpt of the changelog: tag message, then commit subjects
+func changes(v models.Version) string {
+	var lines []string
+	for _, l := range strings.Split(v.Message, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if v.Changes != "" {
+		for _, s := range strings.Split(v.Changes, "\n") {
+			lines = append(lines, "- "+s)
+		}
+	}
+
+	if len(lines) > excerpt {
+		lines = append(lines[:excerpt], "…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tag builds stable id of the entry, see RFC 4151
+func tag(name string) string {
+	return "tag:" + models.Domain + "," + tagDate + ":" + name
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func text(parts []string) content {
+	var lines []string
+	for _, p := range parts {
+		if p != "" {
+			lines = append(lines, p)
+		}
+	}
+	return content{Type: "text", Body: strings.Join(lines, "\n\n")}
+}
+
+type atom struct {
+	XMLName xml.Name `xml:"feed"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []link   `xml:"link"`
+	Entries []entry  `xml:"entry"`
+}
+
+type link struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type entry struct {
+	ID      string  `xml:"id"`
+	Title   string  `xml:"title"`
+	Links   []link  `xml:"link"`
+	Updated string  `xml:"updated"`
+	Content content `xml:"content"`
+
+	time time.Time
+}
+
+type content struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
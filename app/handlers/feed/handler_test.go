@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestPackagesSQL(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(zap.NewNop(), db)
+
+	tests := []struct {
+		scope scope
+		where string
+		vars  []any
+	}{
+		{scope{}, "active = $1", []any{true}},
+		{scope{Namespace: "kovardin"}, `package LIKE $2 ESCAPE '\'`, []any{true, models.Domain + "/kovardin/%"}},
+		// wildcards of LIKE in the namespace match themselves only
+		{scope{Namespace: "go_tools%"}, `package LIKE $2 ESCAPE '\'`, []any{true, models.Domain + `/go\_tools\%/%`}},
+		{scope{Package: models.Domain + "/kovardin/example"}, "package = $2", []any{true, models.Domain + "/kovardin/example"}},
+	}
+
+	for _, tt := range tests {
+		var ps []models.Package
+		stmt := h.packages(context.Background(), tt.scope).Find(&ps).Statement
+		sql := stmt.SQL.String()
+		if !strings.Contains(sql, tt.where) {
+			t.Errorf("%+v: %s has no %s", tt.scope, sql, tt.where)
+		}
+		if len(stmt.Vars) != len(tt.vars) {
+			t.Errorf("%+v: got vars %v, want %v", tt.scope, stmt.Vars, tt.vars)
+			continue
+		}
+		for i := range tt.vars {
+			if stmt.Vars[i] != tt.vars[i] {
+				t.Errorf("%+v: got vars %v, want %v", tt.scope, stmt.Vars, tt.vars)
+			}
+		}
+	}
+}
+
+// fixture publishes packages of two namespaces, the inactive one is never in feeds
+func fixture(t *testing.T) (*gorm.DB, map[string]models.Package) {
+	db := dbtest.Open(t, &models.Package{}, &models.Version{})
+	if err := db.Exec(models.VersionIndex).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs := map[string]models.Package{}
+	for _, p := range []models.Package{
+		{Package: models.Domain + "/kovardin/example", Title: "Example", Active: true},
+		{Package: models.Domain + "/kovardin/lib", Title: "Lib", Active: true},
+		{Package: models.Domain + "/kovardin2/tool", Title: "Tool", Active: true},
+		{Package: models.Domain + "/kovardin/hidden", Title: "Hidden", Active: false},
+	} {
+		if err := db.Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		pkgs[strings.TrimPrefix(p.Package, models.Domain+"/")] = p
+	}
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, versions := range map[string][]models.Version{
+		"kovardin/example": {{Version: "v1.0.0", Time: day}, {Version: "v1.1.0", Time: day.AddDate(0, 1, 0)}},
+		"kovardin/lib":     {{Version: "v0.1.0", Time: day}},
+		"kovardin2/tool":   {{Version: "v2.0.0", Time: day}},
+		"kovardin/hidden":  {{Version: "v9.0.0", Time: day}},
+	} {
+		if _, err := models.SaveVersions(db, pkgs[name].ID, versions); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db, pkgs
+}
+
+func get(h *Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	if path == "/feed.atom" {
+		h.Site(w, r)
+	} else {
+		h.Feed(w, r)
+	}
+	return w
+}
+
+func TestFeedScope(t *testing.T) {
+	db, _ := fixture(t)
+	h := New(zap.NewNop(), db)
+
+	tests := []struct {
+		path     string
+		contains []string
+		excludes []string
+	}{
+		{"/feed.atom", []string{"kovardin/example@v1.1.0", "kovardin/lib@v0.1.0", "kovardin2/tool@v2.0.0"}, []string{"hidden"}},
+		{"/feed/kovardin.atom", []string{"kovardin/example@v1.1.0", "kovardin/lib@v0.1.0"}, []string{"kovardin2", "hidden"}},
+		{"/feed/kovardin2.atom", []string{"kovardin2/tool@v2.0.0"}, []string{"kovardin/"}},
+		{"/feed/kovardin/example.atom", []string{"kovardin/example@v1.0.0", "kovardin/example@v1.1.0"}, []string{"kovardin/lib", "kovardin2"}},
+	}
+
+	for _, tt := range tests {
+		w := get(h, tt.path, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: got %d, want %d", tt.path, w.Code, http.StatusOK)
+			continue
+		}
+		body := w.Body.String()
+		for _, s := range tt.contains {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s: feed has no %s", tt.path, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(body, s) {
+				t.Errorf("%s: feed has %s", tt.path, s)
+			}
+		}
+	}
+
+	// namespaces are not prefixes of each other, inactive packages are unknown
+	for _, path := range []string{"/feed/kovard.atom", "/feed/kovardin/hidden.atom", "/feed/kovardin/example", "/feed/.atom"} {
+		if w := get(h, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestFeedModified(t *testing.T) {
+	db, pkgs := fixture(t)
+	h := New(zap.NewNop(), db)
+	example := pkgs["kovardin/example"]
+
+	path := "/feed/kovardin/example.atom"
+	w := get(h, path, nil)
+	etag, modified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if etag == "" || modified == "" {
+		t.Fatalf("no validators in %v", w.Header())
+	}
+	// the package is published after its tags, so it dates the feed
+	if want := example.CreatedAt.UTC().Format(http.TimeFormat); modified != want {
+		t.Errorf("got Last-Modified %s, want %s", modified, want)
+	}
+
+	// no-op refresh: the same tags, a repository check and rebuilt docs
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	same := []models.Version{{Version: "v1.0.0", Time: day}, {Version: "v1.1.0", Time: day.AddDate(0, 1, 0)}}
+	if changed, err := models.SaveVersions(db, example.ID, same); err != nil || changed {
+		t.Fatalf("same versions: changed %t, error %v", changed, err)
+	}
+	if err := db.Model(&models.Package{}).Where("id = ?", example.ID).UpdateColumns(map[string]interface{}{"checked_at": time.Now()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// docs are not in the feed, their change bumps updated_at of the package only
+	if _, err := models.SaveDocs(db, example.ID, "# Example", "Client"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, header := range []http.Header{
+		{"If-None-Match": {etag}},
+		{"If-Modified-Since": {modified}},
+	} {
+		if w := get(h, path, header); w.Code != http.StatusNotModified {
+			t.Errorf("%v: got %d, want %d", header, w.Code, http.StatusNotModified)
+		}
+	}
+
+	// a new tag is a new entry
+	if _, err := models.SaveVersions(db, example.ID, append(same, models.Version{Version: "v1.2.0", Time: time.Now().Add(time.Hour)})); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range []http.Header{
+		{"If-None-Match": {etag}},
+		{"If-Modified-Since": {modified}},
+	} {
+		w := get(h, path, header)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "kovardin/example@v1.2.0") {
+			t.Errorf("%v: got %d, want the feed with the new version", header, w.Code)
+		}
+	}
+}
@@ -1,84 +1,238 @@
 package home
 
 import (
+	"bytes"
+	"context"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
-	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
 	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
 )
 
+const (
+	// cacheControlPackage lets caches keep pages of registered packages, admin purges them on change
+	cacheControlPackage = "public, max-age=300"
+	// cacheControlUnknown is short, the path may be registered soon
+	cacheControlUnknown = "public, max-age=60"
+)
+
+// resolveTTL bounds how long admin changes of a package are not visible,
+// admin notifies about changes but notifications are lost while the listener reconnects
+const resolveTTL = time.Minute
+
 type Handler struct {
 	logger   *logger.Logger
+	db       *gorm.DB
 	template *template.Template
-	cache    *lru.Cache[string, Meta]
+	cache    *expirable.LRU[string, entry]
+	tracker  *tracker
 }
 
-func (h *Handler) save(path string) {
+// entry is the resolved path, PackageID is zero for paths nobody registered
+type entry struct {
+	Meta      Meta
+	PackageID uint
+	Modified  time.Time
+}
+
+// resolve reports whether path looks like a package path and finds its go-import meta.
+// Registered packages are served from their repository, other paths are guessed as gitflic repositories
+func (h *Handler) resolve(ctx context.Context, path string) (entry, bool) {
 	if strings.Contains(path, ".") {
-		return
+		return entry{}, false
 	}
 
 	if strings.Count(path, "/") <= 1 {
-		return
+		return entry{}, false
 	}
 
-	h.cache.Add(path, Meta{
-		Name: "gohome.4gophers.ru" + path,
-		Repo: "https://gitflic.ru/project" + path + ".git",
-	})
+	path = strings.TrimSuffix(path, "/")
+	if e, ok := h.cache.Get(path); ok {
+		return e, true
+	}
+
+	e := entry{
+		Meta: Meta{
+			Name: models.Domain + path,
+			VCS:  models.VCSGit,
+			Repo: "https://gitflic.ru/project" + path + ".git",
+		},
+	}
+
+	if p, ok := models.Lookup(h.db.WithContext(ctx), models.Domain+path); ok {
+		e = entry{
+			Meta:      Meta{Name: p.Package, VCS: p.VCS, Repo: p.Repo},
+			PackageID: p.ID,
+			Modified:  p.UpdatedAt,
+		}
+	}
+	e.Meta.Home, e.Meta.Dir, e.Meta.File = source(e.Meta.VCS, e.Meta.Repo)
+
+	h.cache.Add(path, e)
+	return e, true
+}
+
+// Invalidate drops cached resolutions of the package and of paths inside it,
+// pkg is the package path with domain as sent by admin
+func (h *Handler) Invalidate(pkg string) {
+	rel := strings.TrimPrefix(pkg, models.Domain)
+	for _, path := range h.cache.Keys() {
+		if path == rel || strings.HasPrefix(path, rel+"/") {
+			h.cache.Remove(path)
+		}
+	}
+}
+
+// Reset drops all cached resolutions, notifications may have been missed
+func (h *Handler) Reset() {
+	h.cache.Purge()
 }
 
-func New(logger *logger.Logger) *Handler {
-	l, _ := lru.New[string, Meta](1024)
+// track counts resolutions of the go command, paths nobody registered are shown on admin dashboard
+func (h *Handler) track(path string, e entry) {
+	// packages inside the module are counted for the module
+	path = models.Domain + strings.TrimSuffix(path, "/")
+	if e.PackageID != 0 {
+		path = e.Meta.Name
+	}
+
+	h.tracker.add(path, e.PackageID)
+}
 
+func New(lc fx.Lifecycle, logger *logger.Logger, db *gorm.DB) *Handler {
 	t, err := template.New("test").Parse(tmpl)
 	if err != nil {
 		panic(err)
 	}
 
-	return &Handler{
+	h := &Handler{
 		logger:   logger,
+		db:       db,
 		template: t,
-		cache:    l,
+		cache:    expirable.NewLRU[string, entry](1024, nil, resolveTTL),
+		tracker:  newTracker(db, logger),
 	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			h.tracker.start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return h.tracker.shutdown(ctx)
+		},
+	})
+
+	return h
 }
 
 func (h *Handler) Home(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("url path", zap.String("path", r.URL.Path))
+	logger.For(r.Context(), h.logger).Info("url path", zap.String("path", r.URL.Path))
 
 	path := r.URL.Path
 
-	h.save(path)
+	// registered packages are revalidated by their update time
+	var (
+		meta         *Meta
+		modified     time.Time
+		cacheControl = cacheControlUnknown
+	)
+	if e, ok := h.resolve(r.Context(), path); ok {
+		meta = &e.Meta
+		if e.PackageID != 0 {
+			modified = e.Modified
+			cacheControl = cacheControlPackage
+		}
+
+		if r.URL.Query().Get("go-get") == "1" {
+			h.track(path, e)
+		}
+	}
 
-	data := h.cache.Values()
+	data := struct {
+		Meta *Meta
+		T    *locales.Messages
+	}{
+		Meta: meta,
+		T:    locales.Get(r),
+	}
 
-	if err := h.template.Execute(w, data); err != nil {
-		h.logger.Error("error on render template", zap.Error(err))
+	buf := &bytes.Buffer{}
+	if err := h.template.Execute(buf, data); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on render template", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if httpcache.Check(w, r, cacheControl, httpcache.ETag(buf.Bytes()), modified) {
+		return
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on write page", zap.Error(err))
+	}
 }
 
+// Meta is the go-import tag: import path prefix, VCS type and repository or module proxy url,
+// and go-source tag: repository home page, directory and file url templates
 type Meta struct {
 	Name string
+	VCS  string
 	Repo string
+
+	Home string
+	Dir  string
+	File string
+}
+
+// source builds go-source templates for hosts with known url layout,
+// other git repositories get only the home page, "_" marks unknown templates
+func source(vcs, repo string) (home, dir, file string) {
+	if vcs != models.VCSGit || !strings.HasPrefix(repo, "http") {
+		return "", "", ""
+	}
+
+	home = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	u, err := url.Parse(home)
+	if err != nil {
+		return "", "", ""
+	}
+
+	switch u.Host {
+	case "github.com":
+		return home, home + "/tree/HEAD{/dir}", home + "/blob/HEAD{/dir}/{file}#L{line}"
+	case "gitlab.com":
+		return home, home + "/-/tree/HEAD{/dir}", home + "/-/blob/HEAD{/dir}/{file}#L{line}"
+	}
+
+	return home, "_", "_"
 }
 
 var tmpl = `<!doctype html>
-<html lang="en">
+<html lang="{{.T.Lang}}">
   <head>
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1">
     <meta name="description" content="">
 
-	{{range .}}
-	<meta name="go-import" content="{{.Name}} git {{.Repo}}">
+	{{with .Meta}}
+	<meta name="go-import" content="{{.Name}} {{.VCS}} {{.Repo}}">
+	{{if .Home}}<meta name="go-source" content="{{.Name}} {{.Home}} {{.Dir}} {{.File}}">{{end}}
 	{{ end }}
-    <title>Go Home</title>    
+    <title>Go Home</title>
+    <link rel="alternate" type="application/atom+xml" title="Go Home" href="/feed.atom">    
 
     <!-- Bootstrap core CSS -->
 <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-EVSTQN3/azprG1Anm3QDgpJLIm9Nao0Yz1ztcQTwFspd3yD65VohhpuuCOmLASjC" crossorigin="anonymous">
@@ -220,20 +374,21 @@ l-16 -56 -80 -34 c-118 -50 -181 -94 -280 -198 -97 -103 -127 -152 -127 -209
 </svg>
       <span class="fs-4">&nbsp;&nbsp;Go Home</span>
     </a>
+    <a href="?lang={{.T.SwitchTo}}" class="ms-auto text-muted">{{.T.Switch}}</a>
   </header>
 
   <main>
-    <h1>Домен для Go пакетов</h1>
+    <h1>{{.T.HomeTitle}}</h1>
 	<p></p>
     <p class="fs-5 col-md-10">
-	  Очень легко устанавливать Go пакеты с гитхаба. Но все сложнее если библиотека лежит на gitflic.ru. Тут обычный <code>go get</code> не сработает.
+	  {{.T.HomeIntro}}
 	</p>
 	<p class="fs-5 col-md-10">
-	  Для этого есть <code>Go Home</code>. С его помощью пакеты можно устанавливать через <code>go get</code>.
+	  {{.T.HomeTool}}
 	</p>
 
 	<p class="fs-5 col-md-10">
-	  Для начала нужно создать пакет, название которого будет начинаться с <code>gohome.4gophers.ru</code>:
+	  {{.T.HomeInit}}
 
 <pre>
 go mod init gohome.4gophers.ru/kovardin/example
@@ -241,7 +396,7 @@ go mod init gohome.4gophers.ru/kovardin/example
 	
 	</p>
 	<p class="fs-5 col-md-10">  
-	  После этого создать репозиторий на gitflic: 
+	  {{.T.HomeRepo}}
 <pre>
 https://gitflic.ru/project/kovardin/example
 </pre>
@@ -249,7 +404,7 @@ https://gitflic.ru/project/kovardin/example
 	</p>
 
 	<p class="fs-5 col-md-8">
-	Теперь вы можете указывать в своем коде зависимость
+	{{.T.HomeImport}}
 
 <pre>
 package main
@@ -267,37 +422,35 @@ func main() {
 	</p>
 
 	<p class="fs-5 col-md-10">
-	Go разрулит зависимости через meta теги на этом сайте и установит зависимость.
+	{{.T.HomeResolve}}
 	</p>
 
     <hr class="col-3 col-md-2 mb-5">
 
     <div class="row g-5">
       <div class="col-md-6">
-        <h2>Ссылки</h2>
-        <p>Как работает и что за giflic такой.</p>
+        <h2>{{.T.LinksTitle}}</h2>
+        <p>{{.T.LinksInfo}}</p>
         <ul class="icon-list">
-          <li><a href="https://gitflic.ru/project/getapp/gohome" rel="noopener" target="_blank">Исходники сервиса</a></li>
-          <li><a href="https://pkg.go.dev/cmd/go#hdr-Remote_import_paths" rel="noopener" target="_blank">Как работает импорт пакетов</a></li>
-          <li><a href="https://gitflic.ru/user/kovardin" rel="noopener" target="_blank">Да кто это ваш giflic</a></li>
-          <li><a href="https://t.me/kodikapusta" rel="noopener" target="_blank">Код и капуста</a></li>
+          {{range .T.Links}}
+          <li><a href="{{.URL}}" rel="noopener" target="_blank">{{.Title}}</a></li>
+          {{end}}
         </ul>
       </div>
 
       <div class="col-md-6">
-        <h2>Статьи</h2>
-        <p>Еще немножко почитать на разные темы.</p>
+        <h2>{{.T.ArticlesTitle}}</h2>
+        <p>{{.T.ArticlesInfo}}</p>
         <ul class="icon-list">
-          <li><a href="https://kovardin.ru/articles/ads/mediation/">Про мобильную медиацию</a></li>
-          <li><a href="https://kovardin.ru/articles/mobile/boosty-android/">Как использовать boosty для мобильного приложения</a></li>
-          <li><a href="https://kovardin.ru/articles/godot/mytacker-and-app-metrica/">Подключаем MyTracker и AppMetrica к игре на Godot</a></li>
-          <li><a href="https://kovardin.ru/articles/godot/first-game/">Первая игра на Godot</a></li>
+          {{range .T.Articles}}
+          <li><a href="{{.URL}}">{{.Title}}</a></li>
+          {{end}}
         </ul>
       </div>
     </div>
   </main>
   <footer class="pt-5 my-5 text-muted border-top">
-    Ковардин Артем &middot; &copy; 2024
+    {{.T.Footer}} &middot; &copy; 2024
   </footer>
 </div>
 
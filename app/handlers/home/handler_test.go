@@ -0,0 +1,104 @@
+package home
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		vcs, repo       string
+		home, dir, file string
+	}{
+		{models.VCSGit, "https://github.com/kovardin/example.git", "https://github.com/kovardin/example", "https://github.com/kovardin/example/tree/HEAD{/dir}", "https://github.com/kovardin/example/blob/HEAD{/dir}/{file}#L{line}"},
+		{models.VCSGit, "https://gitlab.com/kovardin/example", "https://gitlab.com/kovardin/example", "https://gitlab.com/kovardin/example/-/tree/HEAD{/dir}", "https://gitlab.com/kovardin/example/-/blob/HEAD{/dir}/{file}#L{line}"},
+		{models.VCSGit, "https://gitflic.ru/project/kovardin/example.git", "https://gitflic.ru/project/kovardin/example", "_", "_"},
+		{models.VCSGit, "ssh://git@gitflic.ru/kovardin/example.git", "", "", ""},
+		{models.VCSHg, "https://hg.example.com/example", "", "", ""},
+		{models.VCSSvn, "svn://svn.example.com/example", "", "", ""},
+		{models.VCSMod, "https://proxy.example.com", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		home, dir, file := source(tt.vcs, tt.repo)
+		if home != tt.home || dir != tt.dir || file != tt.file {
+			t.Errorf("%s %s: got %q %q %q", tt.vcs, tt.repo, home, dir, file)
+		}
+	}
+}
+
+// newTestHandler is not started, tracked resolutions stay in memory
+func newTestHandler(db *gorm.DB) *Handler {
+	return &Handler{
+		logger:   zap.NewNop(),
+		db:       db,
+		template: template.Must(template.New("test").Parse(tmpl)),
+		cache:    expirable.NewLRU[string, entry](1024, nil, resolveTTL),
+		tracker:  newTracker(db, zap.NewNop()),
+	}
+}
+
+func TestHomeUnknown(t *testing.T) {
+	// dry run finds no packages, unknown paths are guessed as gitflic repositories
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(db)
+
+	w := httptest.NewRecorder()
+	h.Home(w, httptest.NewRequest(http.MethodGet, "/kovardin/example/sub?go-get=1", nil))
+
+	want := `<meta name="go-import" content="gohome.4gophers.ru/kovardin/example/sub git https://gitflic.ru/project/kovardin/example/sub.git">`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("page has no %s", want)
+	}
+}
+
+func TestHomeVCS(t *testing.T) {
+	db := dbtest.Open(t, &models.Package{})
+
+	pkgs := []models.Package{
+		{Package: models.Domain + "/kovardin/git", VCS: models.VCSGit, Repo: "https://github.com/kovardin/git.git", Active: true},
+		{Package: models.Domain + "/kovardin/hg", VCS: models.VCSHg, Repo: "https://hg.example.com/hg", Active: true},
+		{Package: models.Domain + "/kovardin/svn", VCS: models.VCSSvn, Repo: "svn://svn.example.com/svn", Active: true},
+		{Package: models.Domain + "/kovardin/bzr", VCS: models.VCSBzr, Repo: "bzr+ssh://bzr.example.com/bzr", Active: true},
+		{Package: models.Domain + "/kovardin/fossil", VCS: models.VCSFossil, Repo: "https://fossil.example.com/fossil", Active: true},
+		{Package: models.Domain + "/kovardin/mod", VCS: models.VCSMod, Repo: "https://proxy.example.com", Active: true},
+	}
+	if err := db.Create(&pkgs).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(db)
+
+	for _, p := range pkgs {
+		rel := strings.TrimPrefix(p.Package, models.Domain)
+		for _, path := range []string{rel, rel + "/inner"} {
+			w := httptest.NewRecorder()
+			h.Home(w, httptest.NewRequest(http.MethodGet, path+"?go-get=1", nil))
+
+			// the go command decodes entities, html/template escapes "+" of bzr+ssh
+			body := html.UnescapeString(w.Body.String())
+			want := `<meta name="go-import" content="` + p.Package + ` ` + p.VCS + ` ` + p.Repo + `">`
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: page has no %s", path, want)
+			}
+			// only git repositories on known hosts have source links
+			if source := strings.Contains(body, `name="go-source"`); source != (p.VCS == models.VCSGit) {
+				t.Errorf("%s: go-source is %t", path, source)
+			}
+		}
+	}
+}
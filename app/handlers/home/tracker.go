@@ -0,0 +1,155 @@
+package home
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// flushInterval is how often counted resolutions are written, they are lost on crash
+	flushInterval = 10 * time.Second
+	// maxUnknown limits paths nobody registered counted per day by one server,
+	// random paths would grow resolutions without bound
+	maxUnknown = 1000
+)
+
+type trackKey struct {
+	day  time.Time
+	path string
+}
+
+// tracker counts resolutions in memory and writes them in batches,
+// so requests of the go command don't wait for the database
+type tracker struct {
+	db     *gorm.DB
+	logger *logger.Logger
+
+	mu     sync.Mutex
+	counts map[trackKey]*models.Resolution
+	// unknown are paths nobody registered counted today
+	day     time.Time
+	unknown map[string]struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTracker(db *gorm.DB, logger *logger.Logger) *tracker {
+	return &tracker{
+		db:      db,
+		logger:  logger,
+		counts:  map[trackKey]*models.Resolution{},
+		unknown: map[string]struct{}{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// add counts resolution of the path, paths nobody registered are skipped after maxUnknown a day
+func (t *tracker) add(path string, packageID uint) {
+	now := time.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !day.Equal(t.day) {
+		t.day = day
+		t.unknown = map[string]struct{}{}
+	}
+	if packageID == 0 {
+		if _, ok := t.unknown[path]; !ok {
+			if len(t.unknown) >= maxUnknown {
+				return
+			}
+			t.unknown[path] = struct{}{}
+		}
+	}
+
+	k := trackKey{day: day, path: path}
+	r, ok := t.counts[k]
+	if !ok {
+		r = &models.Resolution{Day: day, Path: path}
+		t.counts[k] = r
+	}
+	r.Count++
+	r.PackageID = packageID
+	r.UpdatedAt = now
+}
+
+// flush writes counted resolutions, counts are kept for the next flush on error
+func (t *tracker) flush(ctx context.Context) error {
+	t.mu.Lock()
+	counts := t.counts
+	t.counts = map[trackKey]*models.Resolution{}
+	t.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	rs := make([]models.Resolution, 0, len(counts))
+	for _, r := range counts {
+		rs = append(rs, *r)
+	}
+
+	if err := models.Track(t.db.WithContext(ctx), rs); err != nil {
+		t.restore(counts)
+		return err
+	}
+	return nil
+}
+
+// restore returns unwritten counts, resolutions counted meanwhile are added to them
+func (t *tracker) restore(counts map[trackKey]*models.Resolution) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k, r := range t.counts {
+		if old, ok := counts[k]; ok {
+			r.Count += old.Count
+		}
+		counts[k] = r
+	}
+	t.counts = counts
+}
+
+func (t *tracker) start() {
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				if err := t.flush(context.Background()); err != nil {
+					t.logger.Error("error on track resolutions", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// shutdown stops periodic flushes and writes the rest
+func (t *tracker) shutdown(ctx context.Context) error {
+	close(t.stop)
+
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return t.flush(ctx)
+}
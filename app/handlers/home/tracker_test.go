@@ -0,0 +1,143 @@
+package home
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var errQuery = errors.New("connection refused")
+
+// pool records queries and fails them like unreachable database
+type pool struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (p *pool) record(query string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries = append(p.queries, query)
+}
+
+func (p *pool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errQuery
+}
+
+func (p *pool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.record(query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.record(query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func newTestTracker(t *testing.T) (*tracker, *pool) {
+	p := &pool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: p}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTracker(db, zap.NewNop()), p
+}
+
+func TestTrackerCounts(t *testing.T) {
+	tr, _ := newTestTracker(t)
+
+	for i := 0; i < 3; i++ {
+		tr.add("gohome.4gophers.ru/kovardin/example", 7)
+	}
+	tr.add("gohome.4gophers.ru/unknown/path", 0)
+
+	if len(tr.counts) != 2 {
+		t.Fatalf("expected 2 paths, got %d", len(tr.counts))
+	}
+	for k, r := range tr.counts {
+		switch k.path {
+		case "gohome.4gophers.ru/kovardin/example":
+			if r.Count != 3 || r.PackageID != 7 {
+				t.Errorf("unexpected resolution %+v", r)
+			}
+		case "gohome.4gophers.ru/unknown/path":
+			if r.Count != 1 || r.PackageID != 0 {
+				t.Errorf("unexpected resolution %+v", r)
+			}
+		}
+	}
+}
+
+func TestTrackerUnknownLimit(t *testing.T) {
+	tr, _ := newTestTracker(t)
+
+	for i := 0; i < maxUnknown+10; i++ {
+		tr.add(fmt.Sprintf("gohome.4gophers.ru/random/%d", i), 0)
+	}
+	// counted paths are still counted, registered packages are never skipped
+	tr.add("gohome.4gophers.ru/random/0", 0)
+	tr.add("gohome.4gophers.ru/kovardin/example", 7)
+
+	if len(tr.counts) != maxUnknown+1 {
+		t.Errorf("expected %d paths, got %d", maxUnknown+1, len(tr.counts))
+	}
+	for k, r := range tr.counts {
+		if k.path == "gohome.4gophers.ru/random/0" && r.Count != 2 {
+			t.Errorf("counted unknown path is skipped, count %d", r.Count)
+		}
+	}
+}
+
+func TestTrackerFlush(t *testing.T) {
+	tr, p := newTestTracker(t)
+	ctx := context.Background()
+
+	if err := tr.flush(ctx); err != nil {
+		t.Fatalf("empty flush: %s", err)
+	}
+	if len(p.queries) != 0 {
+		t.Fatalf("empty flush queries database: %v", p.queries)
+	}
+
+	tr.add("gohome.4gophers.ru/kovardin/example", 7)
+	tr.add("gohome.4gophers.ru/kovardin/example", 7)
+	tr.add("gohome.4gophers.ru/kovardin/other", 8)
+
+	if err := tr.flush(ctx); !errors.Is(err, errQuery) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// all paths are written with one query
+	if len(p.queries) != 1 {
+		t.Fatalf("expected one query, got %d", len(p.queries))
+	}
+	if q := p.queries[0]; !strings.Contains(q, `ON CONFLICT ("day","path") DO UPDATE SET`) || !strings.Contains(q, `"count"=resolutions.count + excluded.count`) {
+		t.Errorf("unexpected query %s", q)
+	}
+
+	// failed counts are kept and merged with new ones
+	tr.add("gohome.4gophers.ru/kovardin/example", 7)
+	for k, r := range tr.counts {
+		if k.path == "gohome.4gophers.ru/kovardin/example" && r.Count != 3 {
+			t.Errorf("expected 3 resolutions after failed flush, got %d", r.Count)
+		}
+	}
+	if len(tr.counts) != 2 {
+		t.Errorf("expected 2 paths after failed flush, got %d", len(tr.counts))
+	}
+}
@@ -0,0 +1,9 @@
+package search
+
+import "go.uber.org/fx"
+
+var Search = fx.Module("search",
+	fx.Provide(
+		New,
+	),
+)
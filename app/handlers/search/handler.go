@@ -0,0 +1,196 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	limit        = 50
+	cacheControl = "public, max-age=60"
+)
+
+type Handler struct {
+	logger   *logger.Logger
+	db       *gorm.DB
+	template *template.Template
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	t, err := template.New("search").Parse(tmpl)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Handler{
+		logger:   logger,
+		db:       db,
+		template: t,
+	}
+}
+
+type Result struct {
+	Package string `json:"package"`
+	Title   string `json:"title"`
+	Info    string `json:"info"`
+	Repo    string `json:"repo"`
+	// Path is the package path on this site
+	Path string `json:"-"`
+}
+
+// Search render html page with search results
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	results, err := h.search(r.Context(), q)
+	if err != nil {
+		logger.For(r.Context(), h.logger).Error("error on search packages", zap.String("q", q), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Query   string
+		Results []Result
+		T       *locales.Messages
+	}{
+		Query:   q,
+		Results: results,
+		T:       locales.Get(r),
+	}
+
+	buf := &bytes.Buffer{}
+	if err := h.template.Execute(buf, data); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on render template", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	h.write(w, r, buf.Bytes())
+}
+
+// JSON return search results as json
+func (h *Handler) JSON(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	results, err := h.search(r.Context(), q)
+	if err != nil {
+		logger.For(r.Context(), h.logger).Error("error on search packages", zap.String("q", q), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(results); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on encode results", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.write(w, r, buf.Bytes())
+}
+
+// write answers 304 when results did not change, results depend on many packages,
+// so the body is the validator instead of update time
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, body []byte) {
+	if httpcache.Check(w, r, cacheControl, httpcache.ETag(body), time.Time{}) {
+		return
+	}
+
+	if _, err := w.Write(body); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on write results", zap.Error(err))
+	}
+}
+
+func (h *Handler) search(ctx context.Context, q string) ([]Result, error) {
+	results := []Result{}
+	if q == "" {
+		return results, nil
+	}
+
+	packages := []models.Package{}
+	err := h.db.WithContext(ctx).
+		Where("active = ?", true).
+		Scopes(models.Search(q)).
+		Limit(limit).
+		Find(&packages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range packages {
+		results = append(results, Result{
+			Package: p.Package,
+			Title:   p.Title,
+			Info:    p.Info,
+			Repo:    p.Repo,
+			Path:    strings.TrimPrefix(p.Package, models.Domain),
+		})
+	}
+
+	return results, nil
+}
+
+var tmpl = `<!doctype html>
+<html lang="{{.T.Lang}}">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{.T.SearchTitle}} · Go Home</title>
+    <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css" rel="stylesheet" integrity="sha384-EVSTQN3/azprG1Anm3QDgpJLIm9Nao0Yz1ztcQTwFspd3yD65VohhpuuCOmLASjC" crossorigin="anonymous">
+  </head>
+  <body>
+<div class="col-lg-8 mx-auto p-3 py-md-5">
+  <header class="d-flex align-items-center pb-3 mb-5 border-bottom">
+    <a href="/" class="d-flex align-items-center text-dark text-decoration-none">
+      <span class="fs-4">Go Home</span>
+    </a>
+    <a href="?q={{.Query}}&lang={{.T.SwitchTo}}" class="ms-auto text-muted">{{.T.Switch}}</a>
+  </header>
+
+  <main>
+    <form class="mb-5" action="/search" method="get">
+      <div class="input-group">
+        <input type="search" class="form-control" name="q" value="{{.Query}}" placeholder="{{.T.SearchPlaceholder}}">
+        <button class="btn btn-primary" type="submit">{{.T.SearchButton}}</button>
+      </div>
+    </form>
+
+    {{if .Query}}
+      {{if .Results}}
+      <ul class="list-unstyled">
+        {{range .Results}}
+        <li class="mb-4">
+          <h5><a href="https://{{.Package}}">{{.Package}}</a></h5>
+          {{if .Title}}<div class="fw-bold">{{.Title}}</div>{{end}}
+          {{if .Info}}<div class="text-muted">{{.Info}}</div>{{end}}
+          <code>go get {{.Package}}</code>
+          <a href="/doc{{.Path}}" class="ms-2">{{$.T.DocTitle}}</a>
+          <a href="/changelog{{.Path}}" class="ms-2">{{$.T.SearchChangelog}}</a>
+        </li>
+        {{end}}
+      </ul>
+      {{else}}
+      <p class="fs-5">{{.T.SearchNotFound}} <code>{{.Query}}</code>.</p>
+      {{end}}
+    {{end}}
+  </main>
+</div>
+  </body>
+</html>
+`
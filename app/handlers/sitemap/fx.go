@@ -0,0 +1,9 @@
+package sitemap
+
+import "go.uber.org/fx"
+
+var Sitemap = fx.Module("sitemap",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,177 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	xsitemap "github.com/qor5/x/v3/sitemap"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// limit is the maximum number of urls in one sitemap file,
+	// one url on every page is left for the landing page
+	limit   = 50000
+	perPage = limit - 1
+
+	base         = "https://" + models.Domain
+	cacheControl = "public, max-age=3600"
+)
+
+// Disallow are paths hidden from crawlers: admin, json api, badges
+// and requests of the go command and module proxy protocol
+var Disallow = []string{
+	"/admin",
+	"/search.json",
+	"/badge/",
+	"/*?go-get=",
+	"/*/@v/",
+	"/*/@latest",
+}
+
+// Pages are prefixes of package pages: the package itself, its documentation and changelog
+var Pages = []string{"", "/doc", "/changelog"}
+
+type Handler struct {
+	logger *logger.Logger
+	db     *gorm.DB
+	robots *xsitemap.RobotsBuilder
+	pages  []string
+}
+
+func New(logger *logger.Logger, db *gorm.DB) *Handler {
+	robots := xsitemap.Robots()
+	robots.Agent(xsitemap.AllAgents).
+		Disallow(Disallow...).
+		AddSitemapUrl(base + "/sitemap.xml")
+
+	return &Handler{
+		logger: logger,
+		db:     db,
+		robots: robots,
+		pages:  Pages,
+	}
+}
+
+// Static lists only package pages, static export has no documentation and changelogs
+func (h *Handler) Static() *Handler {
+	s := *h
+	s.pages = Pages[:1]
+	return &s
+}
+
+// Robots render robots.txt
+func (h *Handler) Robots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", cacheControl)
+	h.robots.ServeHTTP(w, r)
+}
+
+// Sitemap render sitemap of online packages, or sitemap index
+// pointing to /sitemap-N.xml pages when there are too many packages
+func (h *Handler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	var count int64
+	if err := h.online(r.Context()).Count(&count).Error; err != nil {
+		logger.For(r.Context(), h.logger).Error("error on count packages", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	size := int64(h.size())
+	if count <= size {
+		h.page(w, r, 1)
+		return
+	}
+
+	index := sitemapIndex{}
+	for i := 1; i <= int((count+size-1)/size); i++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapLoc{
+			Loc: fmt.Sprintf("%s/sitemap-%d.xml", base, i),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml;charset=UTF-8")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(index); err != nil {
+		logger.For(r.Context(), h.logger).Error("error on encode sitemap index", zap.Error(err))
+	}
+}
+
+// Page render one page of sitemap index, pages start from 1
+func (h *Handler) Page(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(chi.URLParam(r, "page"))
+	if err != nil || page < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.page(w, r, page)
+}
+
+func (h *Handler) page(w http.ResponseWriter, r *http.Request, page int) {
+	packages := []models.Package{}
+	err := h.online(r.Context()).
+		Select("package", "updated_at").
+		Order("id").
+		Offset((page - 1) * h.size()).
+		Limit(h.size()).
+		Find(&packages).Error
+	if err != nil {
+		logger.For(r.Context(), h.logger).Error("error on load packages", zap.Int("page", page), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(packages) == 0 && page > 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	urls := []xsitemap.URL{}
+	if page == 1 {
+		urls = append(urls, xsitemap.URL{Loc: base + "/", Changefreq: xsitemap.FreqWeekly})
+	}
+
+	for _, p := range packages {
+		rel := strings.TrimPrefix(p.Package, models.Domain)
+		for _, prefix := range h.pages {
+			urls = append(urls, xsitemap.URL{
+				Loc:     base + prefix + rel,
+				LastMod: p.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	xsitemap.SiteMap().RegisterURL(urls...).ServeHTTP(w, r)
+}
+
+// size is the number of packages on one sitemap page
+func (h *Handler) size() int {
+	return perPage / len(h.pages)
+}
+
+func (h *Handler) online(ctx context.Context) *gorm.DB {
+	return h.db.WithContext(ctx).Model(&models.Package{}).Where("active = ?", true)
+}
+
+// sitemapIndex is encoded here, index of qor5 sitemap joins host with path.Join
+// and breaks scheme of absolute urls
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
@@ -0,0 +1,87 @@
+package sitemap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestRobots(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(zap.NewNop(), nil).Robots(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	for _, s := range []string{"Disallow: /admin", "Disallow: /search.json", "Disallow: /*?go-get=", "Disallow: /*/@v/", "Sitemap: " + base + "/sitemap.xml"} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("robots.txt has no %q:\n%s", s, w.Body.String())
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	h := New(zap.NewNop(), nil)
+
+	if got := h.size() * len(Pages); got > perPage {
+		t.Errorf("page has %d urls, limit is %d", got, perPage)
+	}
+	if got := h.Static().size(); got != perPage {
+		t.Errorf("static page has %d packages, want %d", got, perPage)
+	}
+	if h.size() == h.Static().size() {
+		t.Error("static copy changed the handler")
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	db := dbtest.Open(t, &models.Package{})
+
+	packages := []models.Package{
+		{Package: models.Domain + "/kovardin/example", Active: true},
+		{Package: models.Domain + "/kovardin/hidden", Active: false},
+	}
+	if err := db.Create(&packages).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := New(zap.NewNop(), db)
+
+	tests := []struct {
+		name     string
+		handler  *Handler
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "public",
+			handler:  h,
+			contains: []string{base + "/kovardin/example<", base + "/doc/kovardin/example<", base + "/changelog/kovardin/example<"},
+			excludes: []string{"hidden"},
+		},
+		{
+			name:     "static",
+			handler:  h.Static(),
+			contains: []string{base + "/kovardin/example<"},
+			excludes: []string{"hidden", "/doc/", "/changelog/"},
+		},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler.Sitemap(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+		for _, s := range tt.contains {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("%s: sitemap has no %q", tt.name, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(w.Body.String(), s) {
+				t.Errorf("%s: sitemap has %q", tt.name, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package locales
+
+import (
+	"net/http"
+
+	"github.com/qor5/x/v3/i18n"
+	"golang.org/x/text/language"
+)
+
+// ModuleKey public site messages
+const ModuleKey i18n.ModuleKey = "gohome"
+
+// New create translation bundles, russian is the default language
+func New() *i18n.Builder {
+	b := i18n.New().
+		SupportLanguages(language.Russian, language.English).
+		RegisterForModule(language.Russian, ModuleKey, Messages_ru).
+		RegisterForModule(language.English, ModuleKey, Messages_en)
+
+	return b
+}
+
+// Middleware choose language by "lang" query, cookie or Accept-Language header
+func Middleware(b *i18n.Builder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return b.EnsureLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the same url is rendered differently for different clients
+			w.Header().Add("Vary", "Accept-Language, Cookie")
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// Get return messages for current request language
+func Get(r *http.Request) *Messages {
+	return i18n.MustGetModuleMessages(r, ModuleKey, Messages_ru).(*Messages)
+}
@@ -0,0 +1,161 @@
+package locales
+
+import "html/template"
+
+type Link struct {
+	Title string
+	URL   string
+}
+
+// Messages texts of public pages, fields with html are trusted and rendered as is
+type Messages struct {
+	Lang     string
+	Switch   string
+	SwitchTo string
+
+	HomeTitle     string
+	HomeIntro     template.HTML
+	HomeTool      template.HTML
+	HomeInit      template.HTML
+	HomeRepo      string
+	HomeImport    string
+	HomeResolve   string
+	LinksTitle    string
+	LinksInfo     string
+	Links         []Link
+	ArticlesTitle string
+	ArticlesInfo  string
+	Articles      []Link
+	Footer        string
+
+	SearchTitle       string
+	SearchPlaceholder string
+	SearchButton      string
+	SearchNotFound    string
+	SearchChangelog   string
+
+	ChangelogTitle           string
+	ChangelogEmpty           string
+	ChangelogNotFound        string
+	ChangelogVersionNotFound string
+	ChangelogMajor           string
+	ChangelogPrerelease      string
+	ChangelogPrevious        string
+
+	DocTitle    string
+	DocEmpty    string
+	DocNotFound string
+	DocSymbols  string
+
+	FeedTitle   string
+	FeedScope   string
+	FeedPackage string
+	FeedVersion string
+}
+
+var articles = []Link{
+	{Title: "Про мобильную медиацию", URL: "https://kovardin.ru/articles/ads/mediation/"},
+	{Title: "Как использовать boosty для мобильного приложения", URL: "https://kovardin.ru/articles/mobile/boosty-android/"},
+	{Title: "Подключаем MyTracker и AppMetrica к игре на Godot", URL: "https://kovardin.ru/articles/godot/mytacker-and-app-metrica/"},
+	{Title: "Первая игра на Godot", URL: "https://kovardin.ru/articles/godot/first-game/"},
+}
+
+var Messages_ru = &Messages{
+	Lang:     "ru",
+	Switch:   "English",
+	SwitchTo: "en",
+
+	HomeTitle:   "Домен для Go пакетов",
+	HomeIntro:   "Очень легко устанавливать Go пакеты с гитхаба. Но все сложнее если библиотека лежит на gitflic.ru. Тут обычный <code>go get</code> не сработает.",
+	HomeTool:    "Для этого есть <code>Go Home</code>. С его помощью пакеты можно устанавливать через <code>go get</code>.",
+	HomeInit:    "Для начала нужно создать пакет, название которого будет начинаться с <code>gohome.4gophers.ru</code>:",
+	HomeRepo:    "После этого создать репозиторий на gitflic:",
+	HomeImport:  "Теперь вы можете указывать в своем коде зависимость",
+	HomeResolve: "Go разрулит зависимости через meta теги на этом сайте и установит зависимость.",
+	LinksTitle:  "Ссылки",
+	LinksInfo:   "Как работает и что за giflic такой.",
+	Links: []Link{
+		{Title: "Исходники сервиса", URL: "https://gitflic.ru/project/getapp/gohome"},
+		{Title: "Как работает импорт пакетов", URL: "https://pkg.go.dev/cmd/go#hdr-Remote_import_paths"},
+		{Title: "Да кто это ваш giflic", URL: "https://gitflic.ru/user/kovardin"},
+		{Title: "Код и капуста", URL: "https://t.me/kodikapusta"},
+	},
+	ArticlesTitle: "Статьи",
+	ArticlesInfo:  "Еще немножко почитать на разные темы.",
+	Articles:      articles,
+	Footer:        "Ковардин Артем",
+
+	SearchTitle:       "Поиск пакетов",
+	SearchPlaceholder: "Название пакета, описание или функция",
+	SearchButton:      "Найти",
+	SearchNotFound:    "Ничего не найдено по запросу",
+	SearchChangelog:   "Журнал изменений",
+
+	ChangelogTitle:           "Журнал изменений",
+	ChangelogEmpty:           "У пакета пока нет версий.",
+	ChangelogNotFound:        "Пакет не найден.",
+	ChangelogVersionNotFound: "Такой версии у пакета нет.",
+	ChangelogMajor:           "новая мажорная версия",
+	ChangelogPrerelease:      "предрелиз",
+	ChangelogPrevious:        "Предыдущая версия",
+
+	DocTitle:    "Документация",
+	DocEmpty:    "В репозитории пакета нет README.",
+	DocNotFound: "Пакет не найден.",
+	DocSymbols:  "Экспортируемые имена",
+
+	FeedTitle:   "Go Home: новые пакеты и версии",
+	FeedScope:   "Новые версии %s",
+	FeedPackage: "Новый пакет %s",
+	FeedVersion: "%s %s",
+}
+
+var Messages_en = &Messages{
+	Lang:     "en",
+	Switch:   "Русский",
+	SwitchTo: "ru",
+
+	HomeTitle:   "Domain for Go packages",
+	HomeIntro:   "It is easy to install Go packages from GitHub. But it is harder when the library is hosted on gitflic.ru, plain <code>go get</code> does not work there.",
+	HomeTool:    "That is what <code>Go Home</code> is for. With it packages can be installed with <code>go get</code>.",
+	HomeInit:    "First create a module whose name starts with <code>gohome.4gophers.ru</code>:",
+	HomeRepo:    "Then create a repository on gitflic:",
+	HomeImport:  "Now you can import the dependency in your code",
+	HomeResolve: "Go resolves the dependency through meta tags on this site and installs it.",
+	LinksTitle:  "Links",
+	LinksInfo:   "How it works and what gitflic is.",
+	Links: []Link{
+		{Title: "Service sources", URL: "https://gitflic.ru/project/getapp/gohome"},
+		{Title: "How remote import paths work", URL: "https://pkg.go.dev/cmd/go#hdr-Remote_import_paths"},
+		{Title: "Who is this gitflic", URL: "https://gitflic.ru/user/kovardin"},
+		{Title: "Code and cabbage", URL: "https://t.me/kodikapusta"},
+	},
+	ArticlesTitle: "Articles",
+	ArticlesInfo:  "A bit more to read, in Russian.",
+	Articles:      articles,
+	Footer:        "Artem Kovardin",
+
+	SearchTitle:       "Package search",
+	SearchPlaceholder: "Package name, description or function",
+	SearchButton:      "Search",
+	SearchNotFound:    "Nothing found for",
+	SearchChangelog:   "Changelog",
+
+	ChangelogTitle:           "Changelog",
+	ChangelogEmpty:           "The package has no versions yet.",
+	ChangelogNotFound:        "Package not found.",
+	ChangelogVersionNotFound: "The package has no such version.",
+	ChangelogMajor:           "major version",
+	ChangelogPrerelease:      "pre-release",
+	ChangelogPrevious:        "Previous version",
+
+	DocTitle:    "Documentation",
+	DocEmpty:    "The package repository has no README.",
+	DocNotFound: "Package not found.",
+	DocSymbols:  "Exported names",
+
+	FeedTitle:   "Go Home: new packages and versions",
+	FeedScope:   "New versions of %s",
+	FeedPackage: "New package %s",
+	FeedVersion: "%s %s",
+}
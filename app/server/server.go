@@ -3,18 +3,29 @@ package server
 import (
 	"context"
 	"errors"
-	"log"
-	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/httplog/v2"
+	"github.com/qor5/x/v3/i18n"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/badge"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/changelog"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/doc"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/feed"
 	"gohome.4gophers.ru/getapp/gohome/app/handlers/home"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/search"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/sitemap"
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/database"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/health"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
 type Config struct {
@@ -23,18 +34,41 @@ type Config struct {
 
 type Server struct {
 	server *http.Server
-
-	home *home.Handler
+	logger *logger.Logger
+
+	home      *home.Handler
+	badge     *badge.Handler
+	search    *search.Handler
+	sitemap   *sitemap.Handler
+	changelog *changelog.Handler
+	doc       *doc.Handler
+	feed      *feed.Handler
+	health    *health.Health
+	i18n      *i18n.Builder
+	tp        trace.TracerProvider
 }
 
-func New(lc fx.Lifecycle, cfg Config, home *home.Handler) *Server {
+func New(lc fx.Lifecycle, cfg Config, logger *logger.Logger, home *home.Handler, badge *badge.Handler, search *search.Handler, sitemap *sitemap.Handler, changelog *changelog.Handler, doc *doc.Handler, feed *feed.Handler, health *health.Health, i18n *i18n.Builder, listener *database.Listener, tp trace.TracerProvider) *Server {
 	s := Server{
-		home: home,
+		logger:    logger,
+		home:      home,
+		badge:     badge,
+		search:    search,
+		sitemap:   sitemap,
+		changelog: changelog,
+		doc:       doc,
+		feed:      feed,
+		health:    health,
+		i18n:      i18n,
+		tp:        tp,
 		server: &http.Server{
 			Addr: cfg.Addr,
 		},
 	}
 
+	// other instances and admin change packages, cached resolutions are dropped on notification
+	listener.Listen(models.Channel, home.Invalidate, home.Reset)
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			s.server.Handler = s.routing()
@@ -52,10 +86,10 @@ func New(lc fx.Lifecycle, cfg Config, home *home.Handler) *Server {
 
 func (s *Server) Start() {
 	go func() {
-		log.Printf("start server on %s\n", s.server.Addr)
+		s.logger.Info("start server", zap.String("addr", s.server.Addr))
 		err := s.server.ListenAndServe()
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal(err)
+			s.logger.Fatal("error on serve", zap.Error(err))
 		}
 	}()
 }
@@ -65,25 +99,13 @@ func (s *Server) Stop(ctx context.Context) {
 }
 
 func (s *Server) routing() http.Handler {
-	logger := httplog.NewLogger("gohome", httplog.Options{
-		JSON:             true,
-		LogLevel:         slog.LevelDebug,
-		Concise:          true,
-		RequestHeaders:   true,
-		MessageFieldName: "message",
-		// TimeFieldFormat: time.RFC850,
-		QuietDownRoutes: []string{
-			"/",
-			"/ping",
-		},
-		QuietDownPeriod: 10 * time.Second,
-		// SourceFieldName: "source",
-	})
-
 	r := chi.NewRouter()
 
-	r.Use(middleware.RequestID)
-	r.Use(httplog.RequestLogger(logger))
+	// probes are answered before logging to keep logs clean
+	r.Use(s.health.Middleware)
+	r.Use(logger.RequestIDMiddleware)
+	r.Use(tracing.Middleware(s.tp))
+	r.Use(logger.Middleware(s.logger))
 	r.Use(cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -91,9 +113,27 @@ func (s *Server) routing() http.Handler {
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
-	}).Handler, middleware.Recoverer, middleware.NoCache)
-
-	r.Get("/*", s.home.Home)
+	}).Handler, middleware.Recoverer)
+
+	// badges are cached by clients and proxies
+	r.Get("/badge/*", s.badge.Badge)
+
+	r.Get("/robots.txt", s.sitemap.Robots)
+	r.Get("/sitemap.xml", s.sitemap.Sitemap)
+	r.Get("/sitemap-{page:[0-9]+}.xml", s.sitemap.Page)
+
+	// pages answer conditional requests themselves, see httpcache
+	r.Group(func(r chi.Router) {
+		r.Use(locales.Middleware(s.i18n))
+
+		r.Get("/search", s.search.Search)
+		r.Get("/search.json", s.search.JSON)
+		r.Get("/changelog/*", s.changelog.Changelog)
+		r.Get("/doc/*", s.doc.Doc)
+		r.Get("/feed.atom", s.feed.Site)
+		r.Get("/feed/*", s.feed.Feed)
+		r.Get("/*", s.home.Home)
+	})
 
 	return r
 }
@@ -1,41 +1,143 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"go.uber.org/config"
 	"go.uber.org/fx"
 
 	"gohome.4gophers.ru/getapp/gohome/appv2/database"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/audit"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users"
 	"gohome.4gophers.ru/getapp/gohome/appv2/s3storage"
 	"gohome.4gophers.ru/getapp/gohome/appv2/server"
+	"gohome.4gophers.ru/getapp/gohome/appv2/storage"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
+const (
+	EnvDev  Env = "dev"
+	EnvProd Env = "prod"
+
+	// base is the admin config, environment file admin.<env>.yaml is layered on top of it
+	base = "admin"
+)
+
+// Env is an environment name, dev mode relaxes config checks
+type Env string
+
+func (e Env) Dev() bool {
+	return e == EnvDev
+}
+
 type Config struct {
 	fx.Out
 
+	Env       Env              `yaml:"env"`
+	Logger    logger.Config    `yaml:"logger"`
 	Database  database.Config  `yaml:"database"`
 	Server    server.Config    `yaml:"server"`
 	S3Storage s3storage.Config `yaml:"s3storage"`
+	Storage   storage.Config   `yaml:"storage"`
+	Login     users.Config     `yaml:"login"`
+	Audit     audit.Config     `yaml:"audit"`
+	Mail      mailer.Config    `yaml:"mail"`
+	Tracing   tracing.Config   `yaml:"tracing"`
+	Cache     httpcache.Config `yaml:"cache"`
+	Packages  packages.Config  `yaml:"packages"`
 }
 
-func New(file string) (Config, error) {
-	provider, err := config.NewYAML(
+// New loads config layers in order: base file, environment file, additional file,
+// environment variables and flags. Unknown keys are errors
+func New(opts Options) (Config, error) {
+	sources := []config.YAMLOption{
 		config.Expand(os.LookupEnv),
-		config.File("configs/"+file),
-		config.Permissive(),
-	)
+		config.File(filepath.Join(opts.Dir, base+".yaml")),
+	}
+
+	env := filepath.Join(opts.Dir, base+"."+opts.Env+".yaml")
+	if _, err := os.Stat(env); err == nil {
+		sources = append(sources, config.File(env))
+	}
 
+	if opts.File != "" {
+		sources = append(sources, config.File(filepath.Join(opts.Dir, opts.File)))
+	}
+
+	overrides, err := overrides(opts)
+	if err != nil {
+		return Config{}, err
+	}
+	sources = append(sources, config.Static(overrides))
+
+	provider, err := config.NewYAML(sources...)
 	if err != nil {
 		return Config{}, err
 	}
 
 	cfg := Config{}
+	if err := provider.Get("").Populate(&cfg); err != nil {
+		return Config{}, err
+	}
+	if cfg.Env == "" {
+		cfg.Env = Env(opts.Env)
+	}
 
-	err = provider.Get("").Populate(&cfg)
-	if err != nil {
+	if err := cfg.Validate(); err != nil {
 		return Config{}, err
 	}
 
 	return cfg, nil
 }
+
+// Validate checks every section and reports all problems at once
+func (c Config) Validate() error {
+	type section struct {
+		name string
+		err  error
+	}
+
+	sections := []section{
+		{"database", c.Database.Validate()},
+		{"server", c.Server.Validate()},
+		{"storage", c.Storage.Validate()},
+		{"login", c.Login.Validate(c.Env.Dev())},
+		{"tracing", c.Tracing.Validate()},
+		{"cache", c.Cache.Validate()},
+		{"packages", c.Packages.Validate()},
+		{"mail", c.Mail.Validate(c.Env.Dev())},
+	}
+	if c.Storage.S3() {
+		sections = append(sections, section{"s3storage", c.S3Storage.Validate()})
+	}
+
+	var errs []error
+	for _, s := range sections {
+		if s.err == nil {
+			continue
+		}
+		for _, err := range unwrap(s.err) {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func unwrap(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -1,23 +1,11 @@
 package config
 
-import (
-	"flag"
-
-	"go.uber.org/fx"
-)
+import "go.uber.org/fx"
 
+// Module expects Options parsed from command line, see Parse
 var Module = fx.Module(
 	"config",
 	fx.Provide(
-		func() (Config, error) {
-			var (
-				cfg string
-			)
-
-			flag.StringVar(&cfg, "c", "prod.yaml", "config")
-			flag.Parse()
-
-			return New(cfg)
-		},
+		New,
 	),
 )
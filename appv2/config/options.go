@@ -0,0 +1,61 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+// Options select config files and override values from command line
+type Options struct {
+	// Dir with config files
+	Dir string
+	// Env selects admin.<env>.yaml and enables dev mode for dev
+	Env string
+	// EnvSet reports that -env flag was given, only then it overrides env key of files
+	EnvSet bool
+	// File is additional config file in Dir, e.g. prod.yaml
+	File string
+	// Set overrides values by key path, e.g. server.port=:8090
+	Set []string
+}
+
+type setFlag []string
+
+func (s *setFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *setFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// Parse parses command line flags and returns remaining arguments
+func Parse(args []string) (Options, []string, error) {
+	opts := Options{}
+
+	fs := flag.NewFlagSet("gohomev2", flag.ContinueOnError)
+	fs.StringVar(&opts.Dir, "configs", lookup(prefix+"_CONFIGS", "configs"), "configs directory")
+	fs.StringVar(&opts.Env, "env", lookup(prefix+"_ENV", string(EnvProd)), "environment")
+	fs.StringVar(&opts.File, "c", "", "additional config file")
+	fs.Var((*setFlag)(&opts.Set), "set", "override config value, e.g. -set server.port=:8090")
+
+	if err := fs.Parse(args); err != nil {
+		return Options{}, nil, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "env" {
+			opts.EnvSet = true
+		}
+	})
+
+	return opts, fs.Args(), nil
+}
+
+func lookup(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prefix of environment variables, e.g. GOHOME_DATABASE_PASSWORD for database.password.
+// Secrets can be read from files with _FILE suffix, e.g. GOHOME_DATABASE_PASSWORD_FILE
+const prefix = "GOHOME"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type field struct {
+	path   []string
+	typ    reflect.Type
+	secret bool
+}
+
+func (f field) key() string {
+	return strings.Join(f.path, ".")
+}
+
+func (f field) env() string {
+	return prefix + "_" + strings.ToUpper(strings.Join(f.path, "_"))
+}
+
+// fields returns leaf config fields with yaml keys
+func fields(t reflect.Type, path []string) []field {
+	var r []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || f.Anonymous || name == "" || name == "-" {
+			continue
+		}
+
+		p := append(append([]string{}, path...), name)
+		if f.Type.Kind() == reflect.Struct {
+			r = append(r, fields(f.Type, p)...)
+			continue
+		}
+
+		r = append(r, field{
+			path:   p,
+			typ:    f.Type,
+			secret: f.Tag.Get("secret") == "true",
+		})
+	}
+	return r
+}
+
+// overrides collects values from environment and flags, flags win
+func overrides(opts Options) (map[string]any, error) {
+	values := map[string]any{}
+	known := map[string]field{}
+
+	for _, f := range fields(reflect.TypeOf(Config{}), nil) {
+		known[f.key()] = f
+
+		raw, ok := os.LookupEnv(f.env())
+		if file, fok := os.LookupEnv(f.env() + "_FILE"); fok {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env()+"_FILE", err)
+			}
+			raw, ok = strings.TrimSpace(string(data)), true
+		}
+		if !ok {
+			continue
+		}
+
+		v, err := parse(f, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.env(), err)
+		}
+		put(values, f.path, v)
+	}
+
+	for _, s := range opts.Set {
+		key, raw, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("-set %s: expected key=value", s)
+		}
+
+		f, ok := known[key]
+		if !ok {
+			return nil, fmt.Errorf("-set %s: unknown key %s", s, key)
+		}
+
+		v, err := parse(f, raw)
+		if err != nil {
+			return nil, fmt.Errorf("-set %s: %w", s, err)
+		}
+		put(values, f.path, v)
+	}
+
+	// explicit -env flag selects files, so it wins over any file and GOHOME_ENV
+	if opts.EnvSet && opts.Env != "" {
+		values["env"] = opts.Env
+	}
+
+	return values, nil
+}
+
+func parse(f field, raw string) (any, error) {
+	if f.typ == durationType {
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+
+	switch f.typ.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	case reflect.Slice:
+		if f.typ.Elem().Kind() == reflect.String {
+			return strings.Split(raw, ","), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", f.typ)
+}
+
+func put(values map[string]any, path []string, v any) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := values[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			values[p] = next
+		}
+		values = next
+	}
+	values[path[len(path)-1]] = v
+}
@@ -0,0 +1,51 @@
+package config
+
+import "testing"
+
+func TestOverridesEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		envVar string
+		want   any
+	}{
+		{"default flag keeps file value", Options{Env: "prod"}, "", nil},
+		{"explicit flag", Options{Env: "dev", EnvSet: true}, "", "dev"},
+		{"environment variable", Options{Env: "stage"}, "stage", "stage"},
+		{"explicit flag wins", Options{Env: "dev", EnvSet: true}, "stage", "dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envVar != "" {
+				t.Setenv(prefix+"_ENV", tt.envVar)
+			}
+
+			values, err := overrides(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values["env"]; got != tt.want {
+				t.Errorf("env = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvSet(t *testing.T) {
+	opts, _, err := Parse(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.EnvSet {
+		t.Error("default -env is reported as set")
+	}
+
+	opts, _, err = Parse([]string{"-env", "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.EnvSet || opts.Env != "dev" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = "******"
+
+// Print writes effective config as yaml, secrets are redacted
+func (c Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(values(reflect.ValueOf(c)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func values(v reflect.Value) yaml.MapSlice {
+	var r yaml.MapSlice
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || f.Anonymous || name == "" || name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		var item any
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			item = values(fv)
+		case f.Tag.Get("secret") == "true":
+			item = redact(fv)
+		case f.Type == durationType:
+			item = fv.Interface().(interface{ String() string }).String()
+		default:
+			item = fv.Interface()
+		}
+
+		r = append(r, yaml.MapItem{Key: name, Value: item})
+	}
+
+	return r
+}
+
+func redact(v reflect.Value) any {
+	if v.Kind() == reflect.Slice {
+		r := make([]string, v.Len())
+		for i := range r {
+			r[i] = redacted
+		}
+		return r
+	}
+
+	if v.IsZero() {
+		return ""
+	}
+	return redacted
+}
@@ -1,32 +1,118 @@
 package database
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultSSLMode = "disable"
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
 
 type Config struct {
 	Debug    bool   `yaml:"debug"`
 	User     string `yaml:"user"`
-	Password string `yaml:"password"`
+	Password string `yaml:"password" secret:"true"`
 	Dbname   string `yaml:"dbname"`
 	Host     string `yaml:"host"`
 	Port     string `yaml:"port"`
+
+	// TLS settings, see libpq sslmode, disable is used by default
+	SSLMode     string `yaml:"sslmode"`
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+
+	// Pool settings, zero values keep database/sql defaults
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Host == "" {
+		errs = append(errs, errors.New("host is required"))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("user is required"))
+	}
+	if c.Dbname == "" {
+		errs = append(errs, errors.New("dbname is required"))
+	}
+	if c.SSLMode != "" && !slices.Contains(sslModes, c.SSLMode) {
+		errs = append(errs, fmt.Errorf("sslmode must be one of %s", strings.Join(sslModes, ", ")))
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("pool sizes must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) sslMode() string {
+	if c.SSLMode == "" {
+		return defaultSSLMode
+	}
+	return c.SSLMode
 }
 
 func (c Config) Connection() string {
-	return fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable host=%s port=%s",
-		c.User,
-		c.Password,
-		c.Dbname,
-		c.Host,
-		c.Port,
-	)
+	params := []string{
+		"user=" + quote(c.User),
+		"password=" + quote(c.Password),
+		"dbname=" + quote(c.Dbname),
+		"sslmode=" + quote(c.sslMode()),
+		"host=" + quote(c.Host),
+		"port=" + quote(c.Port),
+	}
+
+	for _, p := range [][2]string{
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	} {
+		if p[1] != "" {
+			params = append(params, p[0]+"="+quote(p[1]))
+		}
+	}
+
+	return strings.Join(params, " ")
 }
 
 func (c Config) URL() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		c.User,
-		c.Password,
-		c.Host,
-		c.Port,
-		c.Dbname,
-	)
+	q := url.Values{}
+	q.Set("sslmode", c.sslMode())
+	if c.SSLRootCert != "" {
+		q.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		q.Set("sslcert", c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		q.Set("sslkey", c.SSLKey)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Host + ":" + c.Port,
+		Path:     "/" + c.Dbname,
+		RawQuery: q.Encode(),
+	}
+
+	return u.String()
+}
+
+// quote escapes value for keyword/value connection string
+func quote(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
 }
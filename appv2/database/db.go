@@ -1,23 +1,49 @@
 package database
 
 import (
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
-func New(config Config) *gorm.DB {
+func New(config Config, tp trace.TracerProvider, l *logger.Logger) *gorm.DB {
+	// Set db log level
+	level := gormlogger.Warn
+	if config.Debug {
+		level = gormlogger.Info
+	}
+
 	// Create database connection
-	db, err := gorm.Open(postgres.Open(config.Connection()))
+	db, err := gorm.Open(postgres.Open(config.Connection()), &gorm.Config{
+		Logger: logger.NewGorm(l, level),
+	})
 	if err != nil {
 		panic(err)
 	}
 
-	// Set db log level
-	if config.Debug {
-		db.Logger = db.Logger.LogMode(logger.Info)
-	} else {
-		db.Logger = db.Logger.LogMode(logger.Warn)
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+
+	if err := db.Use(tracing.NewGorm(tp)); err != nil {
+		panic(err)
 	}
 
 	return db
@@ -6,5 +6,6 @@ var Module = fx.Module(
 	"database",
 	fx.Provide(
 		New,
+		NewNotifier,
 	),
 )
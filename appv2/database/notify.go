@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	// listenRetry grows twice with every failed connection up to maxListenRetry
+	listenRetry    = time.Second
+	maxListenRetry = 30 * time.Second
+)
+
+// Notifier sends Postgres notifications to other instances, see Listener
+type Notifier struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewNotifier(db *gorm.DB, logger *logger.Logger) *Notifier {
+	return &Notifier{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Notify sends every payload to listeners of the channel. Errors are only logged,
+// listeners resync on reconnect and their caches expire anyway
+func (n *Notifier) Notify(ctx context.Context, channel string, payloads ...string) {
+	for _, payload := range payloads {
+		if err := n.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", channel, payload).Error; err != nil {
+			logger.For(ctx, n.logger).Warn("error on notify", zap.String("channel", channel), zap.String("payload", payload), zap.Error(err))
+		}
+	}
+}
+
+type subscription struct {
+	notify func(payload string)
+	resync func()
+}
+
+// Listener keeps a dedicated connection with LISTEN for subscribed channels and reconnects when it breaks
+type Listener struct {
+	config Config
+	logger *logger.Logger
+	subs   map[string][]subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewListener(lc fx.Lifecycle, config Config, logger *logger.Logger) *Listener {
+	l := &Listener{
+		config: config,
+		logger: logger,
+		subs:   map[string][]subscription{},
+		done:   make(chan struct{}),
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			l.Start()
+			return nil
+		},
+		OnStop: l.Stop,
+	})
+
+	return l
+}
+
+// Listen calls notify with payloads sent to the channel. Notifications are lost while
+// the connection is broken, so resync is called after every connect to drop stale state.
+// Subscriptions are made before start
+func (l *Listener) Listen(channel string, notify func(payload string), resync func()) {
+	l.subs[channel] = append(l.subs[channel], subscription{notify: notify, resync: resync})
+}
+
+// Start listens in background until Stop
+func (l *Listener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	go func() {
+		defer close(l.done)
+
+		delay := listenRetry
+		for {
+			connected, err := l.listen(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				delay = listenRetry
+			}
+			l.logger.Warn("error on listen, reconnecting", zap.Duration("delay", delay), zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxListenRetry)
+		}
+	}()
+}
+
+func (l *Listener) Stop(ctx context.Context) error {
+	if l.cancel == nil {
+		return nil
+	}
+	l.cancel()
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// listen serves one connection and reports whether it was established
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.config.Connection())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	for channel := range l.subs {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, err
+		}
+	}
+
+	for _, subs := range l.subs {
+		for _, s := range subs {
+			s.resync()
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		for _, s := range l.subs[n.Channel] {
+			s.notify(n.Payload)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+// lifecycle drops hooks, tests start and stop listeners themselves
+type lifecycle struct{}
+
+func (lifecycle) Append(fx.Hook) {}
+
+// testConfig converts dsn of the test database to the config of the listener
+func testConfig(t *testing.T) Config {
+	dsn := os.Getenv(dbtest.Env)
+	if dsn == "" {
+		t.Skip(dbtest.Env + " is not set")
+	}
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Config{
+		User:     cfg.User,
+		Password: cfg.Password,
+		Dbname:   cfg.Database,
+		Host:     cfg.Host,
+		Port:     strconv.Itoa(int(cfg.Port)),
+		SSLMode:  "prefer",
+	}
+}
+
+// recorder collects calls of a subscription, the listener calls it from its goroutine
+type recorder struct {
+	payloads chan string
+	resyncs  chan struct{}
+}
+
+func listen(l *Listener, channel string) *recorder {
+	r := &recorder{
+		payloads: make(chan string, 16),
+		resyncs:  make(chan struct{}, 16),
+	}
+	l.Listen(channel, func(payload string) { r.payloads <- payload }, func() { r.resyncs <- struct{}{} })
+	return r
+}
+
+func (r *recorder) resynced(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.resyncs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resync")
+	}
+}
+
+func (r *recorder) received(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-r.payloads:
+		if got != want {
+			t.Fatalf("got payload %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no payload %q", want)
+	}
+}
+
+func (r *recorder) quiet(t *testing.T) {
+	t.Helper()
+	select {
+	case got := <-r.payloads:
+		t.Fatalf("unexpected payload %q", got)
+	case <-r.resyncs:
+		t.Fatal("unexpected resync")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func channel(name string) string {
+	return fmt.Sprintf("test_%s_%d", name, time.Now().UnixNano())
+}
+
+func TestListener(t *testing.T) {
+	cfg := testConfig(t)
+	n := NewNotifier(dbtest.Open(t), zap.NewNop())
+
+	l := NewListener(lifecycle{}, cfg, zap.NewNop())
+	first, second := channel("first"), channel("second")
+	a, b := listen(l, first), listen(l, second)
+	l.Start()
+	defer l.Stop(context.Background())
+
+	// every subscription drops its state once the connection listens
+	a.resynced(t)
+	b.resynced(t)
+
+	n.Notify(context.Background(), first, "one", "two")
+	a.received(t, "one")
+	a.received(t, "two")
+	b.quiet(t)
+
+	n.Notify(context.Background(), second, "three")
+	b.received(t, "three")
+	a.quiet(t)
+}
+
+func TestListenerReconnect(t *testing.T) {
+	cfg := testConfig(t)
+	db := dbtest.Open(t)
+	n := NewNotifier(db, zap.NewNop())
+
+	l := NewListener(lifecycle{}, cfg, zap.NewNop())
+	ch := channel("reconnect")
+	r := listen(l, ch)
+	l.Start()
+	defer l.Stop(context.Background())
+	r.resynced(t)
+
+	// the server drops the listening connection, notifications sent meanwhile are lost
+	var terminated int64
+	err := db.Raw("SELECT COUNT(pg_terminate_backend(pid)) FROM pg_stat_activity WHERE pid <> pg_backend_pid() AND query = ?",
+		"LISTEN "+pgx.Identifier{ch}.Sanitize()).Scan(&terminated).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terminated != 1 {
+		t.Fatalf("terminated %d connections, want 1", terminated)
+	}
+
+	// the listener connects again after the retry delay and asks to resync
+	r.resynced(t)
+	n.Notify(context.Background(), ch, "after")
+	r.received(t, "after")
+}
+
+func TestListenerStop(t *testing.T) {
+	// nothing listens on the port, the listener keeps reconnecting
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close()
+
+	l := NewListener(lifecycle{}, Config{Host: "127.0.0.1", Port: port, User: "gohome", Dbname: "gohome"}, zap.NewNop())
+	r := listen(l, channel("stop"))
+	l.Start()
+
+	// stop interrupts the retry delay
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// resync is called on connect only
+	r.quiet(t)
+}
+
+func TestListenerNotStarted(t *testing.T) {
+	l := NewListener(lifecycle{}, Config{}, zap.NewNop())
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package locales
+
+import "github.com/qor5/admin/v3/activity"
+
+var Activity_ru = &activity.Messages{
+	Activities:   "Активность",
+	ActionAll:    "Все",
+	ActionView:   "Просмотр",
+	ActionEdit:   "Изменение",
+	ActionCreate: "Создание",
+	ActionDelete: "Удаление",
+	ActionNote:   "Заметка",
+
+	ModelUserID:    "ID автора",
+	ModelCreatedAt: "Время",
+	ModelAction:    "Действие",
+	ModelUser:      "Автор",
+	ModelKeys:      "Ключи",
+	ModelName:      "Модель",
+	ModelLabel:     "Раздел",
+	ModelLink:      "Ссылка",
+	ModelDiffs:     "Изменения",
+
+	FilterAction:    "Действие",
+	FilterCreatedAt: "Время",
+	FilterUser:      "Автор",
+	FilterModel:     "Модель",
+	FilterModelKeys: "Ключи",
+
+	DiffDetail:  "Подробности",
+	DiffAdd:     "Добавлено",
+	DiffDelete:  "Удалено",
+	DiffChanges: "Изменено",
+	DiffField:   "Поле",
+	DiffOld:     "Было",
+	DiffNew:     "Стало",
+	DiffValue:   "Значение",
+
+	AddedANote:                    "Добавил заметку:",
+	LastEditedAtTemplate:          "(изменено {desc})",
+	EditedNFieldsTemplate:         "Изменено полей: {n}",
+	MoreInfo:                      "Подробнее",
+	Created:                       "Создал",
+	Viewed:                        "Просмотрел",
+	Deleted:                       "Удалил",
+	PerformActionNoDetailTemplate: "Выполнил {action}",
+	PerformActionTemplate:         "Выполнил {action}: {detail}",
+	AddNote:                       "Добавить заметку",
+	UnknownUser:                   "Неизвестный",
+	NoteCannotBeEmpty:             "Заметка не может быть пустой",
+	FailedToCreateNote:            "Не удалось создать заметку",
+	SuccessfullyCreatedNote:       "Заметка создана",
+	FailedToGetCurrentUser:        "Не удалось определить текущего пользователя",
+	FailedToGetNote:               "Не удалось получить заметку",
+	YouAreNotTheNoteUser:          "Вы не автор этой заметки",
+	FailedToUpdateNote:            "Не удалось обновить заметку",
+	SuccessfullyUpdatedNote:       "Заметка обновлена",
+	FailedToDeleteNote:            "Не удалось удалить заметку",
+	SuccessfullyDeletedNote:       "Заметка удалена",
+	DeleteNoteDialogTitle:         "Удаление заметки",
+	DeleteNoteDialogText:          "Вы уверены, что хотите удалить эту заметку?",
+	Cancel:                        "Отмена",
+	Delete:                        "Удалить",
+	NoActivitiesYet:               "Пока нет активности",
+	ViewAll:                       "Показать все",
+	CannotShowMore:                "Достигнут предел отображения, больше загрузить нельзя.",
+
+	HeaderNotes: "Заметки",
+
+	ActivityLogs: "Журнал действий",
+	ActivityLog:  "Запись журнала",
+
+	FilterTabsHasUnreadNotes: "Есть непрочитанные заметки",
+}
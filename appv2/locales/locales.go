@@ -0,0 +1,50 @@
+package locales
+
+import (
+	"github.com/qor5/admin/v3/activity"
+	plogin "github.com/qor5/admin/v3/login"
+	"github.com/qor5/admin/v3/media"
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	"github.com/qor5/x/v3/login"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	. "github.com/theplant/htmlgo"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Configure registers russian messages, russian is the default language,
+// english is chosen by Accept-Language or "lang" cookie
+func Configure(b *presets.Builder) {
+	b.GetI18n().
+		SupportLanguages(language.Russian, language.English).
+		RegisterForModule(language.Russian, presets.CoreI18nModuleKey, Presets_ru).
+		RegisterForModule(language.Russian, presets.ModelsI18nModuleKey, Models_ru).
+		RegisterForModule(language.Russian, media.I18nMediaLibraryKey, Media_ru).
+		RegisterForModule(language.Russian, activity.I18nActivityKey, Activity_ru).
+		RegisterForModule(language.Russian, worker.I18nWorkerKey, Worker_ru).
+		RegisterForModule(language.Russian, login.I18nLoginKey, Login_ru).
+		RegisterForModule(language.Russian, plogin.I18nAdminLoginKey, AdminLogin_ru).
+		RegisterForModule(language.English, plogin.I18nAdminLoginKey, AdminLogin_en)
+}
+
+// Switcher renders links to switch admin language
+func Switcher(b *presets.Builder, ctx *web.EventContext) HTMLComponent {
+	ib := b.GetI18n()
+	current := i18n.LanguageTagFromContext(ctx.R.Context(), language.Russian)
+
+	var links []HTMLComponent
+	for _, tag := range ib.GetSupportLanguages() {
+		if tag == current {
+			continue
+		}
+		links = append(links, VBtn(display.Self.Name(tag)).
+			Variant(VariantText).
+			Size(SizeSmall).
+			Attr("@click", web.Plaid().MergeQuery(true).Query(ib.GetQueryName(), tag.String()).Go()))
+	}
+
+	return Components(links...)
+}
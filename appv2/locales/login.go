@@ -0,0 +1,118 @@
+package locales
+
+import (
+	plogin "github.com/qor5/admin/v3/login"
+	"github.com/qor5/x/v3/login"
+)
+
+var Login_ru = &login.Messages{
+	Confirm:                             "Подтвердить",
+	Verify:                              "Проверить",
+	LoginPageTitle:                      "Вход",
+	AccountLabel:                        "Email",
+	AccountPlaceholder:                  "Email",
+	PasswordLabel:                       "Пароль",
+	PasswordPlaceholder:                 "Пароль",
+	SignInBtn:                           "Войти",
+	ForgetPasswordLink:                  "Забыли пароль?",
+	ForgetPasswordPageTitle:             "Забыли пароль?",
+	ForgotMyPasswordTitle:               "Я забыл пароль",
+	ForgetPasswordEmailLabel:            "Введите email",
+	ForgetPasswordEmailPlaceholder:      "Email",
+	SendResetPasswordEmailBtn:           "Отправить письмо для сброса пароля",
+	ResendResetPasswordEmailBtn:         "Отправить письмо еще раз",
+	SendEmailTooFrequentlyNotice:        "Письма отправляются слишком часто, попробуйте позже",
+	ResetPasswordLinkSentPageTitle:      "Забыли пароль?",
+	ResetPasswordLinkWasSentTo:          "Ссылка для сброса пароля отправлена на",
+	ResetPasswordLinkSentPrompt:         "Можно закрыть эту страницу и сбросить пароль по ссылке из письма.",
+	ResetPasswordPageTitle:              "Сброс пароля",
+	ResetYourPasswordTitle:              "Сбросьте пароль",
+	ResetPasswordLabel:                  "Новый пароль",
+	ResetPasswordPlaceholder:            "Новый пароль",
+	ResetPasswordConfirmLabel:           "Повторите новый пароль",
+	ResetPasswordConfirmPlaceholder:     "Подтверждение пароля",
+	ChangePasswordPageTitle:             "Смена пароля",
+	ChangePasswordTitle:                 "Смените пароль",
+	ChangePasswordOldLabel:              "Старый пароль",
+	ChangePasswordOldPlaceholder:        "Старый пароль",
+	ChangePasswordNewLabel:              "Новый пароль",
+	ChangePasswordNewPlaceholder:        "Новый пароль",
+	ChangePasswordNewConfirmLabel:       "Повторите новый пароль",
+	ChangePasswordNewConfirmPlaceholder: "Новый пароль",
+	TOTPSetupPageTitle:                  "Настройка TOTP",
+	TOTPSetupTitle:                      "Двухфакторная аутентификация",
+	TOTPSetupScanPrompt:                 "Отсканируйте QR код в Google Authenticator или похожем приложении",
+	TOTPSetupSecretPrompt:               "Или введите этот код в приложение-аутентификатор вручную",
+	TOTPSetupEnterCodePrompt:            "Затем введите одноразовый код ниже",
+	TOTPSetupCodePlaceholder:            "Код",
+	TOTPValidatePageTitle:               "Проверка TOTP",
+	TOTPValidateTitle:                   "Двухфакторная аутентификация",
+	TOTPValidateEnterCodePrompt:         "Введите одноразовый код ниже",
+	TOTPValidateCodeLabel:               "Код из приложения",
+	TOTPValidateCodePlaceholder:         "Код",
+	ErrorSystemError:                    "Системная ошибка",
+	ErrorCompleteUserAuthFailed:         "Не удалось завершить аутентификацию",
+	ErrorUserNotFound:                   "Пользователь не найден",
+	ErrorIncorrectAccountNameOrPassword: "Неверный email или пароль",
+	ErrorUserLocked:                     "Пользователь заблокирован",
+	ErrorAccountIsRequired:              "Укажите email",
+	ErrorPasswordCannotBeEmpty:          "Пароль не может быть пустым",
+	ErrorPasswordNotMatch:               "Новые пароли не совпадают. Попробуйте еще раз.",
+	ErrorIncorrectPassword:              "Не удалось сменить пароль. Проверьте введенные данные и попробуйте еще раз.",
+	ErrorInvalidToken:                   "Неверный токен",
+	ErrorTokenExpired:                   "Срок действия токена истек",
+	ErrorIncorrectTOTPCode:              "Неверный код",
+	ErrorTOTPCodeReused:                 "Этот код уже использован",
+	ErrorIncorrectRecaptchaToken:        "Неверный токен reCAPTCHA",
+	WarnPasswordHasBeenChanged:          "Пароль изменен, войдите еще раз",
+	InfoPasswordSuccessfullyReset:       "Пароль сброшен, войдите еще раз",
+	InfoPasswordSuccessfullyChanged:     "Пароль изменен, войдите еще раз",
+}
+
+var AdminLogin_ru = &plogin.Messages{
+	SessionTableHeaderTime:           "Время",
+	SessionTableHeaderDevice:         "Устройство",
+	SessionTableHeaderLocation:       "Местоположение",
+	SessionTableHeaderIPAddress:      "IP адрес",
+	SessionTableHeaderStatus:         "Статус",
+	SessionTableHeaderLastActivedAt:  "Последняя активность",
+	SessionTableHeaderAction:         "Действие",
+	SessionsDialogTitle:              "Сеансы входа",
+	SessionStatusExpired:             "Истек",
+	SessionStatusActive:              "Активен",
+	SessionStatusCurrent:             "Текущий сеанс",
+	HideIPAddressTips:                "Скрыто из соображений безопасности",
+	ExpireOtherSessions:              "Завершить все другие сеансы",
+	SuccessfullyExpiredOtherSessions: "Все другие сеансы завершены.",
+	SuccessfullyExpiredSessions:      "Сеанс завершен.",
+	UnreadMessagesTemplate:           "Непрочитанных заметок: {n}",
+	ViewLoginSessions:                "Сеансы входа",
+	Logout:                           "Выход",
+	Available:                        "Доступно",
+	Unavailable:                      "Недоступно",
+	SuccessfullyRename:               "Переименовано",
+	LocationUnknown:                  "Неизвестно",
+
+	LoginWelcomeLabel: "Добро пожаловать",
+	LoginTitleLabel:   "GoHome",
+
+	LoginAccountLabel:         "Email",
+	LoginAccountPlaceholder:   "Введите email",
+	LoginPasswordLabel:        "Пароль",
+	LoginPasswordPlaceholder:  "Введите пароль",
+	LoginSignInButtonLabel:    "Войти",
+	LoginForgetPasswordLabel:  "Забыли пароль?",
+	LoginQor5DescriptionLabel: "Домен для Go пакетов",
+
+	LoginProviderGoogleText:    "Войти через Google",
+	LoginProviderMicrosoftText: "Войти через Microsoft",
+	LoginProviderGithubText:    "Войти через Github",
+}
+
+// AdminLogin_en brands login page of the default admin messages
+var AdminLogin_en = func() *plogin.Messages {
+	m := *plogin.Messages_en_US
+	m.LoginTitleLabel = "GoHome"
+	m.LoginQor5DescriptionLabel = "Domain for Go packages"
+	return &m
+}()
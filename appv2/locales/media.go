@@ -0,0 +1,49 @@
+package locales
+
+import (
+	"fmt"
+
+	"github.com/qor5/admin/v3/media"
+)
+
+var Media_ru = &media.Messages{
+	Crop:                        "Обрезать",
+	CropImage:                   "Обрезать изображение",
+	ChooseFile:                  "Выбрать файл",
+	Delete:                      "Удалить",
+	ChooseAFile:                 "Выберите файл",
+	Search:                      "Поиск",
+	UploadFiles:                 "Загрузить файлы",
+	Cropping:                    "Обрезка",
+	DescriptionUpdated:          "Описание обновлено",
+	DescriptionForAccessibility: "описание для доступности",
+	OrderBy:                     "Сортировка",
+	UploadedAt:                  "Дата загрузки",
+	UploadedAtDESC:              "Дата загрузки (по убыванию)",
+	All:                         "Все",
+	Images:                      "Изображения",
+	Videos:                      "Видео",
+	Files:                       "Файлы",
+
+	Copy:                                  "Копировать",
+	CopyUpdated:                           "Скопировано",
+	Rename:                                "Переименовать",
+	RenameUpdated:                         "Переименовано",
+	Name:                                  "Название",
+	NewFolder:                             "Новая папка",
+	UpdateDescription:                     "Изменить описание",
+	UpdateDescriptionTextFieldPlaceholder: "Описание",
+	ChooseFolder:                          "Выберите папку",
+	MoveTo:                                "Переместить в",
+	MovedFailed:                           "Не удалось переместить",
+	MovedSuccess:                          "Перемещено",
+	Folders:                               "Папки",
+	UploadFile:                            "Загрузить файл",
+	DeleteObjects: func(v int) string {
+		return fmt.Sprintf("Вы уверены, что хотите удалить объекты (%v)?", v)
+	},
+
+	MediaLibrary:      "Медиа",
+	UnSupportFileType: "Неподдерживаемый тип файла",
+	CopyImageURL:      "скопировать ссылку на изображение",
+}
@@ -0,0 +1,333 @@
+package locales
+
+// Models labels of our models, menus and fields. Keys are built by presets
+// from model label and field name, english texts are the keys themselves
+type Models struct {
+	GoHome string
+
+	Dashboard                        string
+	DashboardPackages                string
+	DashboardActive                  string
+	DashboardInactive                string
+	DashboardUnreachable             string
+	DashboardDownloads               string
+	DashboardFailedResolutions       string
+	DashboardUnreachableRepositories string
+	DashboardRecentActivity          string
+	DashboardJobFailures             string
+	DashboardEmpty                   string
+
+	Packages        string
+	Package         string
+	PackagesID      string
+	PackagesTitle   string
+	PackagesInfo    string
+	PackagesRepo    string
+	PackagesVCS     string
+	PackagesPackage string
+	PackagesActive  string
+	PackagesCommits string
+	PackagesSearch  string
+	PackagesImport  string
+	PackagesExport  string
+
+	PackagesYes         string
+	PackagesNo          string
+	PackagesUnreachable string
+
+	PackagesRefresh                string
+	PackagesRebuildDocs            string
+	PackagesRefreshAll             string
+	PackagesSelected               string
+	PackagesRowMenuItemRefresh     string
+	PackagesRowMenuItemRebuildDocs string
+
+	ImportParamsFile      string
+	ImportParamsDryRun    string
+	RefreshParamsPackages string
+
+	Namespaces               string
+	Namespace                string
+	NamespacesID             string
+	NamespacesName           string
+	NamespacesUserID         string
+	NamespacesOwner          string
+	NamespacesOrganizationID string
+
+	Organizations        string
+	Organization         string
+	OrganizationsID      string
+	OrganizationsName    string
+	OrganizationsTitle   string
+	OrganizationsMembers string
+	OrganizationsDone    string
+
+	Members               string
+	Member                string
+	MembersID             string
+	MembersOrganizationID string
+	MembersUserID         string
+	MembersRole           string
+
+	Invitations                   string
+	Invitation                    string
+	InvitationsID                 string
+	InvitationsOrganizationID     string
+	InvitationsInvitee            string
+	InvitationsRole               string
+	InvitationsStatus             string
+	InvitationsCreatedAt          string
+	InvitationsRowMenuItemAccept  string
+	InvitationsRowMenuItemDecline string
+
+	Transfers                   string
+	Transfer                    string
+	TransfersID                 string
+	TransfersPackageID          string
+	TransfersFrom               string
+	TransfersTo                 string
+	TransfersStatus             string
+	TransfersCreatedAt          string
+	TransfersFromConfirmedBy    string
+	TransfersToConfirmedBy      string
+	TransfersCompletedAt        string
+	TransfersRowMenuItemConfirm string
+	TransfersRowMenuItemDecline string
+
+	Resolutions          string
+	Resolution           string
+	ResolutionsDay       string
+	ResolutionsPath      string
+	ResolutionsPackageID string
+	ResolutionsCount     string
+	ResolutionsUpdatedAt string
+	ResolutionsFound     string
+	ResolutionsYes       string
+	ResolutionsNo        string
+
+	Emails                 string
+	Email                  string
+	EmailsID               string
+	EmailsRecipient        string
+	EmailsSubject          string
+	EmailsTemplate         string
+	EmailsStatus           string
+	EmailsAttempts         string
+	EmailsNextAt           string
+	EmailsSentAt           string
+	EmailsError            string
+	EmailsBody             string
+	EmailsCreatedAt        string
+	EmailsRowMenuItemRetry string
+	EmailsQueued           string
+
+	Users         string
+	User          string
+	UsersID       string
+	UsersName     string
+	UsersAccount  string
+	UsersEmail    string
+	UsersPassword string
+	UsersRoles    string
+
+	Roles            string
+	Role             string
+	RolesID          string
+	RolesCreatedAt   string
+	RolesUpdatedAt   string
+	RolesDeletedAt   string
+	RolesName        string
+	RolesPermissions string
+	RolesEffect      string
+	RolesActions     string
+	RolesResources   string
+
+	MediaLibrary string
+
+	ActivityLogs           string
+	ActivityLog            string
+	ActivityLogsCreateTime string
+	ActivityLogsCreator    string
+	ActivityLogsAction     string
+	ActivityLogsModelKeys  string
+	ActivityLogsMenuName   string
+	ActivityLogsModelName  string
+
+	Workers                          string
+	WorkersID                        string
+	WorkersJob                       string
+	WorkersStatus                    string
+	WorkersCreatedAt                 string
+	WorkersArgs                      string
+	WorkerJobActionJobPackagesImport string
+	WorkerJobActionJobPackagesExport string
+
+	WorkerJobActionJobPackagesRefresh     string
+	WorkerJobActionJobPackagesRebuildDocs string
+	WorkerJobActionJobPackagesRefreshAll  string
+}
+
+var Models_ru = &Models{
+	GoHome: "GoHome",
+
+	Dashboard:                        "Обзор",
+	DashboardPackages:                "Пакеты",
+	DashboardActive:                  "Активные",
+	DashboardInactive:                "Неактивные",
+	DashboardUnreachable:             "Репозиторий недоступен",
+	DashboardDownloads:               "Популярные за неделю",
+	DashboardFailedResolutions:       "Незарегистрированные пути",
+	DashboardUnreachableRepositories: "Недоступные репозитории",
+	DashboardRecentActivity:          "Последние действия",
+	DashboardJobFailures:             "Ошибки задач",
+	DashboardEmpty:                   "Нет данных",
+
+	Packages:        "Пакеты",
+	Package:         "Пакет",
+	PackagesID:      "ID",
+	PackagesTitle:   "Название",
+	PackagesInfo:    "Описание",
+	PackagesRepo:    "Репозиторий",
+	PackagesVCS:     "VCS",
+	PackagesPackage: "Пакет",
+	PackagesActive:  "Активен",
+	PackagesCommits: "Коммиты в журнале изменений",
+	PackagesSearch:  "Поиск",
+	PackagesImport:  "Импорт",
+	PackagesExport:  "Экспорт",
+
+	PackagesYes:         "Да",
+	PackagesNo:          "Нет",
+	PackagesUnreachable: "Репозиторий недоступен",
+
+	PackagesRefresh:                "Обновить",
+	PackagesRebuildDocs:            "Пересобрать документацию",
+	PackagesRefreshAll:             "Обновить все",
+	PackagesSelected:               "Выбрано пакетов",
+	PackagesRowMenuItemRefresh:     "Обновить",
+	PackagesRowMenuItemRebuildDocs: "Пересобрать документацию",
+
+	ImportParamsFile:      "CSV файл",
+	ImportParamsDryRun:    "Только проверить",
+	RefreshParamsPackages: "Пакеты",
+
+	Namespaces:               "Пространства имен",
+	Namespace:                "Пространство имен",
+	NamespacesID:             "ID",
+	NamespacesName:           "Название",
+	NamespacesUserID:         "Владелец",
+	NamespacesOwner:          "Владелец",
+	NamespacesOrganizationID: "Организация",
+
+	Organizations:        "Организации",
+	Organization:         "Организация",
+	OrganizationsID:      "ID",
+	OrganizationsName:    "Имя",
+	OrganizationsTitle:   "Название",
+	OrganizationsMembers: "Участники",
+	OrganizationsDone:    "Готово",
+
+	Members:               "Участники",
+	Member:                "Участник",
+	MembersID:             "ID",
+	MembersOrganizationID: "Организация",
+	MembersUserID:         "Пользователь",
+	MembersRole:           "Роль",
+
+	Invitations:                   "Приглашения",
+	Invitation:                    "Приглашение",
+	InvitationsID:                 "ID",
+	InvitationsOrganizationID:     "Организация",
+	InvitationsInvitee:            "Email или логин",
+	InvitationsRole:               "Роль",
+	InvitationsStatus:             "Статус",
+	InvitationsCreatedAt:          "Создано",
+	InvitationsRowMenuItemAccept:  "Принять",
+	InvitationsRowMenuItemDecline: "Отклонить",
+
+	Transfers:                   "Передачи пакетов",
+	Transfer:                    "Передача пакета",
+	TransfersID:                 "ID",
+	TransfersPackageID:          "Пакет",
+	TransfersFrom:               "От",
+	TransfersTo:                 "Кому",
+	TransfersStatus:             "Статус",
+	TransfersCreatedAt:          "Создана",
+	TransfersFromConfirmedBy:    "Подтвердил отправитель",
+	TransfersToConfirmedBy:      "Подтвердил получатель",
+	TransfersCompletedAt:        "Завершена",
+	TransfersRowMenuItemConfirm: "Подтвердить",
+	TransfersRowMenuItemDecline: "Отклонить",
+
+	Resolutions:          "Запросы go",
+	Resolution:           "Запрос go",
+	ResolutionsDay:       "День",
+	ResolutionsPath:      "Путь",
+	ResolutionsPackageID: "Найден",
+	ResolutionsCount:     "Запросов",
+	ResolutionsUpdatedAt: "Последний запрос",
+	ResolutionsFound:     "Найден",
+	ResolutionsYes:       "Да",
+	ResolutionsNo:        "Нет",
+
+	Emails:                 "Письма",
+	Email:                  "Письмо",
+	EmailsID:               "ID",
+	EmailsRecipient:        "Получатель",
+	EmailsSubject:          "Тема",
+	EmailsTemplate:         "Шаблон",
+	EmailsStatus:           "Статус",
+	EmailsAttempts:         "Попытки",
+	EmailsNextAt:           "Следующая попытка",
+	EmailsSentAt:           "Отправлено",
+	EmailsError:            "Ошибка",
+	EmailsBody:             "Текст",
+	EmailsCreatedAt:        "Создано",
+	EmailsRowMenuItemRetry: "Отправить снова",
+	EmailsQueued:           "Письмо поставлено в очередь",
+
+	Users:         "Пользователи",
+	User:          "Пользователь",
+	UsersID:       "ID",
+	UsersName:     "Имя",
+	UsersAccount:  "Логин",
+	UsersEmail:    "Email",
+	UsersPassword: "Пароль",
+	UsersRoles:    "Роли",
+
+	Roles:            "Роли",
+	Role:             "Роль",
+	RolesID:          "ID",
+	RolesCreatedAt:   "Создана",
+	RolesUpdatedAt:   "Обновлена",
+	RolesDeletedAt:   "Удалена",
+	RolesName:        "Название",
+	RolesPermissions: "Права",
+	RolesEffect:      "Эффект",
+	RolesActions:     "Действия",
+	RolesResources:   "Ресурсы",
+
+	MediaLibrary: "Медиа",
+
+	ActivityLogs:           "Журнал действий",
+	ActivityLog:            "Запись журнала",
+	ActivityLogsCreateTime: "Время",
+	ActivityLogsCreator:    "Автор",
+	ActivityLogsAction:     "Действие",
+	ActivityLogsModelKeys:  "Ключи",
+	ActivityLogsMenuName:   "Раздел",
+	ActivityLogsModelName:  "Модель",
+
+	Workers:                          "Задачи",
+	WorkersID:                        "ID",
+	WorkersJob:                       "Задача",
+	WorkersStatus:                    "Статус",
+	WorkersCreatedAt:                 "Создана",
+	WorkersArgs:                      "Параметры",
+	WorkerJobActionJobPackagesImport: "Импорт пакетов",
+	WorkerJobActionJobPackagesExport: "Экспорт пакетов",
+
+	WorkerJobActionJobPackagesRefresh:     "Обновление пакетов",
+	WorkerJobActionJobPackagesRebuildDocs: "Пересборка документации",
+	WorkerJobActionJobPackagesRefreshAll:  "Обновление всех пакетов",
+}
@@ -0,0 +1,105 @@
+package locales
+
+import (
+	"fmt"
+
+	"github.com/qor5/admin/v3/presets"
+)
+
+var Presets_ru = &presets.Messages{
+	DialogTitleDefault:  "Подтверждение",
+	SuccessfullyUpdated: "Успешно обновлено",
+	SuccessfullyCreated: "Успешно создано",
+	Search:              "Поиск",
+	New:                 "Создать",
+	Update:              "Обновить",
+	Delete:              "Удалить",
+	Edit:                "Редактировать",
+	FormTitle:           "Форма",
+	OK:                  "OK",
+	Cancel:              "Отмена",
+	Clear:               "Очистить",
+	Create:              "Создать",
+	SelectedTemplate: func(v any) string {
+		return fmt.Sprintf("Выбрано: %v", v)
+	},
+	DeleteConfirmationText: "Вы уверены, что хотите удалить этот объект?",
+	DeleteObjectsConfirmationText: func(v int) string {
+		return fmt.Sprintf("Вы уверены, что хотите удалить объекты (%v)?", v)
+	},
+	CreatingObjectTitleTemplate:                "{modelName}: создание",
+	EditingObjectTitleTemplate:                 "{modelName} {id}: редактирование",
+	ListingObjectTitleTemplate:                 "{modelName}",
+	DetailingObjectTitleTemplate:               "{modelName} {id}",
+	FiltersClear:                               "Сбросить",
+	FiltersAdd:                                 "Добавить фильтры",
+	FilterApply:                                "Применить",
+	FilterByTemplate:                           "Фильтр по {filter}",
+	FiltersDateInTheLast:                       "за последние",
+	FiltersDateEquals:                          "равно",
+	FiltersDateBetween:                         "между",
+	FiltersDateIsAfter:                         "после",
+	FiltersDateIsAfterOrOn:                     "не раньше",
+	FiltersDateIsBefore:                        "до",
+	FiltersDateIsBeforeOrOn:                    "не позже",
+	FiltersDateDays:                            "дней",
+	FiltersDateMonths:                          "месяцев",
+	FiltersDateAnd:                             "и",
+	FiltersDateStartAt:                         "Начало",
+	FiltersDateEndAt:                           "Конец",
+	FiltersDateTo:                              "по",
+	FiltersDateClear:                           "Очистить",
+	FiltersDateOK:                              "OK",
+	FiltersNumberEquals:                        "равно",
+	FiltersNumberBetween:                       "между",
+	FiltersNumberGreaterThan:                   "больше",
+	FiltersNumberLessThan:                      "меньше",
+	FiltersNumberAnd:                           "и",
+	FiltersStringEquals:                        "равно",
+	FiltersStringContains:                      "содержит",
+	FiltersMultipleSelectIn:                    "входит в",
+	FiltersMultipleSelectNotIn:                 "не входит в",
+	PaginationRowsPerPage:                      "Строк на странице: ",
+	ListingNoRecordToShow:                      "Нет записей",
+	ListingSelectedCountNotice:                 "Выбрано записей: {count}. ",
+	ListingClearSelection:                      "снять выделение",
+	BulkActionNoRecordsSelected:                "Записи не выбраны",
+	BulkActionNoAvailableRecords:               "Действие нельзя применить ни к одной из выбранных записей.",
+	BulkActionSelectedIdsProcessNoticeTemplate: "Действие нельзя применить к части выбранных записей: {ids}.",
+	ConfirmDialogTitleText:                     "Подтверждение",
+	ConfirmDialogPromptText:                    "Вы уверены?",
+	Language:                                   "Язык",
+	Colon:                                      ":",
+	NotFoundPageNotice:                         "Страница не найдена. Проверьте адрес.",
+	ButtonLabelActionsMenu:                     "Действия",
+	Save:                                       "Сохранить",
+	AddRow:                                     "Добавить",
+	AddCard:                                    "Добавить карточку",
+	AddButton:                                  "Добавить кнопку",
+	CheckboxTrueLabel:                          "ДА",
+	CheckboxFalseLabel:                         "НЕТ",
+
+	HumanizeTimeAgo:       "назад",
+	HumanizeTimeFromNow:   "спустя",
+	HumanizeTimeNow:       "сейчас",
+	HumanizeTime1Second:   "1 секунду %s",
+	HumanizeTimeSeconds:   "%d сек. %s",
+	HumanizeTime1Minute:   "1 минуту %s",
+	HumanizeTimeMinutes:   "%d мин. %s",
+	HumanizeTime1Hour:     "1 час %s",
+	HumanizeTimeHours:     "%d ч. %s",
+	HumanizeTime1Day:      "1 день %s",
+	HumanizeTimeDays:      "%d дн. %s",
+	HumanizeTime1Week:     "1 неделю %s",
+	HumanizeTimeWeeks:     "%d нед. %s",
+	HumanizeTime1Month:    "1 месяц %s",
+	HumanizeTimeMonths:    "%d мес. %s",
+	HumanizeTime1Year:     "1 год %s",
+	HumanizeTime2Years:    "2 года %s",
+	HumanizeTimeYears:     "%d г. %s",
+	HumanizeTimeLongWhile: "очень давно %s",
+
+	LeaveBeforeUnsubmit: "Если уйти со страницы, не отправив форму, все несохраненные данные будут потеряны.",
+
+	RecordNotFound: "запись не найдена",
+}
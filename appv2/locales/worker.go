@@ -0,0 +1,30 @@
+package locales
+
+import "github.com/qor5/admin/v3/worker"
+
+var Worker_ru = &worker.Messages{
+	StatusNew:                "Новая",
+	StatusScheduled:          "Запланирована",
+	StatusRunning:            "Выполняется",
+	StatusCancelled:          "Отменена",
+	StatusDone:               "Готово",
+	StatusException:          "Ошибка",
+	StatusKilled:             "Прервана",
+	FilterTabAll:             "Все задачи",
+	FilterTabRunning:         "Выполняются",
+	FilterTabScheduled:       "Запланированы",
+	FilterTabDone:            "Готовы",
+	FilterTabErrors:          "Ошибки",
+	ActionCancelJob:          "Отменить задачу",
+	ActionAbortJob:           "Прервать задачу",
+	ActionUpdateJob:          "Обновить задачу",
+	ActionRerunJob:           "Перезапустить задачу",
+	DetailTitleStatus:        "Статус",
+	DetailTitleLog:           "Журнал",
+	NoticeJobCannotBeAborted: "Задачу нельзя прервать, отменить или обновить из-за смены статуса",
+	NoticeJobWontBeExecuted:  "Задача не будет выполнена, так как ее код удален или изменен",
+	ScheduleTime:             "Время запуска",
+	DateTimePickerClearText:  "Очистить",
+	DateTimePickerOkText:     "OK",
+	PleaseSelectJob:          "Выберите задачу",
+}
@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qor5/admin/v3/activity"
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/login"
+	. "github.com/theplant/htmlgo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+const pruneInterval = time.Hour
+
+type Audit struct {
+	db     *gorm.DB
+	config Config
+	logger *zap.Logger
+	ab     *activity.Builder
+	stop   chan struct{}
+}
+
+func New(db *gorm.DB, config Config, logger *zap.Logger) *Audit {
+	a := &Audit{
+		db:     db,
+		config: config,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+
+	a.ab = activity.New(db, currentUser).
+		FindUsersFunc(a.findUsers)
+
+	return a
+}
+
+// currentUser is resolved from the login middleware
+func currentUser(ctx context.Context) (*activity.User, error) {
+	u, ok := ctx.Value(login.UserKey).(*models.User)
+	if !ok || u == nil {
+		return nil, errors.New("no current user")
+	}
+
+	return &activity.User{
+		ID:   fmt.Sprint(u.ID),
+		Name: u.Name,
+	}, nil
+}
+
+func (a *Audit) findUsers(ctx context.Context, ids []string) (map[string]*activity.User, error) {
+	var users []models.User
+	if err := a.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	r := make(map[string]*activity.User, len(users))
+	for _, u := range users {
+		id := fmt.Sprint(u.ID)
+		r[id] = &activity.User{
+			ID:   id,
+			Name: u.Name,
+		}
+	}
+
+	return r, nil
+}
+
+// Register logs create, edit and delete of the model and shows the timeline on its detail page,
+// must be called after editing and detailing are configured
+func (a *Audit) Register(mb *presets.ModelBuilder, ignored ...string) {
+	amb := a.ab.RegisterModel(mb).AddIgnoredFields(ignored...)
+
+	if mb.HasDetailing() {
+		mb.Detailing().SidePanelFunc(func(obj interface{}, ctx *web.EventContext) HTMLComponent {
+			return amb.NewTimelineCompo(ctx, obj, "_side")
+		})
+	}
+}
+
+// Log records action made outside of presets editing, e.g. through the api,
+// model of obj must be registered
+func (a *Audit) Log(ctx context.Context, action string, obj any) {
+	if _, err := a.ab.Log(ctx, action, obj, nil); err != nil {
+		a.logger.Error("error on log activity", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// Configure adds the activity log page
+func (a *Audit) Configure(b *presets.Builder) {
+	b.Use(a.ab)
+}
+
+func (a *Audit) Migrate() {
+	a.ab.AutoMigrate()
+
+	if err := a.Prune(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// Prune deletes activity logs older than retention period
+func (a *Audit) Prune(ctx context.Context) error {
+	if a.config.RetentionDays <= 0 {
+		return nil
+	}
+
+	before := time.Now().AddDate(0, 0, -a.config.RetentionDays)
+	res := a.db.WithContext(ctx).
+		Unscoped().
+		Where("created_at < ?", before).
+		Delete(&activity.ActivityLog{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected > 0 {
+		a.logger.Info("activity logs pruned", zap.Int64("count", res.RowsAffected))
+	}
+
+	return nil
+}
+
+// Start prunes old logs periodically
+func (a *Audit) Start() {
+	if a.config.RetentionDays <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+				if err := a.Prune(context.Background()); err != nil {
+					a.logger.Error("error on prune activity logs", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (a *Audit) Stop() {
+	close(a.stop)
+}
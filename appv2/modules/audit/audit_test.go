@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qor5/admin/v3/activity"
+	"github.com/qor5/x/v3/login"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestCurrentUser(t *testing.T) {
+	if _, err := currentUser(context.Background()); err == nil {
+		t.Error("anonymous context has user")
+	}
+
+	ctx := context.WithValue(context.Background(), login.UserKey, &models.User{Model: gorm.Model{ID: 7}, Name: "kovardin"})
+	u, err := currentUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != "7" || u.Name != "kovardin" {
+		t.Errorf("unexpected user %+v", u)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	db := dbtest.Open(t)
+	a := New(db, Config{RetentionDays: 30}, zap.NewNop())
+	a.Migrate()
+
+	now := time.Now()
+	logs := []activity.ActivityLog{
+		{UserID: "1", Action: "Create", ModelName: "Package", ModelKeys: "1", Model: gorm.Model{CreatedAt: now.AddDate(0, 0, -40)}},
+		{UserID: "1", Action: "Edit", ModelName: "Package", ModelKeys: "1", Model: gorm.Model{CreatedAt: now.AddDate(0, 0, -29)}},
+		{UserID: "1", Action: "Delete", ModelName: "Package", ModelKeys: "1", Model: gorm.Model{CreatedAt: now}},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	// soft deleted logs are pruned too
+	old := activity.ActivityLog{UserID: "1", Action: "Edit", ModelName: "Package", ModelKeys: "2", Model: gorm.Model{CreatedAt: now.AddDate(-1, 0, 0)}}
+	db.Create(&old)
+	db.Delete(&old)
+
+	if err := a.Prune(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	db.Unscoped().Model(&activity.ActivityLog{}).Order("id").Pluck("action", &actions)
+	if len(actions) != 2 || actions[0] != "Edit" || actions[1] != "Delete" {
+		t.Errorf("unexpected logs after prune %v", actions)
+	}
+
+	// logs are kept forever without retention
+	forever := New(db, Config{}, zap.NewNop())
+	db.Create(&activity.ActivityLog{UserID: "1", Action: "Create", ModelName: "Package", ModelKeys: "3", Model: gorm.Model{CreatedAt: now.AddDate(-5, 0, 0)}})
+	if err := forever.Prune(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&activity.ActivityLog{}).Count(&count)
+	if count != 3 {
+		t.Errorf("expected 3 logs without retention, got %d", count)
+	}
+}
@@ -0,0 +1,6 @@
+package audit
+
+type Config struct {
+	// RetentionDays is how long activity logs are kept, 0 keeps them forever
+	RetentionDays int `yaml:"retention_days"`
+}
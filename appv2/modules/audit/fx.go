@@ -0,0 +1,10 @@
+package audit
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"audit",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,235 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qor5/admin/v3/activity"
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+const (
+	// limit of rows in every card
+	limit = 10
+	// week is the window of the most downloaded modules
+	week = 7 * 24 * time.Hour
+)
+
+// Dashboard is the admin home page with operational state of the service
+type Dashboard struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Dashboard {
+	return &Dashboard{
+		db: db,
+	}
+}
+
+// Configure replaces the admin home page, must be called after models are configured
+func (d *Dashboard) Configure(b *presets.Builder) {
+	b.HomePageFunc(func(ctx *web.EventContext) (r web.PageResponse, err error) {
+		r.PageTitle = i18n.T(ctx.R, presets.ModelsI18nModuleKey, "Dashboard")
+
+		cards := []struct {
+			uri  string
+			card func(*http.Request, string) HTMLComponent
+		}{
+			{"packages", d.packages},
+			{"resolutions", d.downloads},
+			{"resolutions", d.failed},
+			{"packages", d.unreachable},
+			{"activity-logs", d.activity},
+			{"workers", d.failures},
+		}
+
+		row := VRow()
+		for _, c := range cards {
+			// cards of listings the user can not open are hidden
+			if b.GetVerifier().Spawn().SnakeOn(c.uri).Do(presets.PermList).WithReq(ctx.R).IsAllowed() != nil {
+				continue
+			}
+			row.AppendChildren(VCol(c.card(ctx.R, b.GetURIPrefix()+"/"+c.uri)).Cols(12).Md(6))
+		}
+
+		r.Body = VContainer(row).Fluid(true)
+		return
+	})
+}
+
+// packages counts packages by status
+func (d *Dashboard) packages(r *http.Request, href string) HTMLComponent {
+	var counts struct {
+		Active      int64
+		Inactive    int64
+		Unreachable int64
+	}
+	d.owned(r).Model(&models.Package{}).
+		Select(`COUNT(*) FILTER (WHERE active) AS active, ` +
+			`COUNT(*) FILTER (WHERE NOT active) AS inactive, ` +
+			`COUNT(*) FILTER (WHERE repo_error <> '') AS unreachable`).
+		Scan(&counts)
+
+	return d.card(r, "Packages", href,
+		VListItem().Title(d.t(r, "Active")).Href(href+"?active=true").Children(d.count(counts.Active)),
+		VListItem().Title(d.t(r, "Inactive")).Href(href+"?active=false").Children(d.count(counts.Inactive)),
+		VListItem().Title(d.t(r, "Unreachable")).Href(href+"?unreachable=true").Children(d.count(counts.Unreachable)),
+	)
+}
+
+// downloads lists modules the go command resolved most during the week
+func (d *Dashboard) downloads(r *http.Request, href string) HTMLComponent {
+	var rows []struct {
+		Path  string
+		Count int64
+	}
+	d.db.WithContext(r.Context()).Model(&models.Resolution{}).
+		Select("path, SUM(count) AS count").
+		Where("package_id <> 0 AND day >= ?", time.Now().UTC().Add(-week).Truncate(24*time.Hour)).
+		Group("path").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&rows)
+
+	href += "?found=true"
+	items := make([]HTMLComponent, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, VListItem().Title(row.Path).Href(href).Children(d.count(row.Count)))
+	}
+
+	return d.card(r, "Downloads", href, items...)
+}
+
+// failed lists paths requested by the go command which nobody registered
+func (d *Dashboard) failed(r *http.Request, href string) HTMLComponent {
+	var rs []models.Resolution
+	d.db.WithContext(r.Context()).
+		Where("package_id = 0").
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&rs)
+
+	href += "?found=false"
+	items := make([]HTMLComponent, 0, len(rs))
+	for _, res := range rs {
+		items = append(items, VListItem().Title(res.Path).Subtitle(d.time(res.UpdatedAt)).Href(href).Children(d.count(res.Count)))
+	}
+
+	return d.card(r, "Failed resolutions", href, items...)
+}
+
+// unreachable lists packages whose repository failed on the last refresh
+func (d *Dashboard) unreachable(r *http.Request, href string) HTMLComponent {
+	var ps []models.Package
+	d.owned(r).
+		Where("repo_error <> ''").
+		Order("checked_at DESC").
+		Limit(limit).
+		Find(&ps)
+
+	items := make([]HTMLComponent, 0, len(ps))
+	for _, p := range ps {
+		items = append(items, VListItem().Title(p.Package).Subtitle(p.RepoError).Href(fmt.Sprintf("%s/%d", href, p.ID)))
+	}
+
+	return d.card(r, "Unreachable repositories", href+"?unreachable=true", items...)
+}
+
+// activity lists the last changes made in admin
+func (d *Dashboard) activity(r *http.Request, href string) HTMLComponent {
+	var logs []activity.ActivityLog
+	d.db.WithContext(r.Context()).
+		Where("hidden = ?", false).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs)
+
+	ids := make([]string, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, l.UserID)
+	}
+
+	var users []activity.ActivityUser
+	if len(ids) > 0 {
+		d.db.WithContext(r.Context()).Where("id IN ?", ids).Find(&users)
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+
+	items := make([]HTMLComponent, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, VListItem().
+			Title(fmt.Sprintf("%s: %s %s", names[l.UserID], l.Action, l.ModelLabel)).
+			Subtitle(fmt.Sprintf("%s, %s", l.ModelKeys, d.time(l.CreatedAt))).
+			Href(href))
+	}
+
+	return d.card(r, "Recent activity", href, items...)
+}
+
+// failures lists the last failed worker jobs
+func (d *Dashboard) failures(r *http.Request, href string) HTMLComponent {
+	var jobs []worker.QorJob
+	d.db.WithContext(r.Context()).
+		Where("status IN ?", []string{worker.JobStatusException, worker.JobStatusKilled}).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&jobs)
+
+	href += "?status=" + worker.JobStatusException
+	items := make([]HTMLComponent, 0, len(jobs))
+	for _, j := range jobs {
+		items = append(items, VListItem().
+			Title(i18n.PT(r, presets.ModelsI18nModuleKey, "WorkerJob", j.Job)).
+			Subtitle(fmt.Sprintf("%s, %s", j.Status, d.time(j.UpdatedAt))).
+			Href(href))
+	}
+
+	return d.card(r, "Job failures", href, items...)
+}
+
+// card links its title to the filtered listing
+func (d *Dashboard) card(r *http.Request, title, href string, items ...HTMLComponent) HTMLComponent {
+	list := VList(items...).Density(DensityCompact)
+	if len(items) == 0 {
+		list = VList(VListItem().Subtitle(d.t(r, "Empty"))).Density(DensityCompact)
+	}
+
+	return VCard(
+		VCardTitle(A().Text(d.t(r, title)).Href(href).Class("text-decoration-none")),
+		VCardText(list),
+	).Variant(VariantOutlined).Class("h-100")
+}
+
+func (d *Dashboard) count(n int64) HTMLComponent {
+	return Template(VChip(Text(fmt.Sprint(n))).Size(SizeSmall)).Attr("#append", true)
+}
+
+func (d *Dashboard) time(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func (d *Dashboard) t(r *http.Request, key string) string {
+	return i18n.PT(r, presets.ModelsI18nModuleKey, "Dashboard", key)
+}
+
+// owned limits packages of maintainers to their namespaces like the packages listing does
+func (d *Dashboard) owned(r *http.Request) *gorm.DB {
+	db := d.db.WithContext(r.Context())
+	if user := umodels.CurrentUser(r); user != nil && !user.IsAdmin() && user.HasRole(umodels.RoleMaintainer) {
+		db = db.Where(models.OwnedCondition, models.Owned(user.ID))
+	}
+	return db
+}
@@ -0,0 +1,10 @@
+package dashboard
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"dashboard",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,10 @@
+package jobs
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"jobs",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"github.com/qor5/web/v3"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// Jobs runs long operations of admin in background, jobs are stored in postgres
+type Jobs struct {
+	db *gorm.DB
+	wb *worker.Builder
+	// mb is the jobs page, dialogs of action jobs are its events
+	mb *presets.ModelBuilder
+}
+
+func New(db *gorm.DB) *Jobs {
+	wb := worker.NewWithOptions(&worker.Options{DB: db}).
+		GetCurrentUserIDFunc(currentUserID)
+
+	return &Jobs{
+		db: db,
+		wb: wb,
+	}
+}
+
+// currentUserID is stored as job operator, handlers use it to check permissions
+func currentUserID(r *http.Request) string {
+	u := models.CurrentUser(r)
+	if u == nil {
+		return ""
+	}
+	return fmt.Sprint(u.ID)
+}
+
+// Worker is used by modules to register their jobs
+func (j *Jobs) Worker() *worker.Builder {
+	return j.wb
+}
+
+// Operator loads the user who started the job
+func (j *Jobs) Operator(ctx context.Context, job worker.QorJobInterface) (*models.User, error) {
+	info, err := job.GetJobInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{}
+	if err := j.db.WithContext(ctx).Preload("Roles").Where("id = ?", info.Operator).First(user).Error; err != nil {
+		return nil, fmt.Errorf("job operator %q: %w", info.Operator, err)
+	}
+
+	return user, nil
+}
+
+// URL opens dialog of the action job registered for the model like action.URL, the query is passed
+// to the dialog, so params fields can be prefilled, e.g. with selected records
+func (j *Jobs) URL(mb *presets.ModelBuilder, name, key, value string) string {
+	return web.Plaid().
+		URL(j.mb.Info().ListingHref()).
+		EventFunc(worker.ActionJobInputParams).
+		Query("jobName", actionName(mb, name)).
+		Query(key, value).
+		Go()
+}
+
+// actionName is the name worker registers action jobs by
+func actionName(mb *presets.ModelBuilder, name string) string {
+	return fmt.Sprintf("Action Job - %s - %s", mb.Info().Label(), name)
+}
+
+// Configure adds the jobs page
+func (j *Jobs) Configure(b *presets.Builder) {
+	b.Use(j.wb)
+	j.mb = b.LookUpModelBuilder("workers")
+}
+
+func (j *Jobs) Migrate() {
+	if err := worker.AutoMigrate(j.db); err != nil {
+		panic(err)
+	}
+}
+
+// Start listens the queue, all jobs must be registered before
+func (j *Jobs) Start() {
+	j.wb.Listen()
+}
+
+func (j *Jobs) Stop(ctx context.Context) error {
+	return j.wb.Shutdown(ctx)
+}
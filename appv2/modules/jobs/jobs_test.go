@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID uint
+}
+
+func TestURL(t *testing.T) {
+	// connection is opened lazily, the test never queries
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pb := presets.New()
+	jobs := New(db)
+	jobs.Configure(pb)
+
+	mb := pb.Model(&item{})
+	action := jobs.Worker().ActionJob("Refresh", mb, func(ctx context.Context, job worker.QorJobInterface) error { return nil })
+
+	url := jobs.URL(mb, "Refresh", "ids", "1,2")
+
+	// dialog of the registered job is opened with the query
+	base := strings.TrimSuffix(action.URL(), ".go()")
+	if !strings.HasPrefix(url, base) {
+		t.Errorf("url does not open the action job:\n%s\n%s", url, action.URL())
+	}
+	if !strings.Contains(url, `query("ids", "1,2")`) {
+		t.Errorf("query is not passed: %s", url)
+	}
+}
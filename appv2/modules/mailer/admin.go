@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"strconv"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/relay"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer/models"
+)
+
+// Configure adds the outbox page, emails are created by the mailer only,
+// permissions deny changing them and show them to admins only
+func (m *Mailer) Configure(b *presets.Builder) {
+	ma := b.Model(&models.Email{}).
+		MenuIcon("mdi-email-outline")
+
+	lb := ma.Listing("ID", "Recipient", "Subject", "Status", "Attempts", "NextAt", "CreatedAt").
+		DefaultOrderBy(relay.Order{Field: "CreatedAt", Direction: relay.OrderDirectionDesc}).
+		SearchColumns("recipient", "subject")
+
+	lb.Field("NextAt").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		e := obj.(*models.Email)
+		if e.Status != models.StatusPending {
+			return Td()
+		}
+		return Td(Text(e.NextAt.Format("2006-01-02 15:04:05")))
+	})
+	lb.Field("CreatedAt").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(obj.(*models.Email).CreatedAt.Format("2006-01-02 15:04:05")))
+	})
+
+	lb.FilterDataFunc(func(ctx *web.EventContext) vx.FilterData {
+		var options []*vx.SelectItem
+		for _, s := range []string{models.StatusPending, models.StatusSent, models.StatusFailed} {
+			options = append(options, &vx.SelectItem{Text: s, Value: s})
+		}
+		return []*vx.FilterItem{
+			{
+				Key:          "status",
+				Label:        i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Emails", "Status"),
+				ItemType:     vx.ItemTypeSelect,
+				SQLCondition: `emails.status = ?`,
+				Options:      options,
+			},
+		}
+	})
+
+	lb.RowMenu().RowMenuItem("Retry").
+		OnClick(func(ctx *web.EventContext, id string) (r web.EventResponse, err error) {
+			n, _ := strconv.ParseUint(id, 10, 64)
+			if err := m.Retry(ctx.R.Context(), uint(n)); err != nil {
+				presets.ShowMessage(&r, err.Error(), ColorError)
+				return r, nil
+			}
+
+			presets.ShowMessage(&r, i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Emails", "Queued"), "")
+			r.Emit(ma.NotifModelsUpdated(), presets.PayloadModelsUpdated{Ids: []string{id}})
+			return
+		})
+
+	ma.Detailing("Recipient", "Subject", "Template", "Status", "Attempts", "SentAt", "Error", "Body").
+		Field("Body").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			return Pre(body(obj.(*models.Email))).Style("white-space: pre-wrap")
+		})
+}
+
+// body of the email for admins, emails with one-time links are redacted even before delivery
+func body(e *models.Email) string {
+	if secret[e.Template] {
+		return "[redacted]"
+	}
+	return e.Body
+}
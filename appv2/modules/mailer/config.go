@@ -0,0 +1,124 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Transports deliver emails: smtp sends them, file writes .eml files, log only logs them
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+var transports = []string{TransportSMTP, TransportFile, TransportLog}
+
+const (
+	defaultFrom        = "GoHome <gohome@localhost>"
+	defaultMaxAttempts = 10
+	defaultSMTPPort    = 587
+)
+
+type Config struct {
+	// Transport is smtp, file or log, it is required outside dev mode, dev uses log when empty
+	Transport string `yaml:"transport"`
+	// From is the sender, e.g. "GoHome <noreply@example.com>"
+	From string `yaml:"from"`
+	// Language of notifications, ru or en, reset password emails use language of the request
+	Language string `yaml:"language"`
+	// BaseURL of the admin for links in notifications, e.g. https://admin.example.com
+	BaseURL string `yaml:"base_url"`
+	// Dir stores emails of the file transport
+	Dir string `yaml:"dir"`
+	// MaxAttempts of delivery before the email is marked failed
+	MaxAttempts int        `yaml:"max_attempts"`
+	SMTP        SMTPConfig `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" secret:"true"`
+	// TLS connects with implicit tls, usually on port 465,
+	// otherwise STARTTLS is used when the server supports it
+	TLS bool `yaml:"tls"`
+}
+
+// Validate checks the config, dev mode allows to omit transport
+func (c Config) Validate(dev bool) error {
+	var errs []error
+
+	if c.Transport == "" && !dev {
+		errs = append(errs, fmt.Errorf("transport is required, one of %s", strings.Join(transports, ", ")))
+	}
+	if c.Transport != "" && !slices.Contains(transports, c.Transport) {
+		errs = append(errs, fmt.Errorf("transport must be one of %s", strings.Join(transports, ", ")))
+	}
+	if _, err := mail.ParseAddress(c.from()); err != nil {
+		errs = append(errs, fmt.Errorf("from: %w", err))
+	}
+	if c.Language != "" && c.Language != "ru" && c.Language != "en" {
+		errs = append(errs, errors.New("language must be ru or en"))
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("base url %q must be absolute http url", c.BaseURL))
+		}
+	}
+	if c.MaxAttempts < 0 {
+		errs = append(errs, errors.New("max attempts must not be negative"))
+	}
+
+	switch c.Transport {
+	case TransportSMTP:
+		if c.SMTP.Host == "" {
+			errs = append(errs, errors.New("smtp host is required"))
+		}
+		if c.SMTP.Port < 0 || c.SMTP.Port > 65535 {
+			errs = append(errs, errors.New("smtp port is invalid"))
+		}
+	case TransportFile:
+		if c.Dir == "" {
+			errs = append(errs, errors.New("dir is required for file transport"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) transport() string {
+	if c.Transport == "" {
+		return TransportLog
+	}
+	return c.Transport
+}
+
+func (c Config) from() string {
+	if c.From == "" {
+		return defaultFrom
+	}
+	return c.From
+}
+
+func (c Config) maxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return defaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+func (c SMTPConfig) port() int {
+	if c.Port == 0 {
+		if c.TLS {
+			return 465
+		}
+		return defaultSMTPPort
+	}
+	return c.Port
+}
@@ -0,0 +1,10 @@
+package mailer
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"mailer",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,330 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer/models"
+)
+
+const (
+	// pollInterval is how often the outbox is checked for due emails
+	pollInterval = 10 * time.Second
+	// batchSize limits emails sent by one check
+	batchSize = 20
+	// sendTimeout limits one delivery attempt, the email is claimed for this time
+	sendTimeout = time.Minute
+	// retryDelay grows twice with every failed attempt up to maxRetryDelay
+	retryDelay    = time.Minute
+	maxRetryDelay = 6 * time.Hour
+	// retention of sent emails
+	retention = 30 * 24 * time.Hour
+)
+
+// errSecret is returned on retry of emails with one-time links, the user requests a new one
+var errSecret = errors.New("emails with one-time links can not be sent again")
+
+// Mailer queues emails in the outbox table and delivers them in background,
+// so emails survive restarts and failures of the mail server
+type Mailer struct {
+	db        *gorm.DB
+	config    Config
+	logger    *zap.Logger
+	transport Transport
+	lang      language.Tag
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func New(db *gorm.DB, config Config, logger *zap.Logger) *Mailer {
+	m := &Mailer{
+		db:     db,
+		config: config,
+		logger: logger,
+		lang:   language.Russian,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if config.Language == "en" {
+		m.lang = language.English
+	}
+
+	switch config.transport() {
+	case TransportSMTP:
+		m.transport = NewSMTP(config.SMTP)
+	case TransportFile:
+		m.transport = NewFile(config.Dir)
+	default:
+		m.transport = NewLog(logger)
+	}
+
+	return m
+}
+
+// URL makes absolute admin url of the path, empty when base url is not configured
+func (m *Mailer) URL(path string) string {
+	if m.config.BaseURL == "" {
+		return ""
+	}
+	return m.config.BaseURL + path
+}
+
+// Send renders the template in language closest to lang and queues the email,
+// language.Und selects the configured language of notifications
+func (m *Mailer) Send(ctx context.Context, to string, lang language.Tag, name string, data any) error {
+	if lang == language.Und {
+		lang = m.lang
+	}
+
+	subject, body, err := render(name, lang, data)
+	if err != nil {
+		return err
+	}
+
+	email := models.Email{
+		Recipient: to,
+		Subject:   subject,
+		Body:      body,
+		Template:  name,
+		Status:    models.StatusPending,
+		NextAt:    time.Now(),
+	}
+	if err := m.db.WithContext(ctx).Create(&email).Error; err != nil {
+		return err
+	}
+
+	m.wakeUp()
+	return nil
+}
+
+// wakeUp makes the worker check the outbox right away
+func (m *Mailer) wakeUp() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Retry queues the failed email again with fresh attempts,
+// emails of secret templates are refused, their bodies are already cleared
+func (m *Mailer) Retry(ctx context.Context, id uint) error {
+	var email models.Email
+	if err := m.db.WithContext(ctx).Select("id", "template").First(&email, id).Error; err != nil {
+		return err
+	}
+	if secret[email.Template] {
+		return errSecret
+	}
+
+	res := m.db.WithContext(ctx).Model(&models.Email{}).
+		Where("id = ? AND status = ?", id, models.StatusFailed).
+		Updates(map[string]interface{}{
+			"status":   models.StatusPending,
+			"next_at":  time.Now(),
+			"attempts": 0,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("only failed emails can be sent again")
+	}
+
+	m.wakeUp()
+	return nil
+}
+
+// Deliver sends due emails and returns how many of them were attempted.
+// Every email is claimed before sending, so several instances share the outbox
+func (m *Mailer) Deliver(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	var due []models.Email
+	err := m.db.WithContext(ctx).
+		Where("status = ? AND next_at <= ?", models.StatusPending, now).
+		Order("next_at, id").
+		Limit(batchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, email := range due {
+		// other instance may have claimed the email, the claim expires if the instance dies
+		res := m.db.WithContext(ctx).Model(&models.Email{}).
+			Where("id = ? AND status = ? AND next_at <= ?", email.ID, models.StatusPending, now).
+			Update("next_at", now.Add(sendTimeout))
+		if res.Error != nil {
+			return attempted, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		attempted++
+		if err := m.deliver(ctx, email); err != nil {
+			return attempted, err
+		}
+	}
+
+	return attempted, nil
+}
+
+func (m *Mailer) deliver(ctx context.Context, email models.Email) error {
+	sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	now := time.Now()
+	err := m.transport.Send(sctx, Message{
+		ID:      fmt.Sprintf("email-%d.%d", email.ID, email.CreatedAt.Unix()),
+		From:    m.config.from(),
+		To:      email.Recipient,
+		Subject: email.Subject,
+		Body:    email.Body,
+		Date:    now,
+	})
+
+	// interrupted by stop, the claim expires and the email is sent after restart
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+
+	attempts := email.Attempts + 1
+	values := map[string]interface{}{
+		"attempts": attempts,
+		"error":    "",
+	}
+
+	switch {
+	case err == nil:
+		values["status"] = models.StatusSent
+		values["sent_at"] = now
+		if secret[email.Template] {
+			values["body"] = ""
+		}
+	case attempts >= m.config.maxAttempts():
+		values["status"] = models.StatusFailed
+		values["error"] = err.Error()
+		if secret[email.Template] {
+			values["body"] = ""
+		}
+		m.logger.Error("email failed", zap.Uint("id", email.ID), zap.Int("attempts", attempts), zap.Error(err))
+	default:
+		values["next_at"] = now.Add(backoff(attempts))
+		values["error"] = err.Error()
+		m.logger.Warn("email postponed", zap.Uint("id", email.ID), zap.Int("attempts", attempts), zap.Error(err))
+	}
+
+	return m.db.WithContext(context.WithoutCancel(ctx)).Model(&models.Email{}).
+		Where("id = ?", email.ID).
+		Updates(values).Error
+}
+
+func backoff(attempts int) time.Duration {
+	d := retryDelay
+	for i := 1; i < attempts && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// Prune deletes sent emails older than retention period, failed ones are kept for admins
+func (m *Mailer) Prune(ctx context.Context) error {
+	return m.db.WithContext(ctx).
+		Unscoped().
+		Where("status = ? AND sent_at < ?", models.StatusSent, time.Now().Add(-retention)).
+		Delete(&models.Email{}).Error
+}
+
+func (m *Mailer) Migrate() {
+	if err := m.db.AutoMigrate(&models.Email{}); err != nil {
+		panic(err)
+	}
+
+	// bodies of secret emails delivered before they were cleared on delivery
+	if err := m.db.Model(&models.Email{}).
+		Where("template IN ? AND status <> ? AND body <> ''", secretTemplates(), models.StatusPending).
+		UpdateColumn("body", "").Error; err != nil {
+		panic(err)
+	}
+}
+
+func secretTemplates() []string {
+	names := make([]string, 0, len(secret))
+	for name := range secret {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Start delivers the outbox in background until Stop
+func (m *Mailer) Start() {
+	m.logger.Info("mailer", zap.String("transport", m.config.transport()))
+
+	go func() {
+		defer close(m.done)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		pruned := time.Time{}
+
+		for {
+			// full batches mean more emails are due
+			for {
+				n, err := m.Deliver(ctx)
+				if err != nil && ctx.Err() == nil {
+					m.logger.Error("error on deliver emails", zap.Error(err))
+				}
+				if err != nil || n < batchSize {
+					break
+				}
+			}
+
+			if time.Since(pruned) > time.Hour {
+				if err := m.Prune(ctx); err != nil && ctx.Err() == nil {
+					m.logger.Error("error on prune emails", zap.Error(err))
+				}
+				pruned = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-m.wake:
+			}
+		}
+	}()
+}
+
+// Stop interrupts delivery, emails being sent are retried after restart
+func (m *Mailer) Stop(ctx context.Context) error {
+	close(m.stop)
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
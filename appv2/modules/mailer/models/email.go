@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of emails in the outbox
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Email is a rendered message in the outbox, the worker delivers pending emails
+// when NextAt comes and postpones them after failed attempts
+type Email struct {
+	gorm.Model
+
+	Recipient string `gorm:"index"`
+	Subject   string
+	Body      string
+	// Template the email was rendered from
+	Template string
+
+	Status   string    `gorm:"default:pending;index:idx_emails_due,priority:1"`
+	NextAt   time.Time `gorm:"index:idx_emails_due,priority:2"`
+	Attempts int
+	SentAt   *time.Time
+	// Error of the last failed attempt
+	Error string
+}
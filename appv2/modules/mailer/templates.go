@@ -0,0 +1,152 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+// Templates of emails, every template has russian and english texts
+const (
+	TemplateResetPassword   = "reset_password"
+	TemplateRepoUnreachable = "repo_unreachable"
+	TemplateNewVersions     = "new_versions"
+)
+
+// secret templates contain one-time links, bodies of their emails are kept
+// only until delivery, they are never shown to admins or sent again
+var secret = map[string]bool{
+	TemplateResetPassword: true,
+}
+
+// ResetPassword is data of the reset password email
+type ResetPassword struct {
+	Name string
+	Link string
+}
+
+// RepoUnreachable is data of the email sent when repository of the package stops responding
+type RepoUnreachable struct {
+	Package string
+	Repo    string
+	Error   string
+	// URL of the package in admin, empty when base url is not configured
+	URL string
+}
+
+// NewVersions is data of the email sent when refresh finds new tags of the package
+type NewVersions struct {
+	Package  string
+	Versions []string
+	URL      string
+}
+
+var languages = []language.Tag{language.Russian, language.English}
+
+var matcher = language.NewMatcher(languages)
+
+// texts have the subject on the first line and the body after an empty line
+var texts = map[string]map[language.Tag]string{
+	TemplateResetPassword: {
+		language.Russian: `Восстановление пароля GoHome
+
+Здравствуйте{{with .Name}}, {{.}}{{end}}!
+
+Кто-то запросил восстановление пароля вашей учетной записи GoHome.
+Чтобы задать новый пароль, откройте ссылку:
+
+{{.Link}}
+
+Если вы не запрашивали восстановление, просто проигнорируйте это письмо.
+`,
+		language.English: `GoHome password reset
+
+Hello{{with .Name}}, {{.}}{{end}}!
+
+Somebody requested a password reset of your GoHome account.
+To set a new password open the link:
+
+{{.Link}}
+
+If you did not request the reset, just ignore this email.
+`,
+	},
+	TemplateRepoUnreachable: {
+		language.Russian: `Репозиторий {{.Package}} недоступен
+
+Не удалось получить репозиторий пакета {{.Package}}:
+
+{{.Repo}}
+{{.Error}}
+
+Пока репозиторий недоступен, go get не сможет скачать пакет.
+{{with .URL}}
+Пакет в админке: {{.}}
+{{end}}`,
+		language.English: `Repository of {{.Package}} is unreachable
+
+Repository of the package {{.Package}} can not be fetched:
+
+{{.Repo}}
+{{.Error}}
+
+go get fails to download the package until the repository is reachable.
+{{with .URL}}
+The package in admin: {{.}}
+{{end}}`,
+	},
+	TemplateNewVersions: {
+		language.Russian: `Новые версии {{.Package}}: {{join .Versions ", "}}
+
+В репозитории пакета {{.Package}} найдены новые версии:
+{{range .Versions}}
+  {{.}}{{end}}
+{{with .URL}}
+Пакет в админке: {{.}}
+{{end}}`,
+		language.English: `New versions of {{.Package}}: {{join .Versions ", "}}
+
+New versions of the package {{.Package}} are found in its repository:
+{{range .Versions}}
+  {{.}}{{end}}
+{{with .URL}}
+The package in admin: {{.}}
+{{end}}`,
+	},
+}
+
+var templates = parse()
+
+func parse() map[string]map[language.Tag]*template.Template {
+	funcs := template.FuncMap{"join": strings.Join}
+
+	r := map[string]map[language.Tag]*template.Template{}
+	for name, langs := range texts {
+		r[name] = map[language.Tag]*template.Template{}
+		for lang, text := range langs {
+			r[name][lang] = template.Must(template.New(name).Funcs(funcs).Parse(text))
+		}
+	}
+	return r
+}
+
+// render executes the template in the language closest to lang
+func render(name string, lang language.Tag, data any) (subject, body string, err error) {
+	langs, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	_, i, _ := matcher.Match(lang)
+	t := langs[languages[i]]
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+
+	subject, body, _ = strings.Cut(b.String(), "\n\n")
+	return strings.TrimSpace(subject), strings.TrimLeft(body, "\n"), nil
+}
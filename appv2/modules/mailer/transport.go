@@ -0,0 +1,197 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is an email ready for delivery
+type Message struct {
+	// ID is unique for the message, it is used in Message-ID header and file names
+	ID      string
+	From    string
+	To      string
+	Subject string
+	Body    string
+	Date    time.Time
+}
+
+// Bytes formats the message as plain text utf-8 email
+func (m Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var b bytes.Buffer
+	header := func(k, v string) {
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.BEncoding.Encode("utf-8", m.Subject))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+m.ID+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Transport delivers the message, errors are retried by the outbox
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP sends messages to the mail server, connection is opened for every message
+type SMTP struct {
+	config SMTPConfig
+}
+
+func NewSMTP(config SMTPConfig) *SMTP {
+	return &SMTP{config: config}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(msg.From)
+	to, _ := mail.ParseAddress(msg.To)
+
+	host := s.config.Host
+	addr := net.JoinHostPort(host, strconv.Itoa(s.config.port()))
+
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// stop interrupts the delivery, the server may wait for data forever
+	defer context.AfterFunc(ctx, func() { conn.Close() })()
+	if s.config.TLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !s.config.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// plain auth is refused by net/smtp without tls, except for localhost
+	if s.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// File writes messages as .eml files, e.g. to check emails in development
+type File struct {
+	dir string
+}
+
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+func (f *File) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := filepath.Join(f.dir, msg.Date.Format("20060102-150405")+"-"+msg.ID+".eml")
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// Log only logs that messages are sent, bodies are not logged, they contain reset password links
+type Log struct {
+	logger *zap.Logger
+}
+
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	l.logger.Info("email",
+		zap.String("id", msg.ID),
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.Int("size", len(msg.Body)),
+	)
+	return nil
+}
@@ -0,0 +1,284 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// received is a message accepted by fakeSMTP
+type received struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// fakeSMTP is a minimal SMTP server on a local port. Replies maps commands, e.g. "RCPT",
+// to replies sent instead of success, so tests can reject messages with 4xx and 5xx codes
+type fakeSMTP struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	replies  map[string]string
+	messages []received
+	auth     []string
+	// hold blocks MAIL until it is closed, to keep delivery in progress
+	hold chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeSMTP{ln: ln, replies: map[string]string{}}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// config points the smtp transport to the server
+func (s *fakeSMTP) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPConfig{Host: host, Port: p}
+}
+
+func (s *fakeSMTP) reply(cmd, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reply == "" {
+		delete(s.replies, cmd)
+		return
+	}
+	s.replies[cmd] = reply
+}
+
+func (s *fakeSMTP) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received(nil), s.messages...)
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	var msg received
+	tp.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		cmd = strings.ToUpper(cmd)
+
+		s.mu.Lock()
+		reply, rejected := s.replies[cmd]
+		hold := s.hold
+		s.mu.Unlock()
+		if rejected {
+			tp.PrintfLine("%s", reply)
+			continue
+		}
+
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, arg)
+			s.mu.Unlock()
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			if hold != nil {
+				<-hold
+			}
+			msg = received{From: strings.TrimSuffix(strings.TrimPrefix(arg, "FROM:<"), ">")}
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, strings.TrimSuffix(strings.TrimPrefix(arg, "TO:<"), ">"))
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 ok")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func testMessage() Message {
+	return Message{
+		ID:      "email-1.1700000000",
+		From:    "GoHome <noreply@example.com>",
+		To:      "Артем <artem@example.com>",
+		Subject: "Восстановление пароля",
+		Body:    "Ссылка для восстановления:\nhttps://admin.example.com/auth/reset?token=x\n",
+		Date:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	srv := newFakeSMTP(t)
+	cfg := srv.config()
+	cfg.Username, cfg.Password = "user", "secret"
+
+	if err := NewSMTP(cfg).Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.received()
+	if len(msgs) != 1 {
+		t.Fatalf("expected one message, got %d", len(msgs))
+	}
+	got := msgs[0]
+	if got.From != "noreply@example.com" || len(got.To) != 1 || got.To[0] != "artem@example.com" {
+		t.Errorf("unexpected envelope %s -> %v", got.From, got.To)
+	}
+	if len(srv.auth) != 1 || !strings.HasPrefix(srv.auth[0], "PLAIN ") {
+		t.Errorf("expected plain auth, got %v", srv.auth)
+	}
+
+	m, err := mail.ReadMessage(bytes.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil || subject != "Восстановление пароля" {
+		t.Errorf("unexpected subject %q: %v", subject, err)
+	}
+	to, err := m.Header.AddressList("To")
+	if err != nil || to[0].Name != "Артем" || to[0].Address != "artem@example.com" {
+		t.Errorf("unexpected to %v: %v", to, err)
+	}
+	headers := map[string]string{
+		"Message-Id":                "<email-1.1700000000@example.com>",
+		"Date":                      "Wed, 01 May 2024 10:00:00 +0000",
+		"Mime-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+	}
+	for k, v := range headers {
+		if got := m.Header.Get(k); got != v {
+			t.Errorf("%s: got %q, want %q", k, got, v)
+		}
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(m.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ReadDotBytes of the server turns CRLF back to LF
+	if string(body) != testMessage().Body {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestSMTPRejected(t *testing.T) {
+	srv := newFakeSMTP(t)
+	transport := NewSMTP(srv.config())
+
+	for _, code := range []int{451, 550} {
+		srv.reply("RCPT", fmt.Sprintf("%d mailbox unavailable", code))
+
+		err := transport.Send(context.Background(), testMessage())
+		var perr *textproto.Error
+		if !errors.As(err, &perr) || perr.Code != code {
+			t.Errorf("expected %d error, got %v", code, err)
+		}
+	}
+
+	if n := len(srv.received()); n != 0 {
+		t.Errorf("rejected messages are received: %d", n)
+	}
+}
+
+func TestSMTPUnreachable(t *testing.T) {
+	srv := newFakeSMTP(t)
+	cfg := srv.config()
+	srv.ln.Close()
+
+	if err := NewSMTP(cfg).Send(context.Background(), testMessage()); err == nil {
+		t.Error("expected error of closed server")
+	}
+}
+
+func TestLogOmitsBody(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	if err := NewLog(zap.New(core)).Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if _, ok := fields["body"]; ok {
+		t.Error("body is logged")
+	}
+	for k, v := range fields {
+		if s, ok := v.(string); ok && strings.Contains(s, "token=") {
+			t.Errorf("field %s contains the reset link", k)
+		}
+	}
+	if fields["to"] != testMessage().To || fields["subject"] != testMessage().Subject {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestConfigTransportRequired(t *testing.T) {
+	if err := (Config{}).Validate(false); err == nil {
+		t.Error("empty transport is accepted outside dev")
+	}
+	if err := (Config{}).Validate(true); err != nil {
+		t.Errorf("empty transport is rejected in dev: %s", err)
+	}
+	if err := (Config{Transport: TransportLog}).Validate(false); err != nil {
+		t.Errorf("explicit log transport is rejected: %s", err)
+	}
+}
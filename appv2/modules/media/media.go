@@ -4,29 +4,25 @@ import (
 	"github.com/qor5/admin/v3/media"
 	"github.com/qor5/admin/v3/media/oss"
 	"github.com/qor5/admin/v3/presets"
+	xoss "github.com/qor5/x/v3/oss"
 	"gorm.io/gorm"
-
-	"gohome.4gophers.ru/getapp/gohome/appv2/s3storage"
 )
 
 type Media struct {
-	db        *gorm.DB
-	s3storage *s3storage.Client
+	db      *gorm.DB
+	storage xoss.StorageInterface
 }
 
-func New(db *gorm.DB, s3storage *s3storage.Client) *Media {
+func New(db *gorm.DB, storage xoss.StorageInterface) *Media {
 	return &Media{
-		db:        db,
-		s3storage: s3storage,
+		db:      db,
+		storage: storage,
 	}
 }
 
 func (m *Media) Configure(b *presets.Builder) {
-	oss.Storage = m.s3storage
+	oss.Storage = m.storage
 
 	mediab := media.New(m.db).AutoMigrate()
 	b.Use(mediab)
-
-	// mediab.GetPresetsModelBuilder().
-	// 	Label("Медиа")
 }
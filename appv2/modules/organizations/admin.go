@@ -0,0 +1,486 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/presets/gorm2op"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	"github.com/qor5/x/v3/perm"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// memberOf limits rows to organizations the user is a member of
+const memberOf = `organization_id IN (SELECT organization_id FROM members WHERE user_id = ?)`
+
+// ownerOf limits rows to organizations the user owns
+const ownerOf = `organization_id IN (SELECT organization_id FROM members WHERE user_id = ? AND role = 'owner')`
+
+// Configure adds organizations, members, invitations and transfers to admin,
+// maintainers see only organizations they are members of
+func (o *Organizations) Configure(b *presets.Builder) {
+	o.configureOrganizations(b)
+	o.configureMembers(b)
+	o.configureInvitations(b)
+	o.configureTransfers(b)
+}
+
+func (o *Organizations) configureOrganizations(b *presets.Builder) {
+	ma := b.Model(&models.Organization{}).
+		MenuIcon("mdi-domain")
+	defer o.audit.Register(ma)
+
+	lb := ma.Listing("ID", "Name", "Title")
+	o.scope(lb, "organizations.id IN (SELECT organization_id FROM members WHERE user_id = ?)", false)
+
+	ma.Detailing("Name", "Title", "Members").
+		Field("Members").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var members []models.Member
+			o.db.Where("organization_id = ?", obj.(*models.Organization).ID).Order("role DESC, id").Find(&members)
+
+			var names []string
+			for _, m := range members {
+				names = append(names, fmt.Sprintf("%s (%s)", o.userName(m.UserID), m.Role))
+			}
+
+			return vx.VXReadonlyField().Label(field.Label).Value(strings.Join(names, ", "))
+		})
+
+	ed := ma.Editing("Name", "Title")
+	ed.ValidateFunc(func(obj interface{}, ctx *web.EventContext) (errs web.ValidationErrors) {
+		return validation(o.validate(obj.(*models.Organization)))
+	})
+	ed.WrapSaveFunc(func(in presets.SaveFunc) presets.SaveFunc {
+		// the creator becomes the owner
+		return func(obj interface{}, id string, ctx *web.EventContext) error {
+			if id != "" {
+				return in(obj, id, ctx)
+			}
+			return saveError(o.Create(ctx.R.Context(), umodels.CurrentUser(ctx.R), obj.(*models.Organization)))
+		}
+	})
+	ed.WrapDeleteFunc(func(in presets.DeleteFunc) presets.DeleteFunc {
+		// namespaces and packages must get another owner first, otherwise nobody could manage them
+		return func(obj interface{}, id string, ctx *web.EventContext) error {
+			var count int64
+			o.db.Model(&models.Namespace{}).Where("organization_id = ?", id).Count(&count)
+			if count == 0 {
+				o.db.Model(&models.Package{}).Where("organization_id = ?", id).Count(&count)
+			}
+			if count > 0 {
+				return errors.New("Organization still owns namespaces or packages")
+			}
+
+			return o.db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Where("organization_id = ?", id).Delete(&models.Member{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("organization_id = ?", id).Delete(&models.Invitation{}).Error; err != nil {
+					return err
+				}
+
+				ctx.WithContextValue(gorm2op.CtxKeyDB{}, tx)
+				defer ctx.WithContextValue(gorm2op.CtxKeyDB{}, nil)
+				return in(obj, id, ctx)
+			})
+		}
+	})
+}
+
+func (o *Organizations) configureMembers(b *presets.Builder) {
+	ma := b.Model(&models.Member{}).
+		MenuIcon("mdi-account-key")
+	defer o.audit.Register(ma)
+
+	lb := ma.Listing("ID", "OrganizationID", "UserID", "Role")
+	o.scope(lb, "members."+memberOf, false)
+	o.names(lb, true)
+
+	ed := ma.Editing("OrganizationID", "UserID", "Role")
+	o.organizationField(ed, false, func(obj interface{}, id uint) { obj.(*models.Member).OrganizationID = id })
+	o.userField(ed, "UserID", func(obj interface{}, id uint) { obj.(*models.Member).UserID = id })
+	roleField(ed)
+
+	ed.ValidateFunc(func(obj interface{}, ctx *web.EventContext) (errs web.ValidationErrors) {
+		m := obj.(*models.Member)
+		if m.OrganizationID == 0 {
+			errs.FieldError("OrganizationID", "Organization is required")
+		}
+		if m.UserID == 0 {
+			errs.FieldError("UserID", "User is required")
+		}
+		if errs.HaveErrors() || m.ID == 0 {
+			return
+		}
+
+		// owners change roles, members join by invitations
+		var old models.Member
+		o.db.Where("id = ?", m.ID).Limit(1).Find(&old)
+		if old.OrganizationID != m.OrganizationID || old.UserID != m.UserID {
+			errs.GlobalError("Only role of the member can be changed")
+			return
+		}
+		return validation(o.keepOwner(o.db, old, m.Role))
+	})
+	ed.WrapDeleteFunc(func(in presets.DeleteFunc) presets.DeleteFunc {
+		return func(obj interface{}, id string, ctx *web.EventContext) error {
+			var m models.Member
+			o.db.Where("id = ?", id).Limit(1).Find(&m)
+			if err := o.keepOwner(o.db, m, ""); err != nil {
+				return err
+			}
+			return in(obj, id, ctx)
+		}
+	})
+}
+
+func (o *Organizations) configureInvitations(b *presets.Builder) {
+	ma := b.Model(&models.Invitation{}).
+		MenuIcon("mdi-email-plus")
+	defer o.audit.Register(ma)
+
+	lb := ma.Listing("ID", "OrganizationID", "Invitee", "Role", "Status", "CreatedAt")
+	// owners see invitations of their organizations, invitees see invitations addressed to them
+	lb.WrapSearchFunc(func(in presets.SearchFunc) presets.SearchFunc {
+		return func(ctx *web.EventContext, params *presets.SearchParams) (result *presets.SearchResult, err error) {
+			if user := umodels.CurrentUser(ctx.R); user != nil && !user.IsAdmin() {
+				params.SQLConditions = append(params.SQLConditions, &presets.SQLCondition{
+					Query: "(invitations." + ownerOf + " OR " + models.InviteeCondition + ")",
+					Args:  []interface{}{user.ID, user.Account, user.Email},
+				})
+			}
+			return in(ctx, params)
+		}
+	})
+	o.names(lb, false)
+
+	ed := ma.Editing("OrganizationID", "Invitee", "Role")
+	o.organizationField(ed, true, func(obj interface{}, id uint) { obj.(*models.Invitation).OrganizationID = id })
+	roleField(ed)
+
+	ed.WrapSaveFunc(func(in presets.SaveFunc) presets.SaveFunc {
+		return func(obj interface{}, id string, ctx *web.EventContext) error {
+			if id != "" {
+				return in(obj, id, ctx)
+			}
+			return saveError(o.Invite(ctx.R.Context(), umodels.CurrentUser(ctx.R), obj.(*models.Invitation)))
+		}
+	})
+
+	for _, a := range []struct {
+		name   string
+		accept bool
+	}{
+		{"Accept", true},
+		{"Decline", false},
+	} {
+		accept := a.accept
+		o.rowAction(ma, lb, a.name, func(ctx *web.EventContext, user *umodels.User, id uint) error {
+			if err := o.Respond(ctx.R.Context(), user, id, accept); err != nil {
+				return err
+			}
+
+			var inv models.Invitation
+			o.db.Where("id = ?", id).Limit(1).Find(&inv)
+			o.audit.Log(ctx.R.Context(), inv.Status, &inv)
+			return nil
+		})
+	}
+}
+
+func (o *Organizations) configureTransfers(b *presets.Builder) {
+	ma := b.Model(&models.Transfer{}).
+		MenuIcon("mdi-swap-horizontal")
+	defer o.audit.Register(ma)
+
+	lb := ma.Listing("ID", "PackageID", "From", "To", "Status", "CreatedAt")
+	o.scope(lb, models.TransferCondition, true)
+	lb.Field("PackageID").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(o.packageName(obj.(*models.Transfer).PackageID)))
+	})
+	lb.Field("From").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(o.ownerName(obj.(*models.Transfer).From())))
+	})
+	lb.Field("To").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(o.ownerName(obj.(*models.Transfer).To())))
+	})
+
+	dt := ma.Detailing("PackageID", "From", "To", "Status", "FromConfirmedBy", "ToConfirmedBy", "CompletedAt")
+	readonly := func(value func(t *models.Transfer) string) presets.FieldComponentFunc {
+		return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			return vx.VXReadonlyField().Label(field.Label).Value(value(obj.(*models.Transfer)))
+		}
+	}
+	dt.Field("PackageID").ComponentFunc(readonly(func(t *models.Transfer) string { return o.packageName(t.PackageID) }))
+	dt.Field("From").ComponentFunc(readonly(func(t *models.Transfer) string { return o.ownerName(t.From()) }))
+	dt.Field("To").ComponentFunc(readonly(func(t *models.Transfer) string { return o.ownerName(t.To()) }))
+	dt.Field("FromConfirmedBy").ComponentFunc(readonly(func(t *models.Transfer) string { return o.userName(t.FromConfirmedBy) }))
+	dt.Field("ToConfirmedBy").ComponentFunc(readonly(func(t *models.Transfer) string { return o.userName(t.ToConfirmedBy) }))
+
+	ed := ma.Editing("PackageID", "To")
+	ed.Field("PackageID").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			db := o.db.Select("id", "package").Order("package")
+			if user := umodels.CurrentUser(ctx.R); user != nil && !user.IsAdmin() {
+				db = db.Where(models.OwnedCondition, models.Owned(user.ID))
+			}
+
+			var ps []models.Package
+			db.Find(&ps)
+
+			return VAutocomplete().
+				Label(field.Label).
+				Items(ps).
+				ItemTitle("Package").
+				ItemValue("ID").
+				Attr(presets.VFieldError(field.Name, obj.(*models.Transfer).PackageID, field.Errors)...)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) error {
+			id, _ := strconv.ParseUint(ctx.R.FormValue(field.Name), 10, 64)
+			obj.(*models.Transfer).PackageID = uint(id)
+			return nil
+		})
+	ed.Field("To").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var users []umodels.User
+			o.db.Order("name").Find(&users)
+			var orgs []models.Organization
+			o.db.Order("name").Find(&orgs)
+
+			items := []DefaultOptionItem{}
+			for _, org := range orgs {
+				items = append(items, DefaultOptionItem{Text: o.ownerName(models.Owner{OrganizationID: org.ID}), Value: fmt.Sprintf("organization:%d", org.ID)})
+			}
+			for _, u := range users {
+				items = append(items, DefaultOptionItem{Text: o.ownerName(models.Owner{UserID: u.ID}), Value: fmt.Sprintf("user:%d", u.ID)})
+			}
+
+			return vx.VXSelect().Label(field.Label).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Attr(presets.VFieldError(field.Name, "", field.Errors)...)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) error {
+			t := obj.(*models.Transfer)
+			kind, value, _ := strings.Cut(ctx.R.FormValue(field.Name), ":")
+			id, _ := strconv.ParseUint(value, 10, 64)
+			switch kind {
+			case "user":
+				t.ToUserID = uint(id)
+			case "organization":
+				t.ToOrganizationID = uint(id)
+			}
+			return nil
+		})
+
+	ed.WrapSaveFunc(func(in presets.SaveFunc) presets.SaveFunc {
+		// transfers change only by confirmations
+		return func(obj interface{}, id string, ctx *web.EventContext) error {
+			if id != "" {
+				return perm.PermissionDenied
+			}
+			return saveError(o.RequestTransfer(ctx.R.Context(), umodels.CurrentUser(ctx.R), obj.(*models.Transfer)))
+		}
+	})
+
+	o.rowAction(ma, lb, "Confirm", func(ctx *web.EventContext, user *umodels.User, id uint) error {
+		if err := o.Confirm(ctx.R.Context(), user, id); err != nil {
+			return err
+		}
+		o.audit.Log(ctx.R.Context(), "Confirm", &models.Transfer{Model: gorm.Model{ID: id}})
+		return nil
+	})
+	o.rowAction(ma, lb, "Decline", func(ctx *web.EventContext, user *umodels.User, id uint) error {
+		if err := o.Decline(ctx.R.Context(), user, id); err != nil {
+			return err
+		}
+		o.audit.Log(ctx.R.Context(), "Decline", &models.Transfer{Model: gorm.Model{ID: id}})
+		return nil
+	})
+}
+
+// scope limits rows of the listing for users who are not admins, condition takes user id,
+// named conditions take it as @user
+func (o *Organizations) scope(lb *presets.ListingBuilder, condition string, named bool) {
+	lb.WrapSearchFunc(func(in presets.SearchFunc) presets.SearchFunc {
+		return func(ctx *web.EventContext, params *presets.SearchParams) (result *presets.SearchResult, err error) {
+			if user := umodels.CurrentUser(ctx.R); user != nil && !user.IsAdmin() {
+				var arg interface{} = user.ID
+				if named {
+					arg = models.Owned(user.ID)
+				}
+				params.SQLConditions = append(params.SQLConditions, &presets.SQLCondition{
+					Query: condition,
+					Args:  []interface{}{arg},
+				})
+			}
+			return in(ctx, params)
+		}
+	})
+}
+
+// rowAction runs fn for the row and reloads the listing
+func (o *Organizations) rowAction(ma *presets.ModelBuilder, lb *presets.ListingBuilder, name string, fn func(*web.EventContext, *umodels.User, uint) error) {
+	lb.RowMenu().RowMenuItem(name).
+		OnClick(func(ctx *web.EventContext, id string) (r web.EventResponse, err error) {
+			user := umodels.CurrentUser(ctx.R)
+			n, _ := strconv.ParseUint(id, 10, 64)
+			if user == nil || n == 0 {
+				presets.ShowMessage(&r, perm.PermissionDenied.Error(), ColorError)
+				return
+			}
+
+			if err := fn(ctx, user, uint(n)); err != nil {
+				presets.ShowMessage(&r, err.Error(), ColorError)
+				return r, nil
+			}
+
+			presets.ShowMessage(&r, i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Organizations", "Done"), "")
+			r.Emit(ma.NotifModelsUpdated(), presets.PayloadModelsUpdated{Ids: []string{id}})
+			return
+		})
+}
+
+// names shows organization and user names instead of ids
+func (o *Organizations) names(lb *presets.ListingBuilder, users bool) {
+	lb.Field("OrganizationID").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(o.ownerName(models.Owner{OrganizationID: field.Value(obj).(uint)})))
+	})
+	if users {
+		lb.Field("UserID").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			return Td(Text(o.userName(field.Value(obj).(uint))))
+		})
+	}
+}
+
+// organizationField selects organization, owned limits choice to organizations the user owns
+func (o *Organizations) organizationField(ed *presets.EditingBuilder, owned bool, set func(obj interface{}, id uint)) {
+	ed.Field("OrganizationID").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			db := o.db.Order("name")
+			if user := umodels.CurrentUser(ctx.R); owned && user != nil && !user.IsAdmin() {
+				db = db.Where("organizations.id IN (SELECT organization_id FROM members WHERE user_id = ? AND role = ?)", user.ID, models.MemberOwner)
+			}
+
+			var orgs []models.Organization
+			db.Find(&orgs)
+
+			items := []DefaultOptionItem{}
+			for _, org := range orgs {
+				items = append(items, DefaultOptionItem{Text: org.Name, Value: fmt.Sprint(org.ID)})
+			}
+
+			return vx.VXSelect().Label(field.Label).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Attr(presets.VFieldError(field.Name, fmt.Sprint(field.Value(obj)), field.Errors)...).
+				Disabled(field.Disabled)
+		}).
+		SetterFunc(idSetter(set))
+}
+
+// userField selects a user by name and account
+func (o *Organizations) userField(ed *presets.EditingBuilder, name string, set func(obj interface{}, id uint)) {
+	ed.Field(name).
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var users []umodels.User
+			o.db.Order("name").Find(&users)
+
+			items := []DefaultOptionItem{}
+			for _, u := range users {
+				items = append(items, DefaultOptionItem{Text: o.ownerName(models.Owner{UserID: u.ID}), Value: fmt.Sprint(u.ID)})
+			}
+
+			return vx.VXSelect().Label(field.Label).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Attr(presets.VFieldError(field.Name, fmt.Sprint(field.Value(obj)), field.Errors)...).
+				Disabled(field.Disabled)
+		}).
+		SetterFunc(idSetter(set))
+}
+
+func roleField(ed *presets.EditingBuilder) {
+	ed.Field("Role").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		value := fmt.Sprint(field.Value(obj))
+		if value == "" {
+			value = models.MemberMaintainer
+		}
+		return vx.VXSelect().Label(field.Label).
+			Items(models.MemberRoles).
+			Attr(presets.VFieldError(field.Name, value, field.Errors)...).
+			Disabled(field.Disabled)
+	})
+}
+
+// idSetter sets id chosen in select, empty value is zero
+func idSetter(set func(obj interface{}, id uint)) presets.FieldSetterFunc {
+	return func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) error {
+		id, err := strconv.ParseUint("0"+ctx.R.FormValue(field.Name), 10, 64)
+		if err != nil {
+			return err
+		}
+		set(obj, uint(id))
+		return nil
+	}
+}
+
+func (o *Organizations) userName(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	var u umodels.User
+	o.db.Where("id = ?", id).Limit(1).Find(&u)
+	return fmt.Sprintf("%s (%s)", u.Name, u.Account)
+}
+
+func (o *Organizations) packageName(id uint) string {
+	var p models.Package
+	o.db.Select("package").Where("id = ?", id).Limit(1).Find(&p)
+	return p.Package
+}
+
+// ownerName is shown in selects and listings, organizations are prefixed with @
+func (o *Organizations) ownerName(owner models.Owner) string {
+	if owner.UserID != 0 {
+		return o.userName(owner.UserID)
+	}
+
+	var org models.Organization
+	o.db.Where("id = ?", owner.OrganizationID).Limit(1).Find(&org)
+	return "@" + org.Name
+}
+
+// validation shows invalid input at its field
+func validation(err error) (errs web.ValidationErrors) {
+	var ie *InvalidError
+	switch {
+	case err == nil:
+	case errors.As(err, &ie):
+		errs.FieldError(ie.Field, ie.Message)
+	default:
+		errs.GlobalError(err.Error())
+	}
+	return
+}
+
+// saveError converts errors of the service to errors presets shows in the form
+func saveError(err error) error {
+	var ie *InvalidError
+	switch {
+	case errors.Is(err, ErrForbidden):
+		return perm.PermissionDenied
+	case errors.As(err, &ie):
+		errs := validation(err)
+		return &errs
+	}
+	return err
+}
@@ -0,0 +1,348 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// APIPrefix is the path of the api, it is served behind admin login
+const APIPrefix = "/admin/api/"
+
+type organizationJSON struct {
+	ID      uint         `json:"id"`
+	Name    string       `json:"name"`
+	Title   string       `json:"title"`
+	Members []memberJSON `json:"members"`
+}
+
+type memberJSON struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+}
+
+type invitationJSON struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Invitee        string    `json:"invitee"`
+	Role           string    `json:"role"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type transferJSON struct {
+	ID            uint         `json:"id"`
+	PackageID     uint         `json:"package_id"`
+	Package       string       `json:"package"`
+	From          models.Owner `json:"from"`
+	To            models.Owner `json:"to"`
+	FromConfirmed bool         `json:"from_confirmed"`
+	ToConfirmed   bool         `json:"to_confirmed"`
+	Status        string       `json:"status"`
+	CreatedAt     time.Time    `json:"created_at"`
+	CompletedAt   *time.Time   `json:"completed_at,omitempty"`
+}
+
+// API serves organizations, invitations and transfers as json for the current user
+func (o *Organizations) API() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+APIPrefix+"organizations", o.user(o.listOrganizations))
+	mux.HandleFunc("POST "+APIPrefix+"organizations", o.user(o.createOrganization))
+	mux.HandleFunc("POST "+APIPrefix+"organizations/{id}/invitations", o.user(o.invite))
+	mux.HandleFunc("DELETE "+APIPrefix+"organizations/{id}/members/{user}", o.user(o.removeMember))
+
+	mux.HandleFunc("GET "+APIPrefix+"invitations", o.user(o.listInvitations))
+	mux.HandleFunc("POST "+APIPrefix+"invitations/{id}/accept", o.user(o.respond(true)))
+	mux.HandleFunc("POST "+APIPrefix+"invitations/{id}/decline", o.user(o.respond(false)))
+
+	mux.HandleFunc("GET "+APIPrefix+"transfers", o.user(o.listTransfers))
+	mux.HandleFunc("POST "+APIPrefix+"transfers", o.user(o.requestTransfer))
+	mux.HandleFunc("POST "+APIPrefix+"transfers/{id}/confirm", o.user(o.confirm))
+	mux.HandleFunc("POST "+APIPrefix+"transfers/{id}/decline", o.user(o.decline))
+
+	return mux
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, user *umodels.User) error
+
+// user passes the current user to the handler and writes errors as json
+func (o *Organizations) user(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := umodels.CurrentUser(r)
+		if user == nil {
+			writeError(w, http.StatusUnauthorized, "", http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		err := h(w, r, user)
+		var ie *InvalidError
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrForbidden):
+			writeError(w, http.StatusForbidden, "", err.Error())
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, "", err.Error())
+		case errors.As(err, &ie):
+			writeError(w, http.StatusUnprocessableEntity, ie.Field, ie.Message)
+		default:
+			writeError(w, http.StatusInternalServerError, "", err.Error())
+		}
+	}
+}
+
+func (o *Organizations) listOrganizations(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	var orgs []models.Organization
+	if err := o.Visible(o.db.WithContext(r.Context()), user).Order("name").Find(&orgs).Error; err != nil {
+		return err
+	}
+
+	res := make([]organizationJSON, 0, len(orgs))
+	for _, org := range orgs {
+		j, err := o.organizationJSON(r, org)
+		if err != nil {
+			return err
+		}
+		res = append(res, j)
+	}
+
+	return writeJSON(w, http.StatusOK, res)
+}
+
+func (o *Organizations) createOrganization(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	var req struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	}
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+
+	org := models.Organization{Name: req.Name, Title: req.Title}
+	if err := o.Create(r.Context(), user, &org); err != nil {
+		return err
+	}
+	o.audit.Log(r.Context(), "Create", &org)
+
+	j, err := o.organizationJSON(r, org)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, j)
+}
+
+func (o *Organizations) invite(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Invitee string `json:"invitee"`
+		Role    string `json:"role"`
+	}
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+
+	inv := models.Invitation{OrganizationID: id, Invitee: req.Invitee, Role: req.Role}
+	if err := o.Invite(r.Context(), user, &inv); err != nil {
+		return err
+	}
+	o.audit.Log(r.Context(), "Create", &inv)
+
+	return writeJSON(w, http.StatusCreated, invitationToJSON(inv))
+}
+
+func (o *Organizations) removeMember(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := pathID(r, "user")
+	if err != nil {
+		return err
+	}
+
+	if err := o.RemoveMember(r.Context(), user, id, userID); err != nil {
+		return err
+	}
+	o.audit.Log(r.Context(), "Remove member", &models.Organization{Model: gorm.Model{ID: id}})
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (o *Organizations) listInvitations(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	invs, err := o.Invitations(r.Context(), user)
+	if err != nil {
+		return err
+	}
+
+	res := make([]invitationJSON, 0, len(invs))
+	for _, inv := range invs {
+		res = append(res, invitationToJSON(inv))
+	}
+
+	return writeJSON(w, http.StatusOK, res)
+}
+
+func (o *Organizations) respond(accept bool) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		if err := o.Respond(r.Context(), user, id, accept); err != nil {
+			return err
+		}
+
+		var inv models.Invitation
+		if err := o.db.WithContext(r.Context()).Where("id = ?", id).Limit(1).Find(&inv).Error; err != nil {
+			return err
+		}
+		o.audit.Log(r.Context(), inv.Status, &inv)
+
+		return writeJSON(w, http.StatusOK, invitationToJSON(inv))
+	}
+}
+
+func (o *Organizations) listTransfers(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	db := o.db.WithContext(r.Context()).Order("created_at DESC")
+	if !user.IsAdmin() {
+		db = db.Where(models.TransferCondition, models.Owned(user.ID))
+	}
+
+	var ts []models.Transfer
+	if err := db.Find(&ts).Error; err != nil {
+		return err
+	}
+
+	res := make([]transferJSON, 0, len(ts))
+	for _, t := range ts {
+		res = append(res, o.transferJSON(t))
+	}
+
+	return writeJSON(w, http.StatusOK, res)
+}
+
+func (o *Organizations) requestTransfer(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	var req struct {
+		PackageID uint         `json:"package_id"`
+		To        models.Owner `json:"to"`
+	}
+	if err := decode(r, &req); err != nil {
+		return err
+	}
+
+	t := models.Transfer{PackageID: req.PackageID, ToUserID: req.To.UserID, ToOrganizationID: req.To.OrganizationID}
+	if err := o.RequestTransfer(r.Context(), user, &t); err != nil {
+		return err
+	}
+	o.audit.Log(r.Context(), "Create", &t)
+
+	return writeJSON(w, http.StatusCreated, o.transferJSON(t))
+}
+
+func (o *Organizations) confirm(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	return o.transferAction(w, r, user, "Confirm", o.Confirm)
+}
+
+func (o *Organizations) decline(w http.ResponseWriter, r *http.Request, user *umodels.User) error {
+	return o.transferAction(w, r, user, "Decline", o.Decline)
+}
+
+func (o *Organizations) transferAction(w http.ResponseWriter, r *http.Request, user *umodels.User, action string, fn func(ctx context.Context, user *umodels.User, id uint) error) error {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := fn(r.Context(), user, id); err != nil {
+		return err
+	}
+
+	var t models.Transfer
+	if err := o.db.WithContext(r.Context()).Where("id = ?", id).Limit(1).Find(&t).Error; err != nil {
+		return err
+	}
+	o.audit.Log(r.Context(), action, &t)
+
+	return writeJSON(w, http.StatusOK, o.transferJSON(t))
+}
+
+func (o *Organizations) organizationJSON(r *http.Request, org models.Organization) (organizationJSON, error) {
+	var members []models.Member
+	if err := o.db.WithContext(r.Context()).Where("organization_id = ?", org.ID).Order("id").Find(&members).Error; err != nil {
+		return organizationJSON{}, err
+	}
+
+	j := organizationJSON{ID: org.ID, Name: org.Name, Title: org.Title, Members: make([]memberJSON, 0, len(members))}
+	for _, m := range members {
+		j.Members = append(j.Members, memberJSON{UserID: m.UserID, Name: o.userName(m.UserID), Role: m.Role})
+	}
+	return j, nil
+}
+
+func (o *Organizations) transferJSON(t models.Transfer) transferJSON {
+	return transferJSON{
+		ID:            t.ID,
+		PackageID:     t.PackageID,
+		Package:       o.packageName(t.PackageID),
+		From:          t.From(),
+		To:            t.To(),
+		FromConfirmed: t.FromConfirmedBy != 0,
+		ToConfirmed:   t.ToConfirmedBy != 0,
+		Status:        t.Status,
+		CreatedAt:     t.CreatedAt,
+		CompletedAt:   t.CompletedAt,
+	}
+}
+
+func invitationToJSON(inv models.Invitation) invitationJSON {
+	return invitationJSON{
+		ID:             inv.ID,
+		OrganizationID: inv.OrganizationID,
+		Invitee:        inv.Invitee,
+		Role:           inv.Role,
+		Status:         inv.Status,
+		CreatedAt:      inv.CreatedAt,
+	}
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return invalid("", "Invalid json: %s", err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	return uint(id), nil
+}
+
+// writeJSON never fails, the status is already sent when encoding fails
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+	return nil
+}
+
+func writeError(w http.ResponseWriter, status int, field, message string) {
+	writeJSON(w, status, map[string]string{"error": message, "field": field})
+}
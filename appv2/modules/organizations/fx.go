@@ -0,0 +1,10 @@
+package organizations
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"organizations",
+	fx.Provide(
+		New,
+	),
+)
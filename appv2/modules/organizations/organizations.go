@@ -0,0 +1,491 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/audit"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+var (
+	ErrForbidden = errors.New("not allowed")
+	ErrNotFound  = errors.New("not found")
+)
+
+// InvalidError is wrong input, Field is the field of the form it belongs to
+type InvalidError struct {
+	Field   string
+	Message string
+}
+
+func (e *InvalidError) Error() string {
+	return e.Message
+}
+
+func invalid(field, format string, a ...interface{}) error {
+	return &InvalidError{Field: field, Message: fmt.Sprintf(format, a...)}
+}
+
+// name follows namespace syntax, organizations usually own the namespace of the same name
+var name = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Organizations manages organizations, their members and package transfers,
+// the same rules apply in admin and in the api
+type Organizations struct {
+	db    *gorm.DB
+	audit *audit.Audit
+}
+
+func New(db *gorm.DB, audit *audit.Audit) *Organizations {
+	return &Organizations{
+		db:    db,
+		audit: audit,
+	}
+}
+
+// Visible limits organizations to those the user is a member of, admins see all
+func (o *Organizations) Visible(db *gorm.DB, user *umodels.User) *gorm.DB {
+	if user.IsAdmin() {
+		return db
+	}
+	return db.Where("organizations.id IN (SELECT organization_id FROM members WHERE user_id = ?)", user.ID)
+}
+
+// validate checks organization fields before save
+func (o *Organizations) validate(org *models.Organization) error {
+	if !name.MatchString(org.Name) {
+		return invalid("Name", "Name must contain only lowercase letters, digits, dots, dashes and underscores")
+	}
+
+	var count int64
+	err := o.db.Model(&models.Organization{}).Where("name = ? AND id <> ?", org.Name, org.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return invalid("Name", "Organization %s already exists", org.Name)
+	}
+
+	return nil
+}
+
+// Create adds organization, the user becomes its owner
+func (o *Organizations) Create(ctx context.Context, user *umodels.User, org *models.Organization) error {
+	if !user.IsAdmin() && !user.HasRole(umodels.RoleMaintainer) {
+		return ErrForbidden
+	}
+	if err := o.validate(org); err != nil {
+		return err
+	}
+
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Member{OrganizationID: org.ID, UserID: user.ID, Role: models.MemberOwner}).Error
+	})
+}
+
+// owner checks that user is admin or owner of the organization
+func (o *Organizations) owner(ctx context.Context, user *umodels.User, orgID uint) error {
+	var count int64
+	if err := o.db.WithContext(ctx).Model(&models.Organization{}).Where("id = ?", orgID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+
+	if user.IsAdmin() {
+		return nil
+	}
+
+	ok, err := models.Manages(o.db.WithContext(ctx), user.ID, models.Owner{OrganizationID: orgID}, models.MemberOwner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// Invite asks user with account or email to join the organization, only owners invite
+func (o *Organizations) Invite(ctx context.Context, user *umodels.User, inv *models.Invitation) error {
+	if err := o.owner(ctx, user, inv.OrganizationID); err != nil {
+		return err
+	}
+
+	if inv.Invitee == "" {
+		return invalid("Invitee", "Email or account is required")
+	}
+	if inv.Role == "" {
+		inv.Role = models.MemberMaintainer
+	}
+	if !slices.Contains(models.MemberRoles, inv.Role) {
+		return invalid("Role", "Unknown role %s", inv.Role)
+	}
+
+	db := o.db.WithContext(ctx)
+
+	var count int64
+	err := db.Model(&models.Member{}).
+		Joins("JOIN users ON users.id = members.user_id AND users.deleted_at IS NULL").
+		Where("members.organization_id = ?", inv.OrganizationID).
+		Where("lower(users.account) = lower(?) OR lower(users.email) = lower(?)", inv.Invitee, inv.Invitee).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return invalid("Invitee", "%s is already a member", inv.Invitee)
+	}
+
+	err = db.Model(&models.Invitation{}).
+		Where("organization_id = ? AND lower(invitee) = lower(?) AND status = ?", inv.OrganizationID, inv.Invitee, models.StatusPending).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return invalid("Invitee", "%s is already invited", inv.Invitee)
+	}
+
+	inv.InvitedBy = user.ID
+	inv.Status = models.StatusPending
+	return db.Create(inv).Error
+}
+
+// Invitations are pending invitations addressed to the user
+func (o *Organizations) Invitations(ctx context.Context, user *umodels.User) ([]models.Invitation, error) {
+	var invs []models.Invitation
+	err := o.db.WithContext(ctx).
+		Where(models.InviteeCondition, user.Account, user.Email).
+		Where("status = ?", models.StatusPending).
+		Order("created_at DESC").
+		Find(&invs).Error
+	return invs, err
+}
+
+// Respond accepts or declines invitation addressed to the user
+func (o *Organizations) Respond(ctx context.Context, user *umodels.User, id uint, accept bool) error {
+	db := o.db.WithContext(ctx)
+
+	var inv models.Invitation
+	if err := db.Where("id = ? AND status = ?", id, models.StatusPending).Limit(1).Find(&inv).Error; err != nil {
+		return err
+	}
+	if inv.ID == 0 {
+		return ErrNotFound
+	}
+
+	var count int64
+	if err := db.Model(&models.Invitation{}).Where("id = ?", id).Where(models.InviteeCondition, user.Account, user.Email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrForbidden
+	}
+
+	inv.Status = models.StatusDeclined
+	if accept {
+		inv.Status = models.StatusAccepted
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if accept {
+			// membership accepted earlier through another invitation is kept
+			m := models.Member{OrganizationID: inv.OrganizationID, UserID: user.ID, Role: inv.Role}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&inv).Update("status", inv.Status).Error
+	})
+}
+
+// RemoveMember removes user from the organization: owners remove anybody, members leave by themselves.
+// The last owner can not be removed, organization would be unmanageable
+func (o *Organizations) RemoveMember(ctx context.Context, user *umodels.User, orgID, userID uint) error {
+	if user.ID != userID {
+		if err := o.owner(ctx, user, orgID); err != nil {
+			return err
+		}
+	}
+
+	db := o.db.WithContext(ctx)
+
+	var m models.Member
+	if err := db.Where("organization_id = ? AND user_id = ?", orgID, userID).Limit(1).Find(&m).Error; err != nil {
+		return err
+	}
+	if m.ID == 0 {
+		return ErrNotFound
+	}
+
+	if err := o.keepOwner(db, m, ""); err != nil {
+		return err
+	}
+
+	return db.Delete(&m).Error
+}
+
+// keepOwner fails when the member is the last owner and loses the role
+func (o *Organizations) keepOwner(db *gorm.DB, m models.Member, role string) error {
+	if m.Role != models.MemberOwner || role == models.MemberOwner {
+		return nil
+	}
+
+	var count int64
+	err := db.Model(&models.Member{}).
+		Where("organization_id = ? AND role = ? AND id <> ?", m.OrganizationID, models.MemberOwner, m.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return invalid("Role", "Organization must have an owner")
+	}
+
+	return nil
+}
+
+// RequestTransfer starts transfer of the package to another user or organization.
+// Sides the user acts for are confirmed at once, the transfer completes when both sides confirmed it
+func (o *Organizations) RequestTransfer(ctx context.Context, user *umodels.User, t *models.Transfer) error {
+	db := o.db.WithContext(ctx)
+
+	var p models.Package
+	if err := db.Where("id = ?", t.PackageID).Limit(1).Find(&p).Error; err != nil {
+		return err
+	}
+	if p.ID == 0 {
+		return invalid("PackageID", "Package is required")
+	}
+
+	from, err := models.PackageOwner(db, p)
+	if err != nil {
+		return err
+	}
+	if from.IsZero() {
+		return invalid("PackageID", "Namespace %s has no owner, assign it instead of transfer", p.Namespace())
+	}
+
+	to := t.To()
+	if err := o.exists(db, to); err != nil {
+		return err
+	}
+	if to == from {
+		return invalid("To", "Package is already owned by the recipient")
+	}
+
+	pending, err := models.Pending(db, p.ID)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return invalid("PackageID", "Package %s already has a pending transfer", p.Package)
+	}
+
+	t.FromUserID, t.FromOrganizationID = from.UserID, from.OrganizationID
+	t.Status = models.StatusPending
+	t.CreatedBy = user.ID
+
+	if err := o.sides(db, user, t); err != nil {
+		return err
+	}
+	if t.FromConfirmedBy == 0 && !user.IsAdmin() {
+		return ErrForbidden
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		return o.complete(tx, t)
+	})
+}
+
+// Confirm confirms the transfer for sides the user acts for. The transfer is locked,
+// so concurrent confirmations and declines are applied one after another
+func (o *Organizations) Confirm(ctx context.Context, user *umodels.User, id uint) error {
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		t, err := pending(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// the user must confirm at least one side not confirmed yet
+		before := t
+		if err := o.sides(tx, user, &t); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if t.FromConfirmedBy != before.FromConfirmedBy {
+			updates["from_confirmed_by"] = t.FromConfirmedBy
+		}
+		if t.ToConfirmedBy != before.ToConfirmedBy {
+			updates["to_confirmed_by"] = t.ToConfirmedBy
+		}
+		if len(updates) == 0 {
+			return ErrForbidden
+		}
+
+		if err := transition(tx, t.ID, updates); err != nil {
+			return err
+		}
+		return o.complete(tx, &t)
+	})
+}
+
+// Decline cancels the transfer, either side or admin may do it
+func (o *Organizations) Decline(ctx context.Context, user *umodels.User, id uint) error {
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		t, err := pending(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !user.IsAdmin() && !o.acts(ctx, user, t) {
+			return ErrForbidden
+		}
+
+		return transition(tx, t.ID, map[string]interface{}{"status": models.StatusDeclined})
+	})
+}
+
+// pending loads the transfer waiting for confirmation and locks it until the transaction ends
+func pending(tx *gorm.DB, id uint) (models.Transfer, error) {
+	var t models.Transfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Limit(1).Find(&t).Error; err != nil {
+		return t, err
+	}
+	if t.ID == 0 {
+		return t, ErrNotFound
+	}
+	if t.Status != models.StatusPending {
+		return t, invalid("Status", "Transfer is already %s", t.Status)
+	}
+	return t, nil
+}
+
+// transition updates the transfer only while it is pending
+func transition(tx *gorm.DB, id uint, updates map[string]interface{}) error {
+	res := tx.Model(&models.Transfer{}).Where("id = ? AND status = ?", id, models.StatusPending).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return invalid("Status", "Transfer is not pending anymore")
+	}
+	return nil
+}
+
+// acts checks that user acts for any side of the transfer
+func (o *Organizations) acts(ctx context.Context, user *umodels.User, t models.Transfer) bool {
+	db := o.db.WithContext(ctx)
+	for _, side := range []models.Owner{t.From(), t.To()} {
+		if ok, err := models.Manages(db, user.ID, side, models.MemberOwner); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sides marks sides of the transfer the user acts for as confirmed by the user,
+// organizations are represented by their owners
+func (o *Organizations) sides(db *gorm.DB, user *umodels.User, t *models.Transfer) error {
+	if t.FromConfirmedBy == 0 {
+		ok, err := models.Manages(db, user.ID, t.From(), models.MemberOwner)
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.FromConfirmedBy = user.ID
+		}
+	}
+
+	if t.ToConfirmedBy == 0 {
+		ok, err := models.Manages(db, user.ID, t.To(), models.MemberOwner)
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.ToConfirmedBy = user.ID
+		}
+	}
+
+	return nil
+}
+
+// complete moves the package to the recipient when both sides confirmed the transfer,
+// the transfer is declined if the package changed owner meanwhile
+func (o *Organizations) complete(tx *gorm.DB, t *models.Transfer) error {
+	if t.FromConfirmedBy == 0 || t.ToConfirmedBy == 0 {
+		return nil
+	}
+
+	var p models.Package
+	if err := tx.Where("id = ?", t.PackageID).Limit(1).Find(&p).Error; err != nil {
+		return err
+	}
+
+	from, err := models.PackageOwner(tx, p)
+	if err != nil {
+		return err
+	}
+
+	if p.ID == 0 || from != t.From() {
+		t.Status = models.StatusDeclined
+		return transition(tx, t.ID, map[string]interface{}{"status": t.Status})
+	}
+
+	err = tx.Model(&models.Package{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+		"user_id":         t.ToUserID,
+		"organization_id": t.ToOrganizationID,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	t.Status, t.CompletedAt = models.StatusAccepted, &now
+	return transition(tx, t.ID, map[string]interface{}{
+		"status":       t.Status,
+		"completed_at": now,
+	})
+}
+
+// exists checks that recipient of the transfer is one existing user or organization
+func (o *Organizations) exists(db *gorm.DB, to models.Owner) error {
+	if (to.UserID == 0) == (to.OrganizationID == 0) {
+		return invalid("To", "Choose a user or an organization")
+	}
+
+	var count int64
+	var err error
+	if to.UserID != 0 {
+		err = db.Model(&umodels.User{}).Where("id = ?", to.UserID).Count(&count).Error
+	} else {
+		err = db.Model(&models.Organization{}).Where("id = ?", to.OrganizationID).Count(&count).Error
+	}
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return invalid("To", "Recipient not found")
+	}
+
+	return nil
+}
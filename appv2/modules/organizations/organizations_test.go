@@ -0,0 +1,464 @@
+package organizations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/qor5/admin/v3/role"
+	"github.com/qor5/x/v3/login"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+// fixture: alice owns namespace alice and organization team, bob maintains team,
+// carol is not related to anything, admin is dave
+type fixture struct {
+	o                        *Organizations
+	db                       *gorm.DB
+	alice, bob, carol, admin *umodels.User
+	team                     models.Organization
+	pkg                      models.Package
+}
+
+func setup(t *testing.T) *fixture {
+	db := dbtest.Open(t, append([]any{&role.Role{}, &umodels.User{}}, models.Tables...)...)
+	f := &fixture{o: New(db, nil), db: db}
+
+	user := func(account string, roles ...string) *umodels.User {
+		u := &umodels.User{Name: account, Email: account + "@example.com", UserPass: login.UserPass{Account: account}}
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range roles {
+			u.Roles = append(u.Roles, role.Role{Name: r})
+		}
+		return u
+	}
+	f.alice = user("alice", umodels.RoleMaintainer)
+	f.bob = user("bob", umodels.RoleMaintainer)
+	f.carol = user("carol", umodels.RoleMaintainer)
+	f.admin = user("dave", umodels.RoleAdmin)
+
+	f.team = models.Organization{Name: "team"}
+	if err := f.o.Create(context.Background(), f.alice, &f.team); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Member{OrganizationID: f.team.ID, UserID: f.bob.ID, Role: models.MemberMaintainer}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Create(&models.Namespace{Name: "alice", UserID: f.alice.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.pkg = models.Package{Package: models.Domain + "/alice/lib", Active: true}
+	if err := db.Create(&f.pkg).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+// field of InvalidError, empty for other errors
+func field(err error) string {
+	var ie *InvalidError
+	if errors.As(err, &ie) {
+		return ie.Field
+	}
+	return ""
+}
+
+func TestInvite(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		user  *umodels.User
+		inv   models.Invitation
+		err   error
+		field string
+	}{
+		{"maintainer can not invite", f.bob, models.Invitation{OrganizationID: f.team.ID, Invitee: "carol"}, ErrForbidden, ""},
+		{"unknown organization", f.alice, models.Invitation{OrganizationID: f.team.ID + 100, Invitee: "carol"}, ErrNotFound, ""},
+		{"empty invitee", f.alice, models.Invitation{OrganizationID: f.team.ID}, nil, "Invitee"},
+		{"unknown role", f.alice, models.Invitation{OrganizationID: f.team.ID, Invitee: "carol", Role: "guest"}, nil, "Role"},
+		{"member by account", f.alice, models.Invitation{OrganizationID: f.team.ID, Invitee: "BOB"}, nil, "Invitee"},
+		{"member by email", f.alice, models.Invitation{OrganizationID: f.team.ID, Invitee: "bob@example.com"}, nil, "Invitee"},
+		{"owner invites", f.alice, models.Invitation{OrganizationID: f.team.ID, Invitee: "carol@example.com"}, nil, ""},
+		{"already invited", f.alice, models.Invitation{OrganizationID: f.team.ID, Invitee: "Carol@Example.com"}, nil, "Invitee"},
+		{"admin invites", f.admin, models.Invitation{OrganizationID: f.team.ID, Invitee: "eve", Role: models.MemberOwner}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		err := f.o.Invite(ctx, tt.user, &tt.inv)
+		switch {
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		case tt.field != "" && field(err) != tt.field:
+			t.Errorf("%s: got %v, want error of %s", tt.name, err, tt.field)
+		case tt.err == nil && tt.field == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case err == nil && (tt.inv.ID == 0 || tt.inv.Status != models.StatusPending || tt.inv.InvitedBy != tt.user.ID || tt.inv.Role == ""):
+			t.Errorf("%s: invitation is not stored %+v", tt.name, tt.inv)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	invite := func(invitee, role string) uint {
+		inv := models.Invitation{OrganizationID: f.team.ID, Invitee: invitee, Role: role}
+		if err := f.o.Invite(ctx, f.alice, &inv); err != nil {
+			t.Fatal(err)
+		}
+		return inv.ID
+	}
+	accepted := invite("carol", models.MemberOwner)
+	declined := invite("eve@example.com", "")
+
+	tests := []struct {
+		name   string
+		user   *umodels.User
+		id     uint
+		accept bool
+		err    error
+	}{
+		{"invitation of other user", f.bob, accepted, true, ErrForbidden},
+		{"unknown invitation", f.carol, accepted + 100, true, ErrNotFound},
+		{"invitee accepts", f.carol, accepted, true, nil},
+		{"accepted twice", f.carol, accepted, true, ErrNotFound},
+		{"invitee declines", &umodels.User{Model: gorm.Model{ID: 100}, Email: "EVE@example.com"}, declined, false, nil},
+		{"accepted after decline", &umodels.User{Model: gorm.Model{ID: 100}, Email: "eve@example.com"}, declined, true, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		if err := f.o.Respond(ctx, tt.user, tt.id, tt.accept); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	var member models.Member
+	f.db.Where("organization_id = ? AND user_id = ?", f.team.ID, f.carol.ID).Find(&member)
+	if member.Role != models.MemberOwner {
+		t.Errorf("accepted invitation gives role %q", member.Role)
+	}
+
+	var count int64
+	f.db.Model(&models.Member{}).Where("user_id = ?", 100).Count(&count)
+	if count != 0 {
+		t.Error("declined invitation adds member")
+	}
+
+	var inv models.Invitation
+	f.db.First(&inv, declined)
+	if inv.Status != models.StatusDeclined {
+		t.Errorf("declined invitation is %s", inv.Status)
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		user  *umodels.User
+		who   *umodels.User
+		err   error
+		field string
+	}{
+		{"maintainer removes owner", f.bob, f.alice, ErrForbidden, ""},
+		{"not a member", f.alice, f.carol, ErrNotFound, ""},
+		{"last owner leaves", f.alice, f.alice, nil, "Role"},
+		{"owner removes maintainer", f.alice, f.bob, nil, ""},
+	}
+
+	for _, tt := range tests {
+		err := f.o.RemoveMember(ctx, tt.user, f.team.ID, tt.who.ID)
+		switch {
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		case tt.field != "" && field(err) != tt.field:
+			t.Errorf("%s: got %v, want error of %s", tt.name, err, tt.field)
+		case tt.err == nil && tt.field == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+
+	// the owner leaves when another owner stays
+	if err := f.db.Create(&models.Member{OrganizationID: f.team.ID, UserID: f.carol.ID, Role: models.MemberOwner}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.o.RemoveMember(ctx, f.alice, f.team.ID, f.alice.ID); err != nil {
+		t.Errorf("owner leaves: %v", err)
+	}
+
+	var members []models.Member
+	f.db.Where("organization_id = ?", f.team.ID).Find(&members)
+	if len(members) != 1 || members[0].UserID != f.carol.ID {
+		t.Errorf("got members %+v", members)
+	}
+}
+
+func TestKeepOwner(t *testing.T) {
+	// members keeping or not having the owner role are not checked in database
+	o := New(nil, nil)
+	for _, tt := range []struct {
+		role, next string
+	}{
+		{models.MemberMaintainer, ""},
+		{models.MemberMaintainer, models.MemberOwner},
+		{models.MemberOwner, models.MemberOwner},
+	} {
+		if err := o.keepOwner(nil, models.Member{Role: tt.role}, tt.next); err != nil {
+			t.Errorf("%s -> %q: %v", tt.role, tt.next, err)
+		}
+	}
+
+	f := setup(t)
+	var owner models.Member
+	f.db.Where("organization_id = ? AND user_id = ?", f.team.ID, f.alice.ID).Find(&owner)
+
+	if err := f.o.keepOwner(f.db, owner, models.MemberMaintainer); field(err) != "Role" {
+		t.Errorf("last owner becomes maintainer: %v", err)
+	}
+	if err := f.db.Model(&models.Member{}).Where("user_id = ?", f.bob.ID).Update("role", models.MemberOwner).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := f.o.keepOwner(f.db, owner, models.MemberMaintainer); err != nil {
+		t.Errorf("one of owners becomes maintainer: %v", err)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+
+	type step struct {
+		name   string
+		user   func(*fixture) *umodels.User
+		action string // confirm, decline or move, moving changes owner of the package directly
+		err    error
+		field  string
+	}
+	alice := func(f *fixture) *umodels.User { return f.alice }
+	bob := func(f *fixture) *umodels.User { return f.bob }
+	carol := func(f *fixture) *umodels.User { return f.carol }
+
+	tests := []struct {
+		name   string
+		to     func(*fixture) models.Owner
+		steps  []step
+		status string
+		owner  func(*fixture) models.Owner
+	}{
+		{
+			name: "recipient confirms",
+			to:   func(f *fixture) models.Owner { return models.Owner{UserID: f.bob.ID} },
+			steps: []step{
+				{name: "sender confirms again", user: alice, action: "confirm", err: ErrForbidden},
+				{name: "stranger confirms", user: carol, action: "confirm", err: ErrForbidden},
+				{name: "recipient confirms", user: bob, action: "confirm"},
+				{name: "confirmed twice", user: bob, action: "confirm", field: "Status"},
+			},
+			status: models.StatusAccepted,
+			owner:  func(f *fixture) models.Owner { return models.Owner{UserID: f.bob.ID} },
+		},
+		{
+			name:   "both sides at once",
+			to:     func(f *fixture) models.Owner { return models.Owner{OrganizationID: f.team.ID} },
+			status: models.StatusAccepted,
+			owner:  func(f *fixture) models.Owner { return models.Owner{OrganizationID: f.team.ID} },
+		},
+		{
+			name: "owner changed in between",
+			to:   func(f *fixture) models.Owner { return models.Owner{UserID: f.bob.ID} },
+			steps: []step{
+				{name: "package moved", action: "move"},
+				{name: "recipient confirms", user: bob, action: "confirm"},
+			},
+			status: models.StatusDeclined,
+			owner:  func(f *fixture) models.Owner { return models.Owner{UserID: f.carol.ID} },
+		},
+		{
+			name: "declined",
+			to:   func(f *fixture) models.Owner { return models.Owner{UserID: f.bob.ID} },
+			steps: []step{
+				{name: "stranger declines", user: carol, action: "decline", err: ErrForbidden},
+				{name: "recipient declines", user: bob, action: "decline"},
+				{name: "confirmed after decline", user: bob, action: "confirm", field: "Status"},
+				{name: "declined twice", user: alice, action: "decline", field: "Status"},
+			},
+			status: models.StatusDeclined,
+			owner:  func(f *fixture) models.Owner { return models.Owner{} },
+		},
+	}
+
+	for _, tt := range tests {
+		f := setup(t)
+
+		tr := models.Transfer{PackageID: f.pkg.ID}
+		to := tt.to(f)
+		tr.ToUserID, tr.ToOrganizationID = to.UserID, to.OrganizationID
+		if err := f.o.RequestTransfer(ctx, f.alice, &tr); err != nil {
+			t.Fatalf("%s: request: %v", tt.name, err)
+		}
+		if tr.FromConfirmedBy != f.alice.ID {
+			t.Errorf("%s: sender side is not confirmed %+v", tt.name, tr)
+		}
+
+		// the package has one pending transfer at a time
+		if tr.Status == models.StatusPending {
+			again := models.Transfer{PackageID: f.pkg.ID, ToUserID: f.carol.ID}
+			if err := f.o.RequestTransfer(ctx, f.alice, &again); field(err) != "PackageID" {
+				t.Errorf("%s: second pending transfer: %v", tt.name, err)
+			}
+		}
+
+		for _, s := range tt.steps {
+			var err error
+			switch s.action {
+			case "confirm":
+				err = f.o.Confirm(ctx, s.user(f), tr.ID)
+			case "decline":
+				err = f.o.Decline(ctx, s.user(f), tr.ID)
+			case "move":
+				err = f.db.Model(&models.Package{}).Where("id = ?", f.pkg.ID).Update("user_id", f.carol.ID).Error
+			}
+
+			switch {
+			case s.err != nil && !errors.Is(err, s.err):
+				t.Errorf("%s: %s: got %v, want %v", tt.name, s.name, err, s.err)
+			case s.field != "" && field(err) != s.field:
+				t.Errorf("%s: %s: got %v, want error of %s", tt.name, s.name, err, s.field)
+			case s.err == nil && s.field == "" && err != nil:
+				t.Errorf("%s: %s: %v", tt.name, s.name, err)
+			}
+		}
+
+		var stored models.Transfer
+		f.db.First(&stored, tr.ID)
+		if stored.Status != tt.status || (stored.Status == models.StatusAccepted) != (stored.CompletedAt != nil) {
+			t.Errorf("%s: transfer is %s, completed at %v, want %s", tt.name, stored.Status, stored.CompletedAt, tt.status)
+		}
+
+		var p models.Package
+		f.db.First(&p, f.pkg.ID)
+		if owner := (models.Owner{UserID: p.UserID, OrganizationID: p.OrganizationID}); owner != tt.owner(f) {
+			t.Errorf("%s: package is owned by %+v, want %+v", tt.name, owner, tt.owner(f))
+		}
+	}
+}
+
+func TestTransferConditions(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	// owns_package and owns_organization conditions of permissions follow the transfer
+	owns := func(u *umodels.User) bool {
+		ok, err := models.OwnsPackage(f.db, u.ID, f.pkg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	manages := func(u *umodels.User) bool {
+		ok, err := models.Manages(f.db, u.ID, models.Owner{OrganizationID: f.team.ID}, models.MemberOwner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	if !owns(f.alice) || owns(f.bob) || owns(f.carol) {
+		t.Error("package of namespace is owned by others")
+	}
+
+	tr := models.Transfer{PackageID: f.pkg.ID, ToUserID: f.carol.ID}
+	if err := f.o.RequestTransfer(ctx, f.alice, &tr); err != nil {
+		t.Fatal(err)
+	}
+	if owns(f.carol) {
+		t.Error("pending transfer gives the package")
+	}
+	if err := f.o.Confirm(ctx, f.carol, tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if owns(f.alice) || !owns(f.carol) {
+		t.Error("transferred package is owned by namespace owner")
+	}
+
+	// carol gives the package to the organization, its members own it
+	tr = models.Transfer{PackageID: f.pkg.ID, ToOrganizationID: f.team.ID}
+	if err := f.o.RequestTransfer(ctx, f.carol, &tr); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.o.Confirm(ctx, f.alice, tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !owns(f.alice) || !owns(f.bob) || owns(f.carol) {
+		t.Error("package of the organization is not owned by its members")
+	}
+	if !manages(f.alice) || manages(f.bob) || manages(f.carol) {
+		t.Error("organization is managed by not owners")
+	}
+}
+
+// pool records queries and fails them, only the sql is checked
+type pool struct {
+	queries []string
+}
+
+var errQuery = errors.New("connection refused")
+
+func (p *pool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errQuery
+}
+
+func (p *pool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.queries = append(p.queries, query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.queries = append(p.queries, query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestTransitionSQL(t *testing.T) {
+	p := &pool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: p}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending(db, 1)
+	transition(db, 1, map[string]interface{}{"status": models.StatusDeclined})
+
+	if len(p.queries) != 2 {
+		t.Fatalf("got queries %q", p.queries)
+	}
+	if !strings.HasSuffix(p.queries[0], "FOR UPDATE") {
+		t.Errorf("transfer is not locked: %s", p.queries[0])
+	}
+	if !strings.Contains(p.queries[1], "status = $") {
+		t.Errorf("transition does not check status: %s", p.queries[1])
+	}
+}
@@ -0,0 +1,200 @@
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// RefreshParams are packages selected in the listing
+type RefreshParams struct {
+	Packages []uint
+}
+
+// queryIDs passes selected packages from listing to the job dialog
+const queryIDs = "ids"
+
+// configureActions adds refresh jobs to row menu, bulk actions and listing buttons
+func (m *Packages) configureActions(mb *presets.ModelBuilder, lb *presets.ListingBuilder) {
+	wb := m.jobs.Worker()
+
+	m.packagesJob(wb.ActionJob("Refresh", mb, m.selected(m.Refresh)).
+		Description("Collect versions from repository tags, readme and exported symbols"))
+
+	m.packagesJob(wb.ActionJob("Rebuild docs", mb, m.selected(m.RebuildDocs)).
+		Description("Collect readme and exported symbols from the default branch"))
+
+	all := wb.ActionJob("Refresh all", mb, m.refreshAll).
+		Description("Refresh every active git package").
+		DisplayLog(true)
+
+	// dialogs of selected packages are opened by job name with ids in query
+	for _, name := range []string{"Refresh", "Rebuild docs"} {
+		lb.RowMenu().RowMenuItem(name).
+			PermAction(presets.PermUpdate).
+			OnClick(func(ctx *web.EventContext, id string) (r web.EventResponse, err error) {
+				r.RunScript = m.jobs.URL(mb, name, queryIDs, id)
+				return
+			})
+
+		lb.BulkAction(name).
+			ComponentFunc(func(selectedIds []string, ctx *web.EventContext) HTMLComponent {
+				return Text(fmt.Sprintf("%s: %d", i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Selected"), len(selectedIds)))
+			}).
+			UpdateFunc(func(selectedIds []string, ctx *web.EventContext, r *web.EventResponse) error {
+				web.AppendRunScripts(r, m.jobs.URL(mb, name, queryIDs, strings.Join(selectedIds, ",")))
+				return nil
+			})
+	}
+
+	lb.Action("Refresh all").ButtonCompFunc(func(ctx *web.EventContext) HTMLComponent {
+		if mb.Info().Verifier().Do(presets.PermUpdate).WithReq(ctx.R).IsAllowed() != nil {
+			return Components()
+		}
+		return VBtn(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Refresh all")).
+			Variant(VariantTonal).Class("ml-2").
+			Attr("@click", all.URL())
+	})
+}
+
+// packagesJob adds the packages field prefilled with packages selected in listing
+func (m *Packages) packagesJob(action *worker.ActionJobBuilder) *worker.ActionJobBuilder {
+	action.Params(&RefreshParams{}).DisplayLog(true)
+
+	action.GetParamsModelBuilder().Editing().Field("Packages").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var ps []models.Package
+			if ids := strings.Split(ctx.R.FormValue(queryIDs), ","); ids[0] != "" {
+				m.editable(m.db, umodels.CurrentUser(ctx.R)).Where("id IN ?", ids).Find(&ps)
+			}
+
+			ids := make([]uint, 0, len(ps))
+			for _, p := range ps {
+				ids = append(ids, p.ID)
+			}
+
+			return VAutocomplete().
+				Label(field.Label).
+				Items(ps).
+				ItemTitle("Package").
+				ItemValue("ID").
+				Multiple(true).
+				Chips(true).
+				ClosableChips(true).
+				Attr(web.VField(field.Name, ids)...)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) error {
+			params := obj.(*RefreshParams)
+			for _, v := range ctx.R.Form[field.Name] {
+				id, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return err
+				}
+				params.Packages = append(params.Packages, uint(id))
+			}
+			if len(params.Packages) == 0 {
+				return errors.New("Select packages")
+			}
+			return nil
+		})
+
+	return action
+}
+
+// editable limits packages to those the user can change:
+// admins change all, maintainers packages in their namespaces, others nothing
+func (m *Packages) editable(db *gorm.DB, user *umodels.User) *gorm.DB {
+	switch {
+	case user == nil:
+		return db.Where("1 = 0")
+	case user.IsAdmin():
+		return db
+	case user.HasRole(umodels.RoleMaintainer):
+		return db.Where(models.OwnedCondition, models.Owned(user.ID))
+	default:
+		return db.Where("1 = 0")
+	}
+}
+
+// selected runs fn for packages chosen in the job dialog
+func (m *Packages) selected(fn func(context.Context, *models.Package, Logf) error) worker.JobHandler {
+	return func(ctx context.Context, job worker.QorJobInterface) error {
+		info, err := job.GetJobInfo()
+		if err != nil {
+			return err
+		}
+
+		user, err := m.jobs.Operator(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		var ps []models.Package
+		err = m.editable(m.db.WithContext(ctx), user).
+			Where("id IN ?", info.Argument.(*RefreshParams).Packages).
+			Order("package").
+			Find(&ps).Error
+		if err != nil {
+			return err
+		}
+
+		return m.each(ctx, job, ps, fn)
+	}
+}
+
+// refreshAll refreshes all active git packages the operator can change
+func (m *Packages) refreshAll(ctx context.Context, job worker.QorJobInterface) error {
+	user, err := m.jobs.Operator(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	var ps []models.Package
+	err = m.editable(m.db.WithContext(ctx), user).
+		Where("active = ? AND vcs = ?", true, models.VCSGit).
+		Order("package").
+		Find(&ps).Error
+	if err != nil {
+		return err
+	}
+
+	return m.each(ctx, job, ps, m.Refresh)
+}
+
+// each continues with the next package on error, job fails if any package failed
+func (m *Packages) each(ctx context.Context, job worker.QorJobInterface, ps []models.Package, fn func(context.Context, *models.Package, Logf) error) error {
+	failed := 0
+	for i := range ps {
+		if err := ctx.Err(); err != nil {
+			job.AddLog("job aborted")
+			return nil
+		}
+
+		if err := fn(ctx, &ps[i], job.AddLogf); err != nil {
+			failed++
+			job.AddLogf("%s: error: %s", ps[i].Package, err)
+		}
+
+		job.SetProgress(uint((i + 1) * 100 / len(ps)))
+	}
+
+	job.AddLogf("total %d, failed %d", len(ps), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d packages failed", failed, len(ps))
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package packages
+
+import (
+	"errors"
+	"time"
+)
+
+type Config struct {
+	// RefreshInterval is how often every active git package is refreshed in background, 0 disables it
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+func (c Config) Validate() error {
+	if c.RefreshInterval < 0 {
+		return errors.New("refresh interval must not be negative")
+	}
+	if c.RefreshInterval > 0 && c.RefreshInterval < time.Hour {
+		return errors.New("refresh interval must be at least 1h, refresh clones repositories")
+	}
+	return nil
+}
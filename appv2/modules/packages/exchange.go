@@ -0,0 +1,353 @@
+package packages
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/worker"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/exchange"
+	"github.com/qor5/x/v3/i18n"
+	"github.com/qor5/x/v3/login"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	. "github.com/theplant/htmlgo"
+	"gorm.io/gorm"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// ImportParams are filled in the import dialog
+type ImportParams struct {
+	// File is the uploaded csv path in storage
+	File string
+	// DryRun only validates rows and reports what would be created or updated
+	DryRun bool
+}
+
+// columns of csv, package path is the key rows are upserted by.
+// VCS column is optional on import, files exported before it keep working
+var csvColumns = []string{"Package", "Title", "Info", "Repo", "Active", "VCS"}
+
+func metas() []*exchange.Meta {
+	ms := make([]*exchange.Meta, 0, len(csvColumns))
+	for _, c := range csvColumns {
+		ms = append(ms, exchange.NewMeta(c))
+	}
+	return ms
+}
+
+// configureExchange adds import and export buttons to packages listing
+func (m *Packages) configureExchange(mb *presets.ModelBuilder, lb *presets.ListingBuilder) {
+	wb := m.jobs.Worker()
+
+	imp := wb.ActionJob("Import", mb, m.importPackages).
+		Description("CSV with Package, Title, Info, Repo, Active and optional VCS columns, rows are matched by Package").
+		Params(&ImportParams{DryRun: true}).
+		DisplayLog(true)
+
+	imp.GetParamsModelBuilder().Editing().Field("File").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			return VFileInput().Label(field.Label).Attr("accept", ".csv,text/csv").Attr(web.VField(field.Name, nil)...)
+		}).
+		SetterFunc(m.upload)
+
+	exp := wb.ActionJob("Export", mb, m.exportPackages).
+		DisplayLog(true)
+
+	lb.Action("Import").ButtonCompFunc(func(ctx *web.EventContext) HTMLComponent {
+		// viewers can not change packages, so they can not import them
+		if mb.Info().Verifier().Do(presets.PermCreate).WithReq(ctx.R).IsAllowed() != nil {
+			return Components()
+		}
+		return VBtn(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Import")).
+			Variant(VariantTonal).Class("ml-2").
+			Attr("@click", imp.URL())
+	})
+	lb.Action("Export").ButtonCompFunc(func(ctx *web.EventContext) HTMLComponent {
+		return VBtn(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Export")).
+			Variant(VariantTonal).Class("ml-2").
+			Attr("@click", exp.URL())
+	})
+}
+
+// upload stores csv in storage, job arguments keep only its path
+func (m *Packages) upload(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) error {
+	if ctx.R.MultipartForm == nil || len(ctx.R.MultipartForm.File[field.Name]) == 0 {
+		return errors.New("CSV file is required")
+	}
+
+	fh := ctx.R.MultipartForm.File[field.Name][0]
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := fmt.Sprintf("imports/%d-%s", time.Now().UnixNano(), path.Base(fh.Filename))
+	o, err := m.storage.Put(ctx.R.Context(), name, f)
+	if err != nil {
+		return err
+	}
+
+	obj.(*ImportParams).File = o.Path
+	return nil
+}
+
+// importRow is a parsed csv row, errs are found before the editor rules are checked
+type importRow struct {
+	row  uint
+	pkg  *models.Package
+	errs web.ValidationErrors
+}
+
+// parseImport reads csv rows into packages, vcs reports whether the file has VCS column
+func parseImport(reader exchange.Reader) (rows []importRow, vcs bool, err error) {
+	index := map[string]int{}
+	for i, h := range reader.Header() {
+		index[h] = i
+	}
+	for _, c := range csvColumns[:len(csvColumns)-1] {
+		if _, ok := index[c]; !ok {
+			return nil, false, fmt.Errorf("column %s not found", c)
+		}
+	}
+	_, vcs = index["VCS"]
+
+	seen := map[string]uint{}
+	for reader.Next() {
+		values, err := reader.ReadRow()
+		if err != nil {
+			return nil, false, err
+		}
+		get := func(c string) string {
+			if i, ok := index[c]; ok && i < len(values) {
+				return values[i]
+			}
+			return ""
+		}
+
+		r := importRow{
+			row: uint(len(rows) + 1),
+			pkg: &models.Package{
+				Package: get("Package"),
+				Title:   get("Title"),
+				Info:    get("Info"),
+				Repo:    get("Repo"),
+				VCS:     get("VCS"),
+			},
+		}
+		if v := get("Active"); v != "" {
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				r.errs.FieldError("Active", fmt.Sprintf("Invalid boolean %q", v))
+			}
+			r.pkg.Active = active
+		}
+		if prev, ok := seen[r.pkg.Package]; ok && r.pkg.Package != "" {
+			r.errs.FieldError("Package", fmt.Sprintf("Duplicates row %d", prev))
+		}
+		seen[r.pkg.Package] = r.row
+
+		rows = append(rows, r)
+	}
+
+	return rows, vcs, nil
+}
+
+// differs reports whether imported package differs from the stored one,
+// VCS is compared only when the file has the column
+func differs(old, p *models.Package, vcs bool) bool {
+	return old.Title != p.Title || old.Info != p.Info || old.Repo != p.Repo || old.Active != p.Active ||
+		(vcs && old.VCS != p.VCS)
+}
+
+// importPackages validates every row with the editor rules and upserts changed packages by path
+// in one transaction, nothing is written on dry run or if any row is invalid.
+// Written packages are recorded in the activity log. The uploaded file is removed after the run
+func (m *Packages) importPackages(ctx context.Context, job worker.QorJobInterface) error {
+	info, err := job.GetJobInfo()
+	if err != nil {
+		return err
+	}
+	params := info.Argument.(*ImportParams)
+
+	user, err := m.jobs.Operator(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// aborted job cancels ctx, the file is removed anyway
+		if err := m.storage.Delete(context.WithoutCancel(ctx), params.File); err != nil {
+			job.AddLogf("remove %s: %s", params.File, err)
+		}
+	}()
+
+	rc, err := m.storage.GetStream(ctx, params.File)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	reader, err := exchange.NewCSVReader(rc)
+	if err != nil {
+		return err
+	}
+
+	rows, vcs, err := parseImport(reader)
+	if err != nil {
+		return err
+	}
+
+	var (
+		created, updated, unchanged, invalid int
+		pkgs                                 []*models.Package
+	)
+	for _, r := range rows {
+		job.SetProgress(r.row * 100 / uint(len(rows)))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p, errs := r.pkg, r.errs
+		if !errs.HaveErrors() {
+			errs = m.validate(ctx, user, p)
+		}
+
+		if errs.HaveErrors() {
+			invalid++
+			job.AddLogf("row %d %s: error: %s", r.row, p.Package, describe(&errs))
+			continue
+		}
+
+		var old models.Package
+		if err := m.db.WithContext(ctx).Where("package = ?", p.Package).Limit(1).Find(&old).Error; err != nil {
+			return err
+		}
+
+		switch {
+		case old.ID == 0:
+			created++
+			job.AddLogf("row %d %s: create", r.row, p.Package)
+		case differs(&old, p, vcs):
+			updated++
+			job.AddLogf("row %d %s: update", r.row, p.Package)
+		default:
+			unchanged++
+			job.AddLogf("row %d %s: unchanged", r.row, p.Package)
+			continue
+		}
+		pkgs = append(pkgs, p)
+	}
+
+	job.AddLogf("total %d: create %d, update %d, unchanged %d, error %d", len(rows), created, updated, unchanged, invalid)
+
+	switch {
+	case invalid > 0:
+		return fmt.Errorf("%d invalid rows, nothing is imported", invalid)
+	case params.DryRun:
+		job.AddLog("dry run, nothing is imported")
+		return nil
+	case len(pkgs) == 0:
+		return nil
+	}
+
+	// files exported before VCS column keep VCS of existing packages
+	columns := []string{"title", "info", "repo", "active"}
+	if vcs {
+		columns = append(columns, "vcs")
+	}
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(models.Upsert(columns...)).CreateInBatches(pkgs, 100).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// activity log takes the operator from context like in the editor
+	actx := context.WithValue(ctx, login.UserKey, user)
+	paths := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		m.audit.Log(actx, "Import", p)
+		paths = append(paths, p.Package)
+	}
+	m.changed(ctx, paths...)
+
+	return nil
+}
+
+// exportPackages writes packages visible to the operator to csv in storage
+func (m *Packages) exportPackages(ctx context.Context, job worker.QorJobInterface) error {
+	info, err := job.GetJobInfo()
+	if err != nil {
+		return err
+	}
+
+	user, err := m.jobs.Operator(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	db := m.db.WithContext(ctx)
+	if !user.IsAdmin() && user.HasRole(umodels.RoleMaintainer) {
+		db = db.Where(models.OwnedCondition, models.Owned(user.ID))
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := exchange.NewCSVWriter(buf)
+	if err != nil {
+		return err
+	}
+
+	if err := exchange.NewExporter(&models.Package{}).Metas(metas()...).Exec(db, w); err != nil {
+		return err
+	}
+
+	o, err := m.storage.Put(ctx, fmt.Sprintf("exports/packages-%s.csv", info.JobID), buf)
+	if err != nil {
+		return err
+	}
+
+	url, err := m.storage.GetURL(ctx, o.Path)
+	if err != nil {
+		return err
+	}
+
+	job.SetProgress(100)
+	return job.SetProgressText(fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, html.EscapeString(url), html.EscapeString(path.Base(o.Path))))
+}
+
+func (m *Packages) exists(ctx context.Context, pkg string) bool {
+	var count int64
+	m.db.WithContext(ctx).Model(&models.Package{}).Where("package = ?", pkg).Count(&count)
+	return count > 0
+}
+
+// describe joins validation errors into one log line
+func describe(errs *web.ValidationErrors) string {
+	msgs := append([]string{}, errs.GetGlobalErrors()...)
+
+	fields := make([]string, 0, len(errs.FieldErrors()))
+	for f := range errs.FieldErrors() {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	for _, f := range fields {
+		for _, msg := range errs.GetFieldErrors(f) {
+			msgs = append(msgs, f+": "+msg)
+		}
+	}
+
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,114 @@
+package packages
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/exchange"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+)
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		csv    string
+		err    bool
+		vcs    bool
+		rows   int
+		errors map[uint]string
+	}{
+		{
+			name: "without vcs",
+			csv:  "Package,Title,Info,Repo,Active\ngohome.4gophers.ru/a,A,,https://gitflic.ru/a,true\n",
+			rows: 1,
+		},
+		{
+			name: "with vcs",
+			csv:  "Package,Title,Info,Repo,Active,VCS\ngohome.4gophers.ru/a,A,,https://hg.example.com/a,1,hg\n",
+			vcs:  true,
+			rows: 1,
+		},
+		{
+			name: "missing column",
+			csv:  "Package,Title,Repo,Active\ngohome.4gophers.ru/a,A,https://gitflic.ru/a,true\n",
+			err:  true,
+		},
+		{
+			name:   "invalid active",
+			csv:    "Package,Title,Info,Repo,Active\ngohome.4gophers.ru/a,A,,https://gitflic.ru/a,yes\n",
+			rows:   1,
+			errors: map[uint]string{1: "Active"},
+		},
+		{
+			name:   "duplicate",
+			csv:    "Package,Title,Info,Repo,Active\ngohome.4gophers.ru/a,A,,https://gitflic.ru/a,true\ngohome.4gophers.ru/a,B,,https://gitflic.ru/b,false\n",
+			rows:   2,
+			errors: map[uint]string{2: "Package"},
+		},
+	}
+
+	for _, tt := range tests {
+		reader, err := exchange.NewCSVReader(io.NopCloser(strings.NewReader(tt.csv)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows, vcs, err := parseImport(reader)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: got error %v", tt.name, err)
+			continue
+		}
+		if vcs != tt.vcs || len(rows) != tt.rows {
+			t.Errorf("%s: got vcs %t and %d rows, want %t and %d", tt.name, vcs, len(rows), tt.vcs, tt.rows)
+			continue
+		}
+		for _, r := range rows {
+			field, ok := tt.errors[r.row]
+			if r.errs.HaveErrors() != ok || (ok && len(r.errs.GetFieldErrors(field)) == 0) {
+				t.Errorf("%s: row %d errors %q", tt.name, r.row, describe(&r.errs))
+			}
+		}
+	}
+}
+
+func TestParseImportValues(t *testing.T) {
+	reader, err := exchange.NewCSVReader(io.NopCloser(strings.NewReader("Active,Repo,Package,Info,Title,VCS\ntrue,svn://svn.example.com/a,gohome.4gophers.ru/a,Info,Title,svn\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, _, err := parseImport(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.Package{Package: "gohome.4gophers.ru/a", Title: "Title", Info: "Info", Repo: "svn://svn.example.com/a", Active: true, VCS: models.VCSSvn}
+	if got := *rows[0].pkg; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffers(t *testing.T) {
+	old := &models.Package{Package: "gohome.4gophers.ru/a", Title: "A", Repo: "https://gitflic.ru/a", Active: true, VCS: models.VCSGit, Readme: "# A"}
+
+	tests := []struct {
+		name string
+		p    models.Package
+		vcs  bool
+		want bool
+	}{
+		{"same", models.Package{Package: old.Package, Title: "A", Repo: old.Repo, Active: true, VCS: models.VCSGit}, true, false},
+		{"title", models.Package{Package: old.Package, Title: "B", Repo: old.Repo, Active: true, VCS: models.VCSGit}, true, true},
+		{"active", models.Package{Package: old.Package, Title: "A", Repo: old.Repo, VCS: models.VCSGit}, true, true},
+		{"vcs", models.Package{Package: old.Package, Title: "A", Repo: old.Repo, Active: true, VCS: models.VCSHg}, true, true},
+		{"vcs without column", models.Package{Package: old.Package, Title: "A", Repo: old.Repo, Active: true, VCS: models.VCSHg}, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := differs(old, &tt.p, tt.vcs); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Namespace is the first path element after domain, e.g. kovardin
+// in gohome.4gophers.ru/kovardin/example. It is owned by a user or by an organization
+type Namespace struct {
+	gorm.Model
+
+	Name           string `gorm:"uniqueIndex"`
+	UserID         uint   `gorm:"default:0;index"`
+	OrganizationID uint   `gorm:"default:0;index"`
+}
+
+// Owner of the namespace
+func (n Namespace) Owner() Owner {
+	return Owner{UserID: n.UserID, OrganizationID: n.OrganizationID}
+}
+
+// Namespace returns namespace of the package path
+func (p Package) Namespace() string {
+	path := strings.TrimPrefix(p.Package, Domain+"/")
+	ns, _, _ := strings.Cut(path, "/")
+	return ns
+}
+
+// memberOf selects organizations the user is a member of
+const memberOf = `SELECT organization_id FROM members WHERE user_id = @user`
+
+// OwnedCondition matches packages owned by user directly or through organizations:
+// transferred packages by their owner, others by owner of the namespace.
+// Use it with Owned argument
+const OwnedCondition = `(packages.user_id = @user OR packages.organization_id IN (` + memberOf + `) OR ` +
+	`(packages.user_id = 0 AND packages.organization_id = 0 AND split_part(packages.package, '/', 2) IN ` +
+	`(SELECT name FROM namespaces WHERE deleted_at IS NULL AND (user_id = @user OR organization_id IN (` + memberOf + `)))))`
+
+// Owned is the argument of OwnedCondition
+func Owned(userID uint) sql.NamedArg {
+	return sql.Named("user", userID)
+}
+
+// Owns checks that user owns namespace directly or through organization
+func Owns(db *gorm.DB, userID uint, namespace string) (bool, error) {
+	var count int64
+	err := db.Model(&Namespace{}).
+		Where("name = ?", namespace).
+		Where("user_id = @user OR organization_id IN ("+memberOf+")", Owned(userID)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// OwnsPackage checks that user owns the package directly or through organization
+func OwnsPackage(db *gorm.DB, userID, packageID uint) (bool, error) {
+	var count int64
+	err := db.Model(&Package{}).
+		Where("id = ?", packageID).
+		Where(OwnedCondition, Owned(userID)).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestOwned(t *testing.T) {
+	db := dbtest.Open(t, Tables...)
+
+	// user 1 owns namespace alpha, organization team owns namespace team,
+	// user 2 is a member of team, user 3 got a package transferred to them
+	org := Organization{Name: "team"}
+	db.Create(&org)
+	db.Create(&Member{OrganizationID: org.ID, UserID: 2, Role: MemberMaintainer})
+	db.Create(&[]Namespace{{Name: "alpha", UserID: 1}, {Name: "team", OrganizationID: org.ID}, {Name: "gone", UserID: 4}})
+
+	pkgs := map[string]*Package{
+		"alpha":       {Package: Domain + "/alpha/a"},
+		"alpha-nest":  {Package: Domain + "/alpha/a/v2"},
+		"team":        {Package: Domain + "/team/b"},
+		"transferred": {Package: Domain + "/alpha/c", UserID: 3},
+		"to-team":     {Package: Domain + "/alpha/d", OrganizationID: org.ID},
+		"gone":        {Package: Domain + "/gone/e"},
+		"prefix":      {Package: Domain + "/alphabet/f"},
+	}
+	for _, p := range pkgs {
+		db.Create(p)
+	}
+	// packages of deleted namespaces are not owned by anybody
+	db.Where("name = ?", "gone").Delete(&Namespace{})
+
+	owned := func(user uint) []string {
+		var ids []uint
+		if err := db.Model(&Package{}).Where(OwnedCondition, Owned(user)).Pluck("id", &ids).Error; err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for name, p := range pkgs {
+			if slices.Contains(ids, p.ID) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	tests := map[uint][]string{
+		1: {"alpha", "alpha-nest"},
+		2: {"team", "to-team"},
+		3: {"transferred"},
+		4: nil,
+		5: nil,
+	}
+	for user, want := range tests {
+		if got := owned(user); !slices.Equal(got, want) {
+			t.Errorf("user %d: got %v, want %v", user, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		user uint
+		pkg  string
+		want bool
+	}{
+		{1, "alpha", true},
+		{1, "transferred", false},
+		{3, "transferred", true},
+		{2, "to-team", true},
+	} {
+		got, err := OwnsPackage(db, tt.user, pkgs[tt.pkg].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("user %d owns %s: got %t, want %t", tt.user, tt.pkg, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		user uint
+		ns   string
+		want bool
+	}{
+		{1, "alpha", true},
+		{2, "alpha", false},
+		{2, "team", true},
+		{4, "gone", false},
+		{1, "unknown", false},
+	} {
+		got, err := Owns(db, tt.user, tt.ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("user %d owns namespace %s: got %t, want %t", tt.user, tt.ns, got, tt.want)
+		}
+	}
+}
+
+func TestManages(t *testing.T) {
+	db := dbtest.Open(t, Tables...)
+	db.Create(&[]Member{
+		{OrganizationID: 1, UserID: 1, Role: MemberOwner},
+		{OrganizationID: 1, UserID: 2, Role: MemberMaintainer},
+	})
+
+	tests := []struct {
+		user  uint
+		owner Owner
+		roles []string
+		want  bool
+	}{
+		{1, Owner{UserID: 1}, nil, true},
+		{2, Owner{UserID: 1}, nil, false},
+		{2, Owner{OrganizationID: 1}, nil, true},
+		{2, Owner{OrganizationID: 1}, []string{MemberOwner}, false},
+		{1, Owner{OrganizationID: 1}, []string{MemberOwner}, true},
+		{1, Owner{}, nil, false},
+	}
+	for _, tt := range tests {
+		got, err := Manages(db, tt.user, tt.owner, tt.roles...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("user %d manages %+v with %v: got %t, want %t", tt.user, tt.owner, tt.roles, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles of organization members: owners manage members and transfers,
+// maintainers manage packages in namespaces of the organization
+const (
+	MemberOwner      = "owner"
+	MemberMaintainer = "maintainer"
+)
+
+// MemberRoles are offered in admin in this order
+var MemberRoles = []string{MemberOwner, MemberMaintainer}
+
+// Organization owns namespaces and packages maintained by several users
+type Organization struct {
+	gorm.Model
+
+	Name  string `gorm:"uniqueIndex"`
+	Title string
+}
+
+// Member of the organization, user is a member of the organization once
+type Member struct {
+	ID             uint   `gorm:"primarykey"`
+	OrganizationID uint   `gorm:"uniqueIndex:idx_members_organization_user"`
+	UserID         uint   `gorm:"uniqueIndex:idx_members_organization_user;index"`
+	Role           string `gorm:"default:maintainer"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Statuses of invitations and transfers
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+)
+
+// Invitation asks a user to join the organization, the user accepts or declines it
+type Invitation struct {
+	gorm.Model
+
+	OrganizationID uint `gorm:"index"`
+	// Invitee is email or account of the invited user
+	Invitee   string `gorm:"index"`
+	Role      string `gorm:"default:maintainer"`
+	InvitedBy uint
+	Status    string `gorm:"default:pending;index"`
+}
+
+// InviteeCondition matches invitations addressed to the user by account or email
+const InviteeCondition = `lower(invitations.invitee) IN (lower(?), lower(?))`
+
+// Owner is a user or an organization, one of ids is set
+type Owner struct {
+	UserID         uint `json:"user_id,omitempty"`
+	OrganizationID uint `json:"organization_id,omitempty"`
+}
+
+func (o Owner) IsZero() bool {
+	return o.UserID == 0 && o.OrganizationID == 0
+}
+
+// Manages checks that user acts for the owner: the user itself or a member
+// of the organization with one of roles, any role when roles are empty
+func Manages(db *gorm.DB, userID uint, o Owner, roles ...string) (bool, error) {
+	if o.UserID != 0 {
+		return o.UserID == userID, nil
+	}
+	if o.OrganizationID == 0 {
+		return false, nil
+	}
+
+	q := db.Model(&Member{}).Where("organization_id = ? AND user_id = ?", o.OrganizationID, userID)
+	if len(roles) > 0 {
+		q = q.Where("role IN ?", roles)
+	}
+
+	var count int64
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+// PackageOwner is the owner the package was transferred to or the owner of its namespace,
+// zero for packages in namespaces nobody owns
+func PackageOwner(db *gorm.DB, p Package) (Owner, error) {
+	if p.UserID != 0 || p.OrganizationID != 0 {
+		return Owner{UserID: p.UserID, OrganizationID: p.OrganizationID}, nil
+	}
+
+	var ns Namespace
+	err := db.Where("name = ?", p.Namespace()).Limit(1).Find(&ns).Error
+	return ns.Owner(), err
+}
@@ -1,6 +1,27 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tables are migrated by admin, public server only checks them
+var Tables = []any{
+	&Package{},
+	&Version{},
+	&Namespace{},
+	&Resolution{},
+	&Organization{},
+	&Member{},
+	&Invitation{},
+	&Transfer{},
+}
+
+// Domain is the vanity host every package path starts with
+const Domain = "gohome.4gophers.ru"
 
 type Package struct {
 	gorm.Model
@@ -10,4 +31,108 @@ type Package struct {
 	Repo    string
 	Package string
 	Active  bool
+	// VCS is the go-import type of Repo, for mod Repo is module proxy url
+	VCS string `gorm:"default:git"`
+
+	// Readme and exported Symbols are collected from repository for search
+	Readme  string `gorm:"type:text"`
+	Symbols string `gorm:"type:text"`
+
+	// RepoError is the last error of repository refresh, empty when repository is reachable
+	RepoError string
+	CheckedAt *time.Time
+
+	// Commits adds subjects of commits between tags to the changelog
+	Commits bool
+
+	// UserID or OrganizationID is the owner the package was transferred to,
+	// both are zero while the package belongs to the owner of its namespace
+	UserID         uint `gorm:"default:0;index"`
+	OrganizationID uint `gorm:"default:0;index"`
+}
+
+// VCS types the go command accepts in go-import meta tag
+const (
+	VCSGit    = "git"
+	VCSHg     = "hg"
+	VCSSvn    = "svn"
+	VCSBzr    = "bzr"
+	VCSFossil = "fossil"
+	// VCSMod points the go command to module proxy instead of repository
+	VCSMod = "mod"
+)
+
+// VCSs are offered in admin in this order
+var VCSs = []string{VCSGit, VCSHg, VCSSvn, VCSBzr, VCSFossil, VCSMod}
+
+// Schemes of repository urls the go command accepts for every VCS
+var Schemes = map[string][]string{
+	VCSGit:    {"https", "http", "git", "git+ssh", "ssh"},
+	VCSHg:     {"https", "http", "ssh"},
+	VCSSvn:    {"https", "http", "svn", "svn+ssh"},
+	VCSBzr:    {"https", "http", "bzr", "bzr+ssh"},
+	VCSFossil: {"https", "http"},
+	VCSMod:    {"https", "http"},
+}
+
+// badges are rendered by public server for every package, see app/handlers/badge
+var badges = []string{"version", "goget", "license", "go"}
+
+// Paths are urls of public server showing the package, caches in front of it are purged by them
+func Paths(pkg string) []string {
+	rel := strings.TrimPrefix(pkg, Domain)
+	paths := []string{rel, rel + "?go-get=1"}
+	for _, b := range badges {
+		paths = append(paths, "/badge"+rel+"/"+b+".svg")
+	}
+	paths = append(paths, "/doc"+rel, "/changelog"+rel, "/feed.atom", "/feed"+rel+".atom")
+	if ns, _, ok := strings.Cut(strings.TrimPrefix(rel, "/"), "/"); ok {
+		paths = append(paths, "/feed/"+ns+".atom")
+	}
+	return paths
+}
+
+// Channel of Postgres notifications about changed packages, payload is the package path.
+// Public servers drop cached resolutions of the package when they get it
+const Channel = "gohome_packages"
+
+// PackageIndex makes path of active package unique, import upserts packages by path.
+// Soft deleted packages keep their paths, so they are left out of the index
+const PackageIndex = `CREATE UNIQUE INDEX IF NOT EXISTS idx_packages_package_active ON packages (package) WHERE deleted_at IS NULL`
+
+// DropPackageIndex removes unique index over all paths, it conflicts with soft deletes
+const DropPackageIndex = `DROP INDEX IF EXISTS idx_packages_package`
+
+// DuplicatePaths finds active packages sharing a path, PackageIndex can't be created while they exist
+const DuplicatePaths = `SELECT package FROM packages WHERE deleted_at IS NULL GROUP BY package HAVING count(*) > 1`
+
+// ClaimRefresh takes the active git package checked longest ago if it was not checked since @before.
+// checked_at is moved to now, so other admin instances skip the package
+const ClaimRefresh = `UPDATE packages SET checked_at = now() WHERE id = (
+	SELECT id FROM packages
+	WHERE deleted_at IS NULL AND active AND vcs = 'git' AND repo LIKE 'http%' AND (checked_at IS NULL OR checked_at < @before)
+	ORDER BY checked_at NULLS FIRST LIMIT 1
+	FOR UPDATE SKIP LOCKED
+) RETURNING *`
+
+// SaveDocs stores readme and exported symbols of the package only when they are changed,
+// so updated_at of the package is not moved by refreshes finding the same docs
+func SaveDocs(db *gorm.DB, packageID uint, readme, symbols string) (changed bool, err error) {
+	res := db.Model(&Package{}).
+		Where("id = ? AND (readme IS DISTINCT FROM ? OR symbols IS DISTINCT FROM ?)", packageID, readme, symbols).
+		Updates(map[string]interface{}{
+			"readme":  readme,
+			"symbols": symbols,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Upsert creates packages or updates columns of active packages with the same path,
+// conflict target matches PackageIndex
+func Upsert(columns ...string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns:     []clause.Column{{Name: "package"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoUpdates:   clause.AssignmentColumns(append(columns, "updated_at")),
+	}
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func dryRun(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpsert(t *testing.T) {
+	db := dryRun(t)
+
+	pkgs := []*Package{{Package: Domain + "/a", Title: "a"}}
+	res := db.Clauses(Upsert("title", "active")).Create(&pkgs)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	stmt := res.Statement
+
+	sql := strings.Join(strings.Fields(stmt.SQL.String()), " ")
+	want := `ON CONFLICT ("package") WHERE deleted_at IS NULL DO UPDATE SET "title"="excluded"."title","active"="excluded"."active","updated_at"="excluded"."updated_at"`
+	if !strings.Contains(sql, want) {
+		t.Errorf("conflict target does not match partial index:\n%s", sql)
+	}
+}
+
+func TestClaimRefresh(t *testing.T) {
+	before := time.Now().Add(-time.Hour)
+	var p Package
+	stmt := dryRun(t).Raw(ClaimRefresh, sql.Named("before", before)).Scan(&p).Statement
+
+	query := stmt.SQL.String()
+	if !strings.Contains(query, "checked_at < $1") || !strings.Contains(query, "repo LIKE 'http%'") {
+		t.Errorf("unexpected query:\n%s", query)
+	}
+	if len(stmt.Vars) != 1 || stmt.Vars[0] != before {
+		t.Errorf("unexpected vars %v", stmt.Vars)
+	}
+}
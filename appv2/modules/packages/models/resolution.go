@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Resolution counts requests of the go command for a path per day
+type Resolution struct {
+	ID   uint      `gorm:"primarykey"`
+	Day  time.Time `gorm:"type:date;uniqueIndex:idx_resolutions_day_path"`
+	Path string    `gorm:"uniqueIndex:idx_resolutions_day_path"`
+	// PackageID is zero when nobody registered the path
+	PackageID uint `gorm:"index"`
+	Count     int64
+	UpdatedAt time.Time
+}
+
+// Lookup finds active package the path belongs to,
+// path may point to a package inside the module
+func Lookup(db *gorm.DB, path string) (Package, bool) {
+	// Find instead of First, unknown paths are usual and must not be logged as errors
+	var p Package
+	res := db.
+		Where("active = ?", true).
+		Where("package = ? OR ? LIKE package || '/%'", path, path).
+		Order("length(package) DESC").
+		Limit(1).
+		Find(&p)
+	return p, res.Error == nil && res.RowsAffected > 0
+}
+
+// Track adds counts of resolutions, rows must be unique by day and path
+func Track(db *gorm.DB, rs []Resolution) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}, {Name: "path"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("resolutions.count + excluded.count"),
+			"package_id": gorm.Expr("excluded.package_id"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).CreateInBatches(&rs, 500).Error
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchColumn is generated from package fields, package path and symbols are indexed
+// with simple configuration, texts with both russian and english configurations
+const SearchColumn = `ALTER TABLE packages ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', translate(coalesce(package, ''), './-_', '    ')), 'A') ||
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(symbols, '')), 'B') ||
+	setweight(to_tsvector('english', coalesce(info, '')), 'B') ||
+	setweight(to_tsvector('russian', coalesce(info, '')), 'B') ||
+	setweight(to_tsvector('english', coalesce(readme, '')), 'C') ||
+	setweight(to_tsvector('russian', coalesce(readme, '')), 'C')
+) STORED`
+
+const SearchIndex = `CREATE INDEX IF NOT EXISTS idx_packages_search ON packages USING GIN (search)`
+
+// SearchCondition matches packages against websearch query, expects query three times
+const SearchCondition = `packages.search @@ (websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?) || websearch_to_tsquery('simple', ?))`
+
+const searchRank = `packages.*, ts_rank_cd(packages.search, websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?) || websearch_to_tsquery('simple', ?)) AS search_rank`
+
+// Search filters packages by full text query and orders them by rank. Rank is selected
+// as a column, so orders added later, e.g. by admin listing, are applied after it
+func Search(q string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Select(searchRank, q, q, q).
+			Where(SearchCondition, q, q, q).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "search_rank", Raw: true}, Desc: true})
+	}
+}
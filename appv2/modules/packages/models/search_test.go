@@ -0,0 +1,79 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestSearchOrder(t *testing.T) {
+	// admin applies the search as a hook before its own order
+	var ps []Package
+	stmt := Search("http client")(dryRun(t)).Order("id").Find(&ps).Statement
+
+	sql := strings.Join(strings.Fields(stmt.SQL.String()), " ")
+	if !strings.Contains(sql, "ORDER BY search_rank DESC,id") {
+		t.Errorf("rank is not the first order:\n%s", sql)
+	}
+	if !strings.HasPrefix(sql, "SELECT packages.*, ts_rank_cd(") {
+		t.Errorf("rank is not selected:\n%s", sql)
+	}
+	if len(stmt.Vars) != 6 {
+		t.Errorf("expected query in select and condition, got %v", stmt.Vars)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	db := dbtest.Open(t, &Package{})
+	if err := db.Exec(SearchColumn).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs := []Package{
+		{Package: Domain + "/a/req", Title: "HTTP client", Info: "Requests with retries"},
+		{Package: Domain + "/b/util", Title: "Utilities", Readme: "Helpers, among them a small http client"},
+		{Package: Domain + "/c/log", Title: "Logger", Info: "Structured logs"},
+		{Package: Domain + "/d/api", Title: "API", Symbols: "Client NewClient Client.Do"},
+		{Package: Domain + "/e/kv", Title: "Клиент хранилища", Info: "Ключи и значения"},
+	}
+	if err := db.Create(&pkgs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(q string) []string {
+		var found []Package
+		if err := Search(q)(db).Order("id").Find(&found).Error; err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, p := range found {
+			paths = append(paths, strings.TrimPrefix(p.Package, Domain+"/"))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		// title is weighted above readme
+		{"http client", []string{"a/req", "b/util"}},
+		// english stemming
+		{"clients", []string{"a/req", "d/api", "b/util"}},
+		// russian stemming
+		{"клиенты", []string{"e/kv"}},
+		// path parts are words
+		{"log", []string{"c/log"}},
+		// websearch phrase
+		{`"small http client"`, []string{"b/util"}},
+		{"nothing", nil},
+	}
+
+	for _, tt := range tests {
+		if got := search(tt.q); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Transfer moves ownership of the package between users and organizations,
+// it is applied when both sides confirmed it
+type Transfer struct {
+	gorm.Model
+
+	PackageID uint `gorm:"index"`
+
+	FromUserID         uint
+	FromOrganizationID uint
+	ToUserID           uint
+	ToOrganizationID   uint
+
+	// FromConfirmedBy and ToConfirmedBy are users who confirmed the transfer for their side
+	FromConfirmedBy uint
+	ToConfirmedBy   uint
+
+	Status      string `gorm:"default:pending;index"`
+	CreatedBy   uint
+	CompletedAt *time.Time
+}
+
+func (t Transfer) From() Owner {
+	return Owner{UserID: t.FromUserID, OrganizationID: t.FromOrganizationID}
+}
+
+func (t Transfer) To() Owner {
+	return Owner{UserID: t.ToUserID, OrganizationID: t.ToOrganizationID}
+}
+
+// TransferCondition matches transfers the user may confirm or decline on either side
+const TransferCondition = `(transfers.from_user_id = @user OR transfers.to_user_id = @user OR ` +
+	`transfers.from_organization_id IN (SELECT organization_id FROM members WHERE user_id = @user AND role = 'owner') OR ` +
+	`transfers.to_organization_id IN (SELECT organization_id FROM members WHERE user_id = @user AND role = 'owner'))`
+
+// Pending checks that the package has a transfer waiting for confirmation
+func Pending(db *gorm.DB, packageID uint) (bool, error) {
+	var count int64
+	err := db.Model(&Transfer{}).
+		Where("package_id = ? AND status = ?", packageID, StatusPending).
+		Count(&count).Error
+	return count > 0, err
+}
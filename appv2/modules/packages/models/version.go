@@ -0,0 +1,176 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Version is a semver tag of the package repository. Versions are collected only by
+// the refresh job, see packages.Refresh, so badges of never refreshed packages are unknown
+type Version struct {
+	gorm.Model
+
+	PackageID uint `gorm:"index"`
+	Version   string
+	GoVersion string
+	License   string
+	Time      time.Time
+
+	// Message of the annotated tag, empty for lightweight tags
+	Message string `gorm:"type:text"`
+	// Changes are subjects of commits since the previous version, one per line,
+	// collected for packages with Commits enabled
+	Changes string `gorm:"type:text"`
+}
+
+// VersionIndex makes tags unique in the package, refresh upserts versions by tag
+const VersionIndex = `CREATE UNIQUE INDEX IF NOT EXISTS idx_versions_package_version ON versions (package_id, version)`
+
+// DeleteDuplicateVersions keeps the first row of every tag, VersionIndex can't be created while
+// duplicates exist. Versions were replaced by refreshes before, concurrent ones could store a tag twice
+const DeleteDuplicateVersions = `DELETE FROM versions a USING versions b
+	WHERE a.package_id = b.package_id AND a.version = b.version AND a.id > b.id`
+
+// SaveVersions upserts versions of the package by tag and deletes versions of removed tags.
+// Rows of unchanged tags are not written, so they keep their ids and times. When anything
+// is changed updated_at of the package is moved, pages showing versions depend on it
+func SaveVersions(db *gorm.DB, packageID uint, versions []Version) (changed bool, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var old []Version
+		if err := tx.Where("package_id = ?", packageID).Find(&old).Error; err != nil {
+			return err
+		}
+
+		known := make(map[string]Version, len(old))
+		for _, v := range old {
+			known[v.Version] = v
+		}
+
+		var upsert []Version
+		for _, v := range versions {
+			v.PackageID = packageID
+			if o, ok := known[v.Version]; ok {
+				delete(known, v.Version)
+				if o.same(v) {
+					continue
+				}
+			}
+			upsert = append(upsert, v)
+		}
+
+		if len(upsert) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "package_id"}, {Name: "version"}},
+				DoUpdates: clause.AssignmentColumns([]string{"go_version", "license", "time", "message", "changes", "updated_at"}),
+			}).Create(&upsert).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		removed := make([]uint, 0, len(known))
+		for _, v := range known {
+			removed = append(removed, v.ID)
+		}
+		if len(removed) > 0 {
+			if err := tx.Unscoped().Delete(&Version{}, removed).Error; err != nil {
+				return err
+			}
+		}
+
+		if changed = len(upsert) > 0 || len(removed) > 0; !changed {
+			return nil
+		}
+		return tx.Model(&Package{}).Where("id = ?", packageID).UpdateColumn("updated_at", time.Now()).Error
+	})
+	return changed, err
+}
+
+// same reports whether the tag has the same content, time is compared as instant
+func (v Version) same(o Version) bool {
+	return v.Version == o.Version && v.GoVersion == o.GoVersion && v.License == o.License &&
+		v.Time.Equal(o.Time) && v.Message == o.Message && v.Changes == o.Changes
+}
+
+// Release is the version in the changelog
+type Release struct {
+	Version
+	// Previous is the version before this one, empty for the first version
+	Previous string
+	// Major changes the major version relative to the previous version
+	Major      bool
+	Prerelease bool
+}
+
+// Subjects are commit subjects of the release
+func (r Release) Subjects() []string {
+	if r.Changes == "" {
+		return nil
+	}
+	return strings.Split(r.Changes, "\n")
+}
+
+// Changelog orders versions from the newest and flags major version bumps and pre-releases
+func Changelog(versions []Version) []Release {
+	sorted := make([]Version, 0, len(versions))
+	for _, v := range versions {
+		if semver.IsValid(v.Version) {
+			sorted = append(sorted, v)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return semver.Compare(sorted[i].Version, sorted[j].Version) < 0
+	})
+
+	releases := make([]Release, len(sorted))
+	for i, v := range sorted {
+		r := Release{
+			Version:    v,
+			Prerelease: semver.Prerelease(v.Version) != "",
+		}
+		if i > 0 {
+			r.Previous = sorted[i-1].Version
+			r.Major = semver.Major(v.Version) != semver.Major(r.Previous)
+		}
+		releases[len(sorted)-1-i] = r
+	}
+
+	return releases
+}
+
+// Latest returns the version the go command would pick as latest:
+// the highest release, or the highest pre-release if there are no releases
+func Latest(versions []Version) (Version, bool) {
+	var (
+		release    Version
+		prerelease Version
+	)
+
+	for _, v := range versions {
+		if !semver.IsValid(v.Version) {
+			continue
+		}
+
+		if semver.Prerelease(v.Version) == "" {
+			if release.Version == "" || semver.Compare(v.Version, release.Version) > 0 {
+				release = v
+			}
+			continue
+		}
+
+		if prerelease.Version == "" || semver.Compare(v.Version, prerelease.Version) > 0 {
+			prerelease = v
+		}
+	}
+
+	if release.Version != "" {
+		return release, true
+	}
+
+	return prerelease, prerelease.Version != ""
+}
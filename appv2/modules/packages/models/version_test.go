@@ -0,0 +1,224 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func TestSaveVersions(t *testing.T) {
+	db := dbtest.Open(t, &Package{}, &Version{})
+	if err := db.Exec(VersionIndex).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	p := Package{Package: Domain + "/kovardin/example", Active: true}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	load := func() (map[string]Version, time.Time) {
+		var versions []Version
+		if err := db.Where("package_id = ?", p.ID).Find(&versions).Error; err != nil {
+			t.Fatal(err)
+		}
+		byTag := map[string]Version{}
+		for _, v := range versions {
+			byTag[v.Version] = v
+		}
+		var updated Package
+		if err := db.First(&updated, p.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return byTag, updated.UpdatedAt
+	}
+
+	changed, err := SaveVersions(db, p.ID, []Version{
+		Version{Version: "v1.0.0", Time: day},
+		Version{Version: "v1.1.0", Time: day.AddDate(0, 1, 0), Message: "features"},
+	})
+	if err != nil || !changed {
+		t.Fatalf("first save: changed %t, error %v", changed, err)
+	}
+	first, modified := load()
+	if len(first) != 2 {
+		t.Fatalf("got %d versions, want 2", len(first))
+	}
+
+	// the same tags found again
+	changed, err = SaveVersions(db, p.ID, []Version{
+		Version{Version: "v1.0.0", Time: day},
+		Version{Version: "v1.1.0", Time: day.AddDate(0, 1, 0), Message: "features"},
+	})
+	if err != nil || changed {
+		t.Fatalf("same save: changed %t, error %v", changed, err)
+	}
+	same, sameModified := load()
+	for tag, v := range first {
+		if same[tag].ID != v.ID || !same[tag].CreatedAt.Equal(v.CreatedAt) || !same[tag].UpdatedAt.Equal(v.UpdatedAt) {
+			t.Errorf("%s is rewritten: %+v, was %+v", tag, same[tag], v)
+		}
+	}
+	if !sameModified.Equal(modified) {
+		t.Errorf("package updated_at moved from %s to %s", modified, sameModified)
+	}
+
+	// v1.0.0 removed, message of v1.1.0 changed, v1.2.0 added
+	changed, err = SaveVersions(db, p.ID, []Version{
+		Version{Version: "v1.1.0", Time: day.AddDate(0, 1, 0), Message: "features and fixes"},
+		Version{Version: "v1.2.0", Time: day.AddDate(0, 2, 0)},
+	})
+	if err != nil || !changed {
+		t.Fatalf("changed save: changed %t, error %v", changed, err)
+	}
+	last, lastModified := load()
+	if _, ok := last["v1.0.0"]; ok || len(last) != 2 {
+		t.Errorf("got versions %v", last)
+	}
+	if v := last["v1.1.0"]; v.ID != first["v1.1.0"].ID || !v.CreatedAt.Equal(first["v1.1.0"].CreatedAt) || v.Message != "features and fixes" {
+		t.Errorf("v1.1.0 is not updated in place: %+v", v)
+	}
+	if !lastModified.After(modified) {
+		t.Errorf("package updated_at is not moved: %s", lastModified)
+	}
+}
+
+func TestSaveDocs(t *testing.T) {
+	db := dbtest.Open(t, &Package{})
+
+	p := Package{Package: Domain + "/kovardin/example", Active: true}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		readme, symbols string
+		changed         bool
+	}{
+		{"# Example", "Client", true},
+		{"# Example", "Client", false},
+		{"# Example", "Client NewClient", true},
+		{"", "", true},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		var before Package
+		db.First(&before, p.ID)
+
+		changed, err := SaveDocs(db, p.ID, tt.readme, tt.symbols)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var after Package
+		db.First(&after, p.ID)
+
+		if changed != tt.changed || after.UpdatedAt.Equal(before.UpdatedAt) == tt.changed {
+			t.Errorf("%q %q: changed %t, updated_at %s -> %s", tt.readme, tt.symbols, changed, before.UpdatedAt, after.UpdatedAt)
+		}
+		if after.Readme != tt.readme || after.Symbols != tt.symbols {
+			t.Errorf("%q %q: stored %q %q", tt.readme, tt.symbols, after.Readme, after.Symbols)
+		}
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	type flags struct {
+		version    string
+		previous   string
+		major      bool
+		prerelease bool
+	}
+
+	tests := []struct {
+		name     string
+		versions []string
+		want     []flags
+	}{
+		{
+			name:     "empty",
+			versions: nil,
+			want:     []flags{},
+		},
+		{
+			name:     "first version",
+			versions: []string{"v0.1.0"},
+			want:     []flags{{version: "v0.1.0"}},
+		},
+		{
+			name:     "minor and patch",
+			versions: []string{"v1.0.1", "v1.0.0", "v1.1.0"},
+			want: []flags{
+				{version: "v1.1.0", previous: "v1.0.1"},
+				{version: "v1.0.1", previous: "v1.0.0"},
+				{version: "v1.0.0"},
+			},
+		},
+		{
+			name:     "major bumps",
+			versions: []string{"v0.9.0", "v1.0.0", "v2.0.0", "v2.1.0"},
+			want: []flags{
+				{version: "v2.1.0", previous: "v2.0.0"},
+				{version: "v2.0.0", previous: "v1.0.0", major: true},
+				{version: "v1.0.0", previous: "v0.9.0", major: true},
+				{version: "v0.9.0"},
+			},
+		},
+		{
+			name:     "prereleases",
+			versions: []string{"v2.0.0", "v2.0.0-rc.1", "v1.2.0", "v2.0.0-beta.1"},
+			want: []flags{
+				{version: "v2.0.0", previous: "v2.0.0-rc.1"},
+				{version: "v2.0.0-rc.1", previous: "v2.0.0-beta.1", prerelease: true},
+				{version: "v2.0.0-beta.1", previous: "v1.2.0", major: true, prerelease: true},
+				{version: "v1.2.0"},
+			},
+		},
+		{
+			name:     "invalid tags are skipped",
+			versions: []string{"latest", "v1.0.0", "1.1.0", "v1.1.0"},
+			want: []flags{
+				{version: "v1.1.0", previous: "v1.0.0"},
+				{version: "v1.0.0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := make([]Version, len(tt.versions))
+			for i, v := range tt.versions {
+				versions[i] = Version{Version: v}
+			}
+
+			releases := Changelog(versions)
+			got := make([]flags, len(releases))
+			for i, r := range releases {
+				got[i] = flags{version: r.Version.Version, previous: r.Previous, major: r.Major, prerelease: r.Prerelease}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("release %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSubjects(t *testing.T) {
+	if got := (Release{}).Subjects(); got != nil {
+		t.Errorf("release without changes has subjects %q", got)
+	}
+
+	r := Release{Version: Version{Changes: "fix parser\nadd flag"}}
+	if got := r.Subjects(); len(got) != 2 || got[0] != "fix parser" || got[1] != "add flag" {
+		t.Errorf("unexpected subjects %q", got)
+	}
+}
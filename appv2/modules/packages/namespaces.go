@@ -0,0 +1,93 @@
+package packages
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/web/v3"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	. "github.com/theplant/htmlgo"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+func (m *Packages) configureNamespaces(b *presets.Builder) {
+	ma := b.Model(&models.Namespace{}).
+		MenuIcon("mdi-folder-account")
+
+	ma.Listing("ID", "Name", "UserID", "OrganizationID")
+
+	// namespace is owned by a user or by an organization
+	ed := ma.Editing("Name", "UserID", "OrganizationID")
+	ed.Field("UserID").
+		Label("Owner").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var users []umodels.User
+			m.db.Order("name").Find(&users)
+
+			items := []DefaultOptionItem{}
+			for _, u := range users {
+				items = append(items, DefaultOptionItem{
+					Text:  fmt.Sprintf("%s (%s)", u.Name, u.Account),
+					Value: fmt.Sprint(u.ID),
+				})
+			}
+
+			return vx.VXSelect().Label(field.Label).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Clearable(true).
+				Attr(presets.VFieldError(field.Name, fmt.Sprint(field.Value(obj)), field.Errors)...).
+				Disabled(field.Disabled)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+			obj.(*models.Namespace).UserID, err = parseID(ctx.R.FormValue(field.Name))
+			return
+		})
+
+	ed.Field("OrganizationID").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var orgs []models.Organization
+			m.db.Order("name").Find(&orgs)
+
+			items := []DefaultOptionItem{}
+			for _, o := range orgs {
+				items = append(items, DefaultOptionItem{
+					Text:  o.Name,
+					Value: fmt.Sprint(o.ID),
+				})
+			}
+
+			return vx.VXSelect().Label(field.Label).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Clearable(true).
+				Attr(presets.VFieldError(field.Name, fmt.Sprint(field.Value(obj)), field.Errors)...).
+				Disabled(field.Disabled)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+			obj.(*models.Namespace).OrganizationID, err = parseID(ctx.R.FormValue(field.Name))
+			return
+		})
+
+	ed.ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
+		ns := obj.(*models.Namespace)
+		if ns.Name == "" {
+			err.FieldError("Name", "Name is required")
+		}
+		if (ns.UserID == 0) == (ns.OrganizationID == 0) {
+			err.FieldError("UserID", "Choose a user or an organization as owner")
+		}
+		return
+	})
+}
+
+// parseID reads id chosen in select, cleared select is zero
+func parseID(v string) (uint, error) {
+	if v == "" || v == "0" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	return uint(id), err
+}
@@ -0,0 +1,72 @@
+package packages
+
+import (
+	"context"
+	"net/url"
+
+	"golang.org/x/text/language"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// notify emails the owner of the package: the user or owners of the organization,
+// packages in namespaces nobody owns have nobody to notify
+func (m *Packages) notify(ctx context.Context, p *models.Package, template string, data any) error {
+	owner, err := models.PackageOwner(m.db.WithContext(ctx), *p)
+	if err != nil || owner.IsZero() {
+		return err
+	}
+
+	db := m.db.WithContext(ctx)
+	if owner.UserID != 0 {
+		db = db.Where("id = ?", owner.UserID)
+	} else {
+		db = db.Where("id IN (SELECT user_id FROM members WHERE organization_id = ? AND role = ?)", owner.OrganizationID, models.MemberOwner)
+	}
+
+	var users []umodels.User
+	if err := db.Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if to := u.Address(); to != "" {
+			// owners get notifications in the configured language
+			if err := m.mailer.Send(ctx, to, language.Und, template, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// adminURL is the package in admin listing, empty when base url of emails is not configured
+func (m *Packages) adminURL(p *models.Package) string {
+	return m.mailer.URL("/admin/packages?keyword=" + url.QueryEscape(p.Package))
+}
+
+func (m *Packages) notifyUnreachable(ctx context.Context, p *models.Package, logf Logf) {
+	err := m.notify(ctx, p, mailer.TemplateRepoUnreachable, mailer.RepoUnreachable{
+		Package: p.Package,
+		Repo:    p.Repo,
+		Error:   p.RepoError,
+		URL:     m.adminURL(p),
+	})
+	if err != nil {
+		logf("%s: notify owners: %s", p.Package, err)
+	}
+}
+
+func (m *Packages) notifyVersions(ctx context.Context, p *models.Package, versions []string, logf Logf) {
+	err := m.notify(ctx, p, mailer.TemplateNewVersions, mailer.NewVersions{
+		Package:  p.Package,
+		Versions: versions,
+		URL:      m.adminURL(p),
+	})
+	if err != nil {
+		logf("%s: notify owners: %s", p.Package, err)
+	}
+}
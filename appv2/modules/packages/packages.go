@@ -1,36 +1,224 @@
 package packages
 
 import (
+	"context"
+
 	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/presets/gorm2op"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	"github.com/qor5/x/v3/oss"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	h "github.com/theplant/htmlgo"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"gohome.4gophers.ru/getapp/gohome/appv2/database"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/audit"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/jobs"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer"
 	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/gitrepo"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
 )
 
 type Packages struct {
-	db *gorm.DB
+	db       *gorm.DB
+	git      *gitrepo.Client
+	audit    *audit.Audit
+	jobs     *jobs.Jobs
+	storage  oss.StorageInterface
+	purger   *httpcache.Purger
+	notifier *database.Notifier
+	mailer   *mailer.Mailer
+	logger   *zap.Logger
+	config   Config
+	stop     chan struct{}
+	done     chan struct{}
 }
 
-func New(db *gorm.DB) *Packages {
+func New(db *gorm.DB, git *gitrepo.Client, audit *audit.Audit, jobs *jobs.Jobs, storage oss.StorageInterface, purger *httpcache.Purger, notifier *database.Notifier, mailer *mailer.Mailer, logger *zap.Logger, config Config) *Packages {
 	return &Packages{
-		db: db,
+		db:       db,
+		git:      git,
+		audit:    audit,
+		jobs:     jobs,
+		storage:  storage,
+		purger:   purger,
+		notifier: notifier,
+		mailer:   mailer,
+		logger:   logger,
+		config:   config,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 }
 
 func (m *Packages) Configure(b *presets.Builder) {
 	ma := b.Model(&models.Package{}).
 		MenuIcon("mdi-account-group").
-		// Label("Пакеты").
 		RightDrawerWidth("1000")
+	defer m.audit.Register(ma)
+
+	ma.Detailing("Title", "Info", "VCS", "Repo", "Package", "Active", "Commits")
+
+	ed := ma.Editing("Title", "Info", "VCS", "Repo", "Package", "Active", "Commits")
+	ed.Field("VCS").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		value := obj.(*models.Package).VCS
+		if value == "" {
+			value = models.VCSGit
+		}
+		return vx.VXSelect().Label(field.Label).
+			Items(models.VCSs).
+			Attr(presets.VFieldError(field.Name, value, field.Errors)...).
+			Disabled(field.Disabled)
+	})
+
+	ed.
+		ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
+			return m.validate(ctx.R.Context(), umodels.CurrentUser(ctx.R), obj.(*models.Package))
+		}).
+		WrapSaveFunc(func(in presets.SaveFunc) presets.SaveFunc {
+			// pages of the old path are purged too, the path may be changed
+			return func(obj interface{}, id string, ctx *web.EventContext) error {
+				pkgs := []string{obj.(*models.Package).Package}
+				if old := m.path(id); old != "" {
+					pkgs = append(pkgs, old)
+				}
+
+				if err := in(obj, id, ctx); err != nil {
+					return err
+				}
+
+				m.changed(ctx.R.Context(), pkgs...)
+				return nil
+			}
+		}).
+		WrapDeleteFunc(func(in presets.DeleteFunc) presets.DeleteFunc {
+			// packages are soft deleted, their pages are purged like on save
+			return func(obj interface{}, id string, ctx *web.EventContext) error {
+				old := m.path(id)
+				if err := in(obj, id, ctx); err != nil {
+					return err
+				}
+
+				m.changed(ctx.R.Context(), old)
+				return nil
+			}
+		})
+
+	lb := ma.Listing("ID", "Title", "Info", "VCS", "Repo", "Package", "Active")
+
+	// keyword search uses full text index instead of ILIKE over columns, best matches go first
+	lb.WrapSearchFunc(func(in presets.SearchFunc) presets.SearchFunc {
+		return func(ctx *web.EventContext, params *presets.SearchParams) (result *presets.SearchResult, err error) {
+			if params.Keyword != "" {
+				ctx = gorm2op.EventContextWithHook(ctx, models.Search(params.Keyword))
+				params.Keyword = ""
+			}
+
+			// maintainers see only packages in their namespaces
+			if user := umodels.CurrentUser(ctx.R); user != nil && !user.IsAdmin() && user.HasRole(umodels.RoleMaintainer) {
+				params.SQLConditions = append(params.SQLConditions, &presets.SQLCondition{
+					Query: models.OwnedCondition,
+					Args:  []interface{}{models.Owned(user.ID)},
+				})
+			}
+
+			return in(ctx, params)
+		}
+	})
+
+	lb.FilterDataFunc(func(ctx *web.EventContext) vx.FilterData {
+		return []*vx.FilterItem{
+			{
+				Key:      "search",
+				Label:    i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Search"),
+				ItemType: vx.ItemTypeString,
+				// contains modifier would wrap the query with %, websearch query is passed as is
+				Modifier:              vx.ModifierEquals,
+				DisableChooseModifier: true,
+				SQLCondition:          models.SearchCondition,
+			},
+			{
+				Key:          "active",
+				Label:        i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Active"),
+				ItemType:     vx.ItemTypeSelect,
+				SQLCondition: `packages.active = true`,
+				Options: []*vx.SelectItem{
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Yes"), Value: "true", SQLCondition: `packages.active = true`},
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "No"), Value: "false", SQLCondition: `packages.active = false`},
+				},
+			},
+			{
+				// repository status is stored by refresh jobs
+				Key:          "unreachable",
+				Label:        i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Unreachable"),
+				ItemType:     vx.ItemTypeSelect,
+				SQLCondition: `packages.repo_error <> ''`,
+				Options: []*vx.SelectItem{
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "Yes"), Value: "true", SQLCondition: `packages.repo_error <> ''`},
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Packages", "No"), Value: "false", SQLCondition: `packages.repo_error = ''`},
+				},
+			},
+		}
+	})
+
+	m.configureExchange(ma, lb)
+	m.configureActions(ma, lb)
+	m.configureNamespaces(b)
+	m.configureResolutions(b)
+}
 
-	ma.Listing("ID", "Title", "Info", "Repo", "Package", "Active")
+// changed purges public pages of the packages from caches in front of public server
+// and notifies public servers to drop resolutions of the packages
+func (m *Packages) changed(ctx context.Context, pkgs ...string) {
+	var paths, changed []string
+	for _, pkg := range pkgs {
+		if pkg != "" {
+			paths = append(paths, models.Paths(pkg)...)
+			changed = append(changed, pkg)
+		}
+	}
+	m.purger.Purge(ctx, paths...)
+	m.notifier.Notify(ctx, models.Channel, changed...)
+}
+
+// path of the package before it is changed, empty for new packages
+func (m *Packages) path(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	var p models.Package
+	m.db.Select("package").Where("id = ?", id).Limit(1).Find(&p)
+	return p.Package
 }
 
 func (m *Packages) Migrate() {
-	err := m.db.AutoMigrate(
-		&models.Package{},
-	)
+	err := m.db.AutoMigrate(models.Tables...)
 	if err != nil {
 		panic(err)
 	}
+
+	for _, q := range []string{models.SearchColumn, models.SearchIndex, models.DropPackageIndex, models.DeleteDuplicateVersions, models.VersionIndex} {
+		if err := m.db.Exec(q).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	// paths were not unique before, duplicates are resolved by admins and the index is created on next start
+	var duplicates []string
+	if err := m.db.Raw(models.DuplicatePaths).Scan(&duplicates).Error; err != nil {
+		panic(err)
+	}
+	if len(duplicates) > 0 {
+		m.logger.Warn("packages share paths, unique index is not created", zap.Strings("packages", duplicates))
+		return
+	}
+
+	if err := m.db.Exec(models.PackageIndex).Error; err != nil {
+		panic(err)
+	}
 }
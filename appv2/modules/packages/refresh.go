@@ -0,0 +1,430 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/gitrepo"
+)
+
+const (
+	// refreshTimeout limits work on one package, full clone of large repository is slow
+	refreshTimeout = 5 * time.Minute
+	// maxSymbols keeps search column of huge modules in reasonable size
+	maxSymbols = 5000
+	// maxSubjects limits commits of one version in the changelog, e.g. the first one
+	maxSubjects = 100
+)
+
+// Logf reports progress of the refresh, job logs are used in admin
+type Logf func(format string, a ...interface{}) error
+
+// Refresh collects versions from repository tags and rebuilds docs of the package
+func (m *Packages) Refresh(ctx context.Context, p *models.Package, logf Logf) error {
+	return m.refresh(ctx, p, true, logf)
+}
+
+// RebuildDocs collects readme and exported symbols from the default branch
+func (m *Packages) RebuildDocs(ctx context.Context, p *models.Package, logf Logf) error {
+	return m.refresh(ctx, p, false, logf)
+}
+
+func (m *Packages) refresh(ctx context.Context, p *models.Package, versions bool, logf Logf) error {
+	// other VCS need their own tools, module proxies have no sources to collect
+	if p.VCS != models.VCSGit || !strings.HasPrefix(p.Repo, "http") {
+		return fmt.Errorf("only git repositories over http can be refreshed, %s is %s %s", p.Package, p.VCS, p.Repo)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	// tags need full history, docs only the last commit
+	depth := 1
+	if versions {
+		depth = 0
+	}
+
+	repo, err := m.git.Clone(ctx, p.Repo, depth)
+	if m.checked(ctx, p, err) {
+		m.notifyUnreachable(ctx, p, logf)
+	}
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	dir, ok := moduleDir(ctx, repo, "HEAD", p.Package)
+	if !ok {
+		return fmt.Errorf("go.mod of %s not found on default branch", p.Package)
+	}
+
+	updated := false
+	if versions {
+		if updated, err = m.versions(ctx, repo, p, logf); err != nil {
+			return err
+		}
+	}
+
+	docs, err := m.docs(ctx, repo, p, dir, logf)
+	if err != nil {
+		return err
+	}
+
+	// badges show the latest version, caches are kept when the repository has not changed
+	if updated || docs {
+		m.changed(ctx, p.Package)
+	}
+	return nil
+}
+
+// checked stores whether repository of the package is reachable, dashboard lists unreachable ones.
+// It reports that the repository has just become unreachable
+func (m *Packages) checked(ctx context.Context, p *models.Package, err error) bool {
+	now := time.Now()
+	reachable := p.RepoError == ""
+	p.RepoError, p.CheckedAt = "", &now
+	if err != nil {
+		p.RepoError = err.Error()
+	}
+
+	// refresh continues even if status is not stored, the job log has the error anyway.
+	// The check is not a change of the package, updated_at is kept for caches and feeds
+	m.db.WithContext(context.WithoutCancel(ctx)).Model(&models.Package{}).Where("id = ?", p.ID).UpdateColumns(map[string]interface{}{
+		"repo_error": p.RepoError,
+		"checked_at": now,
+	})
+
+	return reachable && err != nil
+}
+
+// moduleDir finds directory of the module, module with major version suffix
+// may live in the root or in the major version subdirectory, e.g. v2/go.mod
+func moduleDir(ctx context.Context, repo *gitrepo.Repository, rev, pkg string) (string, bool) {
+	dirs := []string{""}
+	if _, major, _ := module.SplitPathVersion(pkg); major != "" {
+		dirs = append(dirs, strings.TrimPrefix(major, "/")+"/")
+	}
+
+	for _, dir := range dirs {
+		data, err := repo.File(ctx, rev, dir+"go.mod")
+		if err == nil && modfile.ModulePath(data) == pkg {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// versions stores semver tags of the repository as versions of the package,
+// it reports whether any version is added, changed or removed
+func (m *Packages) versions(ctx context.Context, repo *gitrepo.Repository, p *models.Package, logf Logf) (bool, error) {
+	refs, err := m.git.Refs(ctx, p.Repo)
+	if err != nil {
+		return false, err
+	}
+
+	_, major, _ := module.SplitPathVersion(p.Package)
+
+	var versions []models.Version
+	dirs := map[string]string{}
+	for tag := range refs.Tags {
+		if !semver.IsValid(tag) || semver.Canonical(tag) != tag || module.CheckPathMajor(tag, major) != nil {
+			continue
+		}
+
+		dir, ok := moduleDir(ctx, repo, tag, p.Package)
+		if !ok {
+			logf("%s: go.mod does not declare %s, skipped", tag, p.Package)
+			continue
+		}
+
+		t, err := repo.Time(ctx, tag)
+		if err != nil {
+			return false, err
+		}
+
+		v := models.Version{
+			PackageID: p.ID,
+			Version:   tag,
+			Time:      t,
+		}
+
+		if data, err := repo.File(ctx, tag, dir+"go.mod"); err == nil {
+			if f, err := modfile.ParseLax("go.mod", data, nil); err == nil && f.Go != nil {
+				v.GoVersion = f.Go.Version
+			}
+		}
+
+		v.License = license(ctx, repo, tag, dir)
+
+		if v.Message, err = repo.TagMessage(ctx, tag); err != nil {
+			return false, err
+		}
+
+		dirs[tag] = dir
+		versions = append(versions, v)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i].Version, versions[j].Version) < 0
+	})
+
+	if p.Commits {
+		if err := changes(ctx, repo, versions, dirs); err != nil {
+			return false, err
+		}
+	}
+
+	var known []string
+	if err := m.db.WithContext(ctx).Model(&models.Version{}).Where("package_id = ?", p.ID).Pluck("version", &known).Error; err != nil {
+		return false, err
+	}
+
+	changed, err := models.SaveVersions(m.db.WithContext(ctx), p.ID, versions)
+	if err != nil {
+		return false, err
+	}
+
+	logf("%s: %d versions", p.Package, len(versions))
+
+	// the first refresh finds all tags, they are not news for the owner
+	if len(known) > 0 {
+		var added []string
+		for _, v := range versions {
+			if !slices.Contains(known, v.Version) {
+				added = append(added, v.Version)
+			}
+		}
+		if len(added) > 0 {
+			m.notifyVersions(ctx, p, added, logf)
+		}
+	}
+
+	return changed, nil
+}
+
+// changes collects subjects of commits between consecutive versions,
+// module in a subdirectory gets only commits changing the subdirectory
+func changes(ctx context.Context, repo *gitrepo.Repository, versions []models.Version, dirs map[string]string) error {
+	for i := range versions {
+		since := ""
+		if i > 0 {
+			since = versions[i-1].Version
+		}
+
+		var paths []string
+		if dir := dirs[versions[i].Version]; dir != "" {
+			paths = append(paths, dir)
+		}
+
+		subjects, err := repo.Subjects(ctx, since, versions[i].Version, maxSubjects, paths...)
+		if err != nil {
+			return err
+		}
+		versions[i].Changes = strings.Join(subjects, "\n")
+	}
+
+	return nil
+}
+
+// licenseFiles are checked in the module directory, then in the repository root
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "COPYING"}
+
+// licenses are recognized by distinctive phrases of their texts, order matters
+var licenses = []struct {
+	id     string
+	phrase string
+}{
+	{"AGPL-3.0", "GNU AFFERO GENERAL PUBLIC LICENSE"},
+	{"LGPL-3.0", "GNU LESSER GENERAL PUBLIC LICENSE"},
+	{"GPL-3.0", "GNU GENERAL PUBLIC LICENSE"},
+	{"Apache-2.0", "Apache License"},
+	{"MPL-2.0", "Mozilla Public License"},
+	{"BSD-3-Clause", "Neither the name"},
+	{"BSD-2-Clause", "Redistribution and use in source and binary forms"},
+	{"ISC", "Permission to use, copy, modify, and/or distribute"},
+	{"MIT", "Permission is hereby granted, free of charge"},
+	{"Unlicense", "This is free and unencumbered software"},
+}
+
+func license(ctx context.Context, repo *gitrepo.Repository, rev, dir string) string {
+	dirs := []string{dir}
+	if dir != "" {
+		dirs = append(dirs, "")
+	}
+
+	for _, d := range dirs {
+		for _, name := range licenseFiles {
+			data, err := repo.File(ctx, rev, d+name)
+			if err != nil {
+				continue
+			}
+
+			text := string(data)
+			for _, l := range licenses {
+				if strings.Contains(text, l.phrase) {
+					return l.id
+				}
+			}
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// docs stores readme and exported symbols of the default branch, it reports whether they are changed
+func (m *Packages) docs(ctx context.Context, repo *gitrepo.Repository, p *models.Package, dir string, logf Logf) (bool, error) {
+	files, err := repo.Files(ctx, "HEAD")
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		readme  string
+		symbols = map[string]bool{}
+		nested  = nestedModules(files, dir)
+	)
+
+	for _, f := range files {
+		rel, ok := strings.CutPrefix(f, dir)
+		if !ok || skipped(rel, nested) {
+			continue
+		}
+
+		switch {
+		case readme == "" && !strings.Contains(rel, "/") && strings.HasPrefix(strings.ToLower(rel), "readme"):
+			data, err := repo.File(ctx, "HEAD", f)
+			if err != nil {
+				return false, err
+			}
+			readme = string(data)
+
+		case strings.HasSuffix(rel, ".go") && !strings.HasSuffix(rel, "_test.go") && len(symbols) < maxSymbols:
+			data, err := repo.File(ctx, "HEAD", f)
+			if err != nil {
+				return false, err
+			}
+			exported(data, symbols)
+		}
+	}
+
+	names := make([]string, 0, len(symbols))
+	for s := range symbols {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	p.Readme = readme
+	p.Symbols = strings.Join(names, " ")
+
+	changed, err := models.SaveDocs(m.db.WithContext(ctx), p.ID, p.Readme, p.Symbols)
+	if err != nil {
+		return false, err
+	}
+
+	logf("%s: readme %d bytes, %d symbols", p.Package, len(readme), len(names))
+	return changed, nil
+}
+
+// nestedModules are directories with own go.mod inside the module, they are separate modules
+func nestedModules(files []string, dir string) []string {
+	var nested []string
+	for _, f := range files {
+		rel, ok := strings.CutPrefix(f, dir)
+		if ok && rel != "go.mod" && path.Base(rel) == "go.mod" {
+			nested = append(nested, path.Dir(rel)+"/")
+		}
+	}
+	return nested
+}
+
+// skipped files are not part of the public API of the module
+func skipped(rel string, nested []string) bool {
+	for _, n := range nested {
+		if strings.HasPrefix(rel, n) {
+			return true
+		}
+	}
+
+	dir := path.Dir(rel)
+	if dir == "." {
+		return false
+	}
+
+	for _, part := range strings.Split(dir, "/") {
+		if part == "testdata" || part == "vendor" || part == "internal" ||
+			strings.HasPrefix(part, ".") || strings.HasPrefix(part, "_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exported adds exported top level names of the go file, methods are added as Type.Method
+func exported(src []byte, symbols map[string]bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil || f.Name.Name == "main" {
+		return
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				symbols[d.Name.Name] = true
+				continue
+			}
+			if recv := receiver(d.Recv.List[0].Type); ast.IsExported(recv) {
+				symbols[recv+"."+d.Name.Name] = true
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						symbols[s.Name.Name] = true
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.IsExported() {
+							symbols[n.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func receiver(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiver(t.X)
+	case *ast.IndexExpr:
+		return receiver(t.X)
+	case *ast.IndexListExpr:
+		return receiver(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
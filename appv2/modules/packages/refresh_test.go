@@ -0,0 +1,83 @@
+package packages
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+)
+
+// pool records statements and fails them, only the sql is checked
+type pool struct {
+	queries []string
+}
+
+var errQuery = errors.New("connection refused")
+
+func (p *pool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errQuery
+}
+
+func (p *pool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.queries = append(p.queries, query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.queries = append(p.queries, query)
+	return nil, errQuery
+}
+
+func (p *pool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestChecked(t *testing.T) {
+	p := &pool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: p}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Packages{db: db}
+
+	tests := []struct {
+		repoError string
+		err       error
+		want      bool
+	}{
+		{"", nil, false},
+		{"", errQuery, true},
+		{"unreachable", errQuery, false},
+		{"unreachable", nil, false},
+	}
+
+	for _, tt := range tests {
+		pkg := &models.Package{Model: gorm.Model{ID: 1}, RepoError: tt.repoError}
+		if got := m.checked(context.Background(), pkg, tt.err); got != tt.want {
+			t.Errorf("%q, %v: got %t, want %t", tt.repoError, tt.err, got, tt.want)
+		}
+		if pkg.CheckedAt == nil || (tt.err == nil) != (pkg.RepoError == "") {
+			t.Errorf("%q, %v: package is not marked %+v", tt.repoError, tt.err, pkg)
+		}
+	}
+
+	for _, q := range p.queries {
+		if !strings.HasPrefix(q, "UPDATE") || strings.Contains(q, "updated_at") {
+			t.Errorf("health check changes the package: %s", q)
+		}
+	}
+	if len(p.queries) != len(tests) {
+		t.Errorf("got %d queries, want %d", len(p.queries), len(tests))
+	}
+}
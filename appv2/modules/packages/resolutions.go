@@ -0,0 +1,50 @@
+package packages
+
+import (
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/relay"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+)
+
+// configureResolutions shows requests of the go command counted by public server,
+// resolutions are read only, permissions deny changing them
+func (m *Packages) configureResolutions(b *presets.Builder) {
+	ma := b.Model(&models.Resolution{}).
+		MenuIcon("mdi-download")
+
+	lb := ma.Listing("Day", "Path", "PackageID", "Count", "UpdatedAt").
+		DefaultOrderBy(relay.Order{Field: "UpdatedAt", Direction: relay.OrderDirectionDesc}).
+		SearchColumns("path")
+
+	lb.Field("Day").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		return Td(Text(obj.(*models.Resolution).Day.Format("2006-01-02")))
+	})
+	lb.Field("PackageID").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+		key := "No"
+		if obj.(*models.Resolution).PackageID != 0 {
+			key = "Yes"
+		}
+		return Td(Text(i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Resolutions", key)))
+	})
+
+	lb.FilterDataFunc(func(ctx *web.EventContext) vx.FilterData {
+		return []*vx.FilterItem{
+			{
+				// resolutions of paths nobody registered are not found
+				Key:          "found",
+				Label:        i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Resolutions", "Found"),
+				ItemType:     vx.ItemTypeSelect,
+				SQLCondition: `resolutions.package_id <> 0`,
+				Options: []*vx.SelectItem{
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Resolutions", "Yes"), Value: "true", SQLCondition: `resolutions.package_id <> 0`},
+					{Text: i18n.PT(ctx.R, presets.ModelsI18nModuleKey, "Resolutions", "No"), Value: "false", SQLCondition: `resolutions.package_id = 0`},
+				},
+			},
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package packages
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+)
+
+// schedulePoll is how often packages are checked for scheduled refresh
+const schedulePoll = time.Minute
+
+// Start refreshes packages not checked for the refresh interval in background,
+// so versions stay fresh and owners get emails about unreachable repositories
+func (m *Packages) Start() {
+	if m.config.RefreshInterval <= 0 {
+		close(m.done)
+		return
+	}
+
+	go func() {
+		defer close(m.done)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ticker := time.NewTicker(schedulePoll)
+		defer ticker.Stop()
+
+		for {
+			m.refreshDue(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop interrupts scheduled refresh, the interrupted package is refreshed after the next interval
+func (m *Packages) Stop(ctx context.Context) error {
+	close(m.stop)
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refreshDue refreshes due packages one by one until none is left
+func (m *Packages) refreshDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		p, err := m.claim(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				m.logger.Error("error on claim package for refresh", zap.Error(err))
+			}
+			return
+		}
+		if p == nil {
+			return
+		}
+
+		logf := func(format string, a ...interface{}) error {
+			m.logger.Debug(fmt.Sprintf(format, a...), zap.String("package", p.Package))
+			return nil
+		}
+		if err := m.Refresh(ctx, p, logf); err != nil && ctx.Err() == nil {
+			m.logger.Warn("scheduled refresh failed", zap.String("package", p.Package), zap.Error(err))
+		}
+	}
+}
+
+// claim takes the due package checked longest ago, nil if no package is due
+func (m *Packages) claim(ctx context.Context) (*models.Package, error) {
+	var p models.Package
+	res := m.db.WithContext(ctx).Raw(models.ClaimRefresh, sql.Named("before", time.Now().Add(-m.config.RefreshInterval))).Scan(&p)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &p, nil
+}
@@ -0,0 +1,190 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qor5/web/v3"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+const validateTimeout = 30 * time.Second
+
+// validate checks package path, repository url scheme of the VCS and that repository is reachable
+// and its go.mod declares the same module path. Only git repositories and module proxies are checked remotely
+func (m *Packages) validate(ctx context.Context, user *umodels.User, p *models.Package) (errs web.ValidationErrors) {
+	if p.VCS == "" {
+		p.VCS = models.VCSGit
+	}
+
+	schemes, ok := models.Schemes[p.VCS]
+	if !ok {
+		errs.FieldError("VCS", fmt.Sprintf("Unknown VCS %s", p.VCS))
+	}
+
+	if p.Repo == "" {
+		errs.FieldError("Repo", "Repository is required")
+	} else if ok {
+		if u, err := url.Parse(p.Repo); err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+			errs.FieldError("Repo", fmt.Sprintf("Repository url of %s must start with %s://", p.VCS, strings.Join(schemes, "://, ")))
+		}
+	}
+
+	if err := module.CheckPath(p.Package); err != nil {
+		errs.FieldError("Package", err.Error())
+	} else if !strings.HasPrefix(p.Package, models.Domain+"/") {
+		errs.FieldError("Package", fmt.Sprintf("Package path must start with %s/", models.Domain))
+	}
+
+	if errs.HaveErrors() {
+		return
+	}
+
+	if user != nil && !user.IsAdmin() {
+		if err := m.owns(user.ID, p); err != nil {
+			errs.FieldError("Package", err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+
+	switch {
+	case p.VCS == models.VCSMod:
+		return m.validateProxy(ctx, p)
+	case p.VCS == models.VCSGit && strings.HasPrefix(p.Repo, "http"):
+		return m.validateGit(ctx, p)
+	}
+
+	return
+}
+
+// owns checks that user may save the package: existing packages must be owned by the user,
+// transferred packages stay with their owner, new paths must be in namespaces of the user
+func (m *Packages) owns(userID uint, p *models.Package) error {
+	// import matches existing packages by path
+	var existing models.Package
+	if p.ID != 0 {
+		existing.ID = p.ID
+		existing.Package = m.path(fmt.Sprint(p.ID))
+	} else if err := m.db.Select("id", "package").Where("package = ?", p.Package).Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	if existing.ID != 0 {
+		owns, err := models.OwnsPackage(m.db, userID, existing.ID)
+		if err != nil {
+			return err
+		}
+		if !owns {
+			return fmt.Errorf("Package %s is not owned by you", existing.Package)
+		}
+		if existing.Namespace() == p.Namespace() {
+			return nil
+		}
+	}
+
+	owns, err := models.Owns(m.db, userID, p.Namespace())
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return fmt.Errorf("Namespace %s is not owned by you", p.Namespace())
+	}
+
+	return nil
+}
+
+// validateProxy checks that module proxy serves the module
+func (m *Packages) validateProxy(ctx context.Context, p *models.Package) (errs web.ValidationErrors) {
+	escaped, err := module.EscapePath(p.Package)
+	if err != nil {
+		errs.FieldError("Package", err.Error())
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.Repo, "/")+"/"+escaped+"/@v/list", nil)
+	if err != nil {
+		errs.FieldError("Repo", err.Error())
+		return
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		errs.FieldError("Repo", fmt.Sprintf("Module proxy is unreachable: %s", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errs.FieldError("Repo", fmt.Sprintf("Module proxy does not serve %s: %s", p.Package, resp.Status))
+	}
+
+	return
+}
+
+// validateGit checks that go.mod on the default branch declares the package path,
+// only smart http repositories can be checked
+func (m *Packages) validateGit(ctx context.Context, p *models.Package) (errs web.ValidationErrors) {
+	refs, err := m.git.Refs(ctx, p.Repo)
+	if err != nil {
+		errs.FieldError("Repo", fmt.Sprintf("Repository is unreachable: %s", err))
+		return
+	}
+
+	if refs.Head == "" {
+		errs.FieldError("Repo", "Repository has no default branch")
+		return
+	}
+
+	// module with major version suffix may live in the root
+	// or in the major version subdirectory, e.g. v2/go.mod
+	files := []string{"go.mod"}
+	_, major, _ := module.SplitPathVersion(p.Package)
+	if major != "" {
+		files = append(files, strings.TrimPrefix(major, "/")+"/go.mod")
+	}
+
+	// only go.mod files are fetched, saving does not wait for the whole repository
+	fetched, err := m.git.Fetch(ctx, p.Repo, files...)
+	if err != nil {
+		errs.FieldError("Repo", fmt.Sprintf("Can not fetch go.mod: %s", err))
+		return
+	}
+
+	var declared []string
+	for _, f := range files {
+		data, ok := fetched[f]
+		if !ok {
+			continue
+		}
+
+		path := modfile.ModulePath(data)
+		if path == p.Package {
+			return
+		}
+		if path != "" {
+			declared = append(declared, fmt.Sprintf("%s declares %s", f, path))
+		}
+	}
+
+	branch := strings.TrimPrefix(refs.Head, "refs/heads/")
+	if len(declared) == 0 {
+		errs.FieldError("Package", fmt.Sprintf("go.mod not found on branch %s", branch))
+		return
+	}
+
+	errs.FieldError("Package", fmt.Sprintf("Module path does not match repository on branch %s: %s", branch, strings.Join(declared, ", ")))
+
+	return
+}
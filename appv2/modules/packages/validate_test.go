@@ -0,0 +1,43 @@
+package packages
+
+import (
+	"context"
+	"testing"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+)
+
+func TestValidateRepo(t *testing.T) {
+	// repositories over http are fetched, the rest is checked without network
+	m := &Packages{}
+
+	tests := []struct {
+		vcs, repo string
+		field     string
+	}{
+		{"", "ssh://git@gitflic.ru/kovardin/example.git", ""},
+		{models.VCSGit, "git+ssh://git@gitflic.ru/kovardin/example.git", ""},
+		{models.VCSGit, "ftp://gitflic.ru/kovardin/example.git", "Repo"},
+		{models.VCSHg, "ssh://hg@hg.example.com/example", ""},
+		{models.VCSHg, "svn://hg.example.com/example", "Repo"},
+		{models.VCSSvn, "svn+ssh://svn.example.com/example", ""},
+		{models.VCSBzr, "bzr://bzr.example.com/example", ""},
+		{models.VCSFossil, "ssh://fossil.example.com/example", "Repo"},
+		{models.VCSMod, "ftp://proxy.example.com", "Repo"},
+		{models.VCSSvn, "svn:///example", "Repo"},
+		{models.VCSHg, "", "Repo"},
+		{"cvs", "https://cvs.example.com/example", "VCS"},
+	}
+
+	for _, tt := range tests {
+		p := &models.Package{Package: models.Domain + "/kovardin/example", VCS: tt.vcs, Repo: tt.repo}
+		errs := m.validate(context.Background(), nil, p)
+
+		if tt.field == "" && errs.HaveErrors() {
+			t.Errorf("%s %s: unexpected errors %s", tt.vcs, tt.repo, describe(&errs))
+		}
+		if tt.field != "" && len(errs.GetFieldErrors(tt.field)) == 0 {
+			t.Errorf("%s %s: no %s error in %q", tt.vcs, tt.repo, tt.field, describe(&errs))
+		}
+	}
+}
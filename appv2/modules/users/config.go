@@ -0,0 +1,47 @@
+package users
+
+import (
+	"errors"
+	"slices"
+)
+
+// DefaultSecret was shared by all deployments, it is allowed only in dev mode
+const DefaultSecret = "wldfkjwdlfjh0"
+
+type Config struct {
+	// Secrets used to sign login sessions. The first one signs new sessions,
+	// the rest are still accepted, so the secret can be rotated without logging everyone out
+	Secrets []string `yaml:"secrets" secret:"true"`
+}
+
+// Validate checks that secrets are configured, dev mode allows empty or default secret
+func (c Config) Validate(dev bool) error {
+	if dev {
+		return nil
+	}
+
+	if len(c.Secrets) == 0 || c.Secrets[0] == "" {
+		return errors.New("login secret is empty")
+	}
+
+	if slices.Contains(c.Secrets, DefaultSecret) {
+		return errors.New("login secret must not be the default one")
+	}
+
+	return nil
+}
+
+func (c Config) secrets() []string {
+	var secrets []string
+	for _, s := range c.Secrets {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+
+	if len(secrets) == 0 {
+		secrets = []string{DefaultSecret}
+	}
+
+	return secrets
+}
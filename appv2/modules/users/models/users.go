@@ -1,15 +1,75 @@
 package models
 
 import (
+	"net/http"
+	"net/mail"
+	"slices"
+
+	"github.com/qor5/admin/v3/role"
 	"github.com/qor5/x/v3/login"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleAdmin      = "admin"
+	RoleMaintainer = "maintainer"
+	RoleViewer     = "viewer"
+)
+
+var DefaultRoles = []string{
+	RoleAdmin,
+	RoleMaintainer,
+	RoleViewer,
+}
+
 type User struct {
 	gorm.Model
 
 	Name string
+	// Email receives invitations and notifications, account may differ from it
+	Email string `gorm:"index"`
 
 	login.UserPass
 	login.SessionSecure
+
+	Roles []role.Role `gorm:"many2many:user_role_join;"`
+}
+
+// GetRoles returns role names, user without roles is a viewer
+func (u *User) GetRoles() (rs []string) {
+	for _, r := range u.Roles {
+		rs = append(rs, r.Name)
+	}
+	if len(rs) == 0 {
+		rs = []string{RoleViewer}
+	}
+	return
+}
+
+func (u *User) HasRole(name string) bool {
+	return slices.Contains(u.GetRoles(), name)
+}
+
+func (u *User) IsAdmin() bool {
+	return u.HasRole(RoleAdmin)
+}
+
+// Address receives emails of the user, account is used when it is an email,
+// empty when the user has no address
+func (u *User) Address() string {
+	for _, a := range []string{u.Email, u.Account} {
+		if _, err := mail.ParseAddress(a); err == nil && a != "" {
+			return a
+		}
+	}
+	return ""
+}
+
+// CurrentUser returns user authorized by login middleware
+func CurrentUser(r *http.Request) *User {
+	u, ok := login.GetCurrentUser(r).(*User)
+	if !ok {
+		return nil
+	}
+	return u
 }
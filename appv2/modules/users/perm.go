@@ -0,0 +1,189 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/ory/ladon"
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/role"
+	"github.com/qor5/x/v3/perm"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	"gorm.io/gorm"
+
+	pmodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// Permission configures role based policies:
+// admins manage everything, maintainers manage packages in their namespaces,
+// viewers can only read
+func (u *Users) Permission(b *presets.Builder) {
+	b.Permission(
+		perm.New().
+			Policies(policies()...).
+			SubjectsFunc(subjects).
+			ContextFunc(u.context).
+			DBPolicy(perm.NewDBPolicy(u.db)),
+	)
+
+	b.Use(role.New(u.db).
+		EditorSubject(models.RoleAdmin).
+		Resources([]*DefaultOptionItem{
+			{Text: "All", Value: "*"},
+			{Text: "Packages", Value: "*:packages:*"},
+			{Text: "Namespaces", Value: "*:namespaces:*"},
+			{Text: "Organizations", Value: "*:organizations:*,*:members:*,*:invitations:*"},
+			{Text: "Transfers", Value: "*:transfers:*"},
+			{Text: "Resolutions", Value: "*:resolutions:*"},
+			{Text: "Users", Value: "*:users:*"},
+			{Text: "Media", Value: "*:media_library:*,*:media_libraries:*"},
+			{Text: "Activity", Value: "*:activity_logs:*"},
+			{Text: "Workers", Value: "*:workers:*"},
+			{Text: "Emails", Value: "*:emails:*"},
+		}))
+}
+
+// policies are checked with owns_package and owns_organization conditions set by context
+func policies() []*perm.PolicyBuilder {
+	return []*perm.PolicyBuilder{
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Allowed).ToDo(perm.Anything).On(perm.Anything),
+		perm.PolicyFor(models.RoleMaintainer, models.RoleViewer).WhoAre(perm.Denied).ToDo(perm.Anything).
+			On("*:users:*", "*:roles:*", "*:media_library:*", "*:media_libraries:*", "*:activity_logs:*", "*:emails:*"),
+		perm.PolicyFor(models.RoleMaintainer).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate, presets.PermDelete).
+			On("*:namespaces:*"),
+		// packages of other namespaces are hidden from listing and can't be opened by id
+		perm.PolicyFor(models.RoleMaintainer).WhoAre(perm.Denied).ToDo(presets.PermGet, presets.PermUpdate, presets.PermDelete).
+			On("*:packages:*").
+			Given(perm.Conditions{
+				"owns_package": &ladon.BooleanCondition{BooleanValue: false},
+			}),
+		// organizations are changed by their owners, members join by invitations
+		perm.PolicyFor(models.RoleMaintainer).WhoAre(perm.Denied).ToDo(presets.PermUpdate, presets.PermDelete).
+			On("*:organizations:*", "*:members:*", "*:invitations:*").
+			Given(perm.Conditions{
+				"owns_organization": &ladon.BooleanCondition{BooleanValue: false},
+			}),
+		perm.PolicyFor(models.RoleMaintainer).WhoAre(perm.Denied).ToDo(presets.PermCreate).
+			On("*:members:*"),
+		// transfers change only by confirmations of both sides
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Denied).ToDo(presets.PermUpdate).
+			On("*:transfers:*"),
+		perm.PolicyFor(models.RoleMaintainer).WhoAre(perm.Denied).ToDo(presets.PermDelete).
+			On("*:transfers:*"),
+		// resolutions are counted by public server only
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate, presets.PermDelete).
+			On("*:resolutions:*"),
+		// emails are queued by the mailer only
+		perm.PolicyFor(perm.Anybody).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate).
+			On("*:emails:*"),
+		perm.PolicyFor(models.RoleViewer).WhoAre(perm.Denied).ToDo(presets.PermCreate, presets.PermUpdate, presets.PermDelete).
+			On(perm.Anything),
+	}
+}
+
+func subjects(r *http.Request) []string {
+	user := models.CurrentUser(r)
+	if user == nil {
+		return nil
+	}
+	return user.GetRoles()
+}
+
+// context tells whether the current user owns checked packages and organizations
+func (u *Users) context(r *http.Request, objs []interface{}) perm.Context {
+	c := make(perm.Context)
+
+	user := models.CurrentUser(r)
+	if user == nil {
+		return c
+	}
+
+	for _, obj := range objs {
+		var org uint
+		switch o := obj.(type) {
+		case *pmodels.Package:
+			if o.ID != 0 {
+				owns, err := pmodels.OwnsPackage(u.db, user.ID, o.ID)
+				c["owns_package"] = err == nil && owns
+			}
+		case *pmodels.Organization:
+			org = o.ID
+		case *pmodels.Member:
+			org = o.OrganizationID
+		case *pmodels.Invitation:
+			org = o.OrganizationID
+		}
+
+		if org != 0 {
+			owns, err := pmodels.Manages(u.db, user.ID, pmodels.Owner{OrganizationID: org}, pmodels.MemberOwner)
+			c["owns_organization"] = err == nil && owns
+		}
+	}
+
+	return c
+}
+
+// Middleware loads roles of the current user, must be used after login middleware
+func (u *Users) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := models.CurrentUser(r)
+		if user != nil {
+			if err := u.db.Model(user).Association("Roles").Find(&user.Roles); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AdminOnly allows handler only for admins, must be used after Middleware
+func (u *Users) AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := models.CurrentUser(r)
+		if user == nil || !user.IsAdmin() {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bootstrap creates default roles and makes the first user admin
+// when there is no admin yet, so existing installations are not locked out
+func (u *Users) bootstrap() error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range models.DefaultRoles {
+			if err := tx.Where(role.Role{Name: name}).FirstOrCreate(&role.Role{}).Error; err != nil {
+				return err
+			}
+		}
+
+		admin := role.Role{}
+		if err := tx.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+			return err
+		}
+
+		var admins int64
+		err := tx.Table("user_role_join").
+			Where("role_id = ?", admin.ID).
+			Count(&admins).Error
+		if err != nil {
+			return err
+		}
+
+		if admins > 0 {
+			return nil
+		}
+
+		first := models.User{}
+		err = tx.Order("id").Limit(1).Find(&first).Error
+		if err != nil || first.ID == 0 {
+			return err
+		}
+
+		return tx.Model(&first).Association("Roles").Append(&admin)
+	})
+}
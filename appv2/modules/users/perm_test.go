@@ -0,0 +1,135 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/role"
+	"github.com/qor5/x/v3/login"
+	"github.com/qor5/x/v3/perm"
+	"gorm.io/gorm"
+
+	pmodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/dbtest"
+)
+
+func request(user *models.User) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	if user == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), login.UserKey, user))
+}
+
+func userWith(id uint, roles ...string) *models.User {
+	u := &models.User{Model: gorm.Model{ID: id}}
+	for _, name := range roles {
+		u.Roles = append(u.Roles, role.Role{Name: name})
+	}
+	return u
+}
+
+func TestPolicies(t *testing.T) {
+	// ownership is given by the test instead of database
+	owns := perm.Context{}
+	verifier := perm.NewVerifier(presets.PermModule, perm.New().
+		Policies(policies()...).
+		SubjectsFunc(subjects).
+		ContextFunc(func(r *http.Request, objs []interface{}) perm.Context {
+			c := perm.Context{}
+			for k, v := range owns {
+				c[k] = v
+			}
+			return c
+		}))
+
+	pkg := &pmodels.Package{Model: gorm.Model{ID: 1}}
+	org := &pmodels.Organization{Model: gorm.Model{ID: 1}}
+
+	tests := []struct {
+		name    string
+		roles   []string
+		action  string
+		obj     interface{}
+		context perm.Context
+		allowed bool
+	}{
+		{"admin updates any package", []string{models.RoleAdmin}, presets.PermUpdate, pkg, perm.Context{"owns_package": false}, true},
+		{"admin edits users", []string{models.RoleAdmin}, presets.PermUpdate, &models.User{Model: gorm.Model{ID: 1}}, nil, true},
+		{"maintainer updates own package", []string{models.RoleMaintainer}, presets.PermUpdate, pkg, perm.Context{"owns_package": true}, true},
+		{"maintainer opens other package", []string{models.RoleMaintainer}, presets.PermGet, pkg, perm.Context{"owns_package": false}, false},
+		{"maintainer deletes other package", []string{models.RoleMaintainer}, presets.PermDelete, pkg, perm.Context{"owns_package": false}, false},
+		{"maintainer lists packages", []string{models.RoleMaintainer}, presets.PermList, &pmodels.Package{}, nil, true},
+		{"maintainer creates package", []string{models.RoleMaintainer}, presets.PermCreate, &pmodels.Package{}, nil, true},
+		{"maintainer lists users", []string{models.RoleMaintainer}, presets.PermList, &models.User{}, nil, false},
+		{"maintainer creates namespace", []string{models.RoleMaintainer}, presets.PermCreate, &pmodels.Namespace{}, nil, false},
+		{"owner updates organization", []string{models.RoleMaintainer}, presets.PermUpdate, org, perm.Context{"owns_organization": true}, true},
+		{"member updates organization", []string{models.RoleMaintainer}, presets.PermUpdate, org, perm.Context{"owns_organization": false}, false},
+		{"maintainer adds member directly", []string{models.RoleMaintainer}, presets.PermCreate, &pmodels.Member{}, nil, false},
+		{"admin updates transfer", []string{models.RoleAdmin}, presets.PermUpdate, &pmodels.Transfer{Model: gorm.Model{ID: 1}}, nil, false},
+		{"maintainer deletes transfer", []string{models.RoleMaintainer}, presets.PermDelete, &pmodels.Transfer{Model: gorm.Model{ID: 1}}, nil, false},
+		{"admin deletes transfer", []string{models.RoleAdmin}, presets.PermDelete, &pmodels.Transfer{Model: gorm.Model{ID: 1}}, nil, true},
+		{"admin updates resolution", []string{models.RoleAdmin}, presets.PermUpdate, &pmodels.Resolution{}, nil, false},
+		{"viewer reads package", []string{models.RoleViewer}, presets.PermGet, pkg, nil, true},
+		{"viewer updates package", []string{models.RoleViewer}, presets.PermUpdate, pkg, nil, false},
+		{"user without roles is viewer", nil, presets.PermCreate, &pmodels.Package{}, nil, false},
+	}
+
+	for _, tt := range tests {
+		owns = tt.context
+		err := verifier.Spawn().Do(tt.action).ObjectOn(tt.obj).WithReq(request(userWith(1, tt.roles...))).IsAllowed()
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: allowed %t, want %t", tt.name, allowed, tt.allowed)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	db := dbtest.Open(t, pmodels.Tables...)
+	u := &Users{db: db}
+
+	// user 1 owns namespace alpha and the organization, user 2 maintains packages of the organization
+	org := pmodels.Organization{Name: "team"}
+	db.Create(&org)
+	db.Create(&[]pmodels.Member{
+		{OrganizationID: org.ID, UserID: 1, Role: pmodels.MemberOwner},
+		{OrganizationID: org.ID, UserID: 2, Role: pmodels.MemberMaintainer},
+	})
+	db.Create(&[]pmodels.Namespace{{Name: "alpha", UserID: 1}, {Name: "team", OrganizationID: org.ID}})
+	own := pmodels.Package{Package: pmodels.Domain + "/alpha/a"}
+	team := pmodels.Package{Package: pmodels.Domain + "/team/b"}
+	db.Create(&own)
+	db.Create(&team)
+
+	tests := []struct {
+		name  string
+		user  uint
+		obj   interface{}
+		key   string
+		owned bool
+	}{
+		{"namespace owner", 1, &own, "owns_package", true},
+		{"other user", 2, &own, "owns_package", false},
+		{"organization member", 2, &team, "owns_package", true},
+		{"organization owner", 1, &org, "owns_organization", true},
+		{"organization maintainer", 2, &org, "owns_organization", false},
+		{"invitation of owned organization", 1, &pmodels.Invitation{OrganizationID: org.ID}, "owns_organization", true},
+		{"member of other organization", 3, &pmodels.Member{OrganizationID: org.ID}, "owns_organization", false},
+	}
+
+	for _, tt := range tests {
+		c := u.context(request(userWith(tt.user, models.RoleMaintainer)), []interface{}{tt.obj})
+		if owned, ok := c[tt.key]; !ok || owned != tt.owned {
+			t.Errorf("%s: %s is %v, want %t", tt.name, tt.key, owned, tt.owned)
+		}
+	}
+
+	// new packages are checked by namespace on save, they have no condition
+	if c := u.context(request(userWith(1)), []interface{}{&pmodels.Package{}}); len(c) != 0 {
+		t.Errorf("unexpected context of new package %v", c)
+	}
+}
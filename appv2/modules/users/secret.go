@@ -0,0 +1,155 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/qor5/x/v3/login"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
+)
+
+// cookies of login sessions, the same settings configure login.Builder and Rotate
+type cookies struct {
+	auth   string
+	secure string
+	config login.CookieConfig
+}
+
+func newCookies() cookies {
+	return cookies{
+		auth:   "auth",
+		secure: "qor5_auth_secure",
+		config: login.CookieConfig{
+			Path:     "/",
+			Secure:   cookieSecure(),
+			SameSite: http.SameSiteLaxMode,
+		},
+	}
+}
+
+// cookieSecure reads CookieSecure env like qor5 login does, false is used for localhost
+func cookieSecure() bool {
+	v, err := strconv.ParseBool(os.Getenv("CookieSecure"))
+	if err != nil {
+		return true
+	}
+	return v
+}
+
+// configure applies cookie settings to the login builder
+func (c cookies) configure(b *login.Builder) {
+	b.AuthCookieName(c.auth).
+		AuthSecureCookieName(c.secure).
+		CookieConfig(c.config)
+}
+
+// Rotate re-signs session cookies issued with older secrets by the newest one,
+// must be used before login middleware
+func (u *Users) Rotate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(u.secrets) > 1 {
+			u.rotate(w, r)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (u *Users) rotate(w http.ResponseWriter, r *http.Request) {
+	auth, err := r.Cookie(u.cookies.auth)
+	if err != nil || auth.Value == "" {
+		return
+	}
+
+	if parse(auth.Value, &login.UserClaims{}, u.secrets[0]) == nil {
+		return
+	}
+
+	for _, old := range u.secrets[1:] {
+		claims := &login.UserClaims{}
+		if parse(auth.Value, claims, old) != nil {
+			continue
+		}
+
+		token, err := sign(claims, u.secrets[0])
+		if err != nil {
+			return
+		}
+
+		values := map[string]string{
+			u.cookies.auth: token,
+		}
+
+		// secure cookie is signed with secret and user salt
+		if secure, err := r.Cookie(u.cookies.secure); err == nil && secure.Value != "" {
+			user := models.User{}
+			if err := u.db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+				return
+			}
+
+			salt := user.GetSecure()
+			base := &jwt.RegisteredClaims{}
+			if parse(secure.Value, base, old+salt) != nil {
+				return
+			}
+
+			token, err := sign(base, u.secrets[0]+salt)
+			if err != nil {
+				return
+			}
+			values[u.cookies.secure] = token
+		}
+
+		u.cookies.replace(w, r, values, claims.ExpiresAt)
+		return
+	}
+}
+
+func parse(val string, claims jwt.Claims, secret string) error {
+	_, err := jwt.ParseWithClaims(val, claims, func(t *jwt.Token) (interface{}, error) {
+		// sessions are signed with HMAC only, other methods would accept forged tokens
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	return err
+}
+
+func sign(claims jwt.Claims, secret string) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// replace sets new values both for the client and for the current request
+func (c cookies) replace(w http.ResponseWriter, r *http.Request, values map[string]string, expires *jwt.NumericDate) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if v, ok := values[cookie.Name]; ok {
+			cookie.Value = v
+		}
+		r.AddCookie(cookie)
+	}
+
+	for name, value := range values {
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     c.config.Path,
+			Domain:   c.config.Domain,
+			HttpOnly: true,
+			Secure:   c.config.Secure,
+			SameSite: c.config.SameSite,
+		}
+		if expires != nil {
+			cookie.Expires = expires.Time
+			cookie.MaxAge = int(time.Until(expires.Time).Seconds())
+		}
+		http.SetCookie(w, cookie)
+	}
+}
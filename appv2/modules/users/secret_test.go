@@ -0,0 +1,76 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/qor5/x/v3/login"
+)
+
+func TestParseRejectsOtherMethods(t *testing.T) {
+	claims := &jwt.RegisteredClaims{Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(none, &jwt.RegisteredClaims{}, "secret"); err == nil {
+		t.Error("token without signature is accepted")
+	}
+
+	signed, err := sign(claims, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(signed, &jwt.RegisteredClaims{}, "secret"); err != nil {
+		t.Errorf("valid token is rejected: %s", err)
+	}
+	if err := parse(signed, &jwt.RegisteredClaims{}, "other"); err == nil {
+		t.Error("token of other secret is accepted")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	u := &Users{
+		secrets: []string{"new", "old"},
+		cookies: cookies{
+			auth:   "session",
+			secure: "session_secure",
+			config: login.CookieConfig{Path: "/admin", Secure: false, SameSite: http.SameSiteStrictMode},
+		},
+	}
+
+	claims := &login.UserClaims{UserID: "1"}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	old, err := sign(claims, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen string
+	h := u.Rotate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := r.Cookie("session")
+		seen = c.Value
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: old})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if err := parse(seen, &login.UserClaims{}, "new"); err != nil {
+		t.Fatalf("request cookie is not re-signed: %s", err)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != "session" || c.Value != seen || c.Path != "/admin" || c.Secure || c.SameSite != http.SameSiteStrictMode || !c.HttpOnly {
+		t.Errorf("cookie does not follow login settings: %+v", c)
+	}
+}
@@ -1,25 +1,49 @@
 package users
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
 	plogin "github.com/qor5/admin/v3/login"
 	"github.com/qor5/admin/v3/presets"
+	"github.com/qor5/admin/v3/presets/gorm2op"
+	"github.com/qor5/admin/v3/role"
 	"github.com/qor5/web/v3"
+	"github.com/qor5/x/v3/i18n"
 	"github.com/qor5/x/v3/login"
+	"github.com/qor5/x/v3/perm"
+	. "github.com/qor5/x/v3/ui/vuetify"
+	vx "github.com/qor5/x/v3/ui/vuetifyx"
 	. "github.com/theplant/htmlgo"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
 	"gorm.io/gorm"
 
+	"gohome.4gophers.ru/getapp/gohome/appv2/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/audit"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer"
 	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
 )
 
-var loginSecret = "wldfkjwdlfjh0"
-
 type Users struct {
-	db *gorm.DB
+	db      *gorm.DB
+	audit   *audit.Audit
+	mailer  *mailer.Mailer
+	logger  *zap.Logger
+	secrets []string
+	cookies cookies
 }
 
-func New(db *gorm.DB) *Users {
+func New(db *gorm.DB, config Config, audit *audit.Audit, mailer *mailer.Mailer, logger *zap.Logger) *Users {
 	return &Users{
-		db: db,
+		db:      db,
+		audit:   audit,
+		mailer:  mailer,
+		logger:  logger,
+		secrets: config.secrets(),
+		cookies: newCookies(),
 	}
 }
 
@@ -27,23 +51,75 @@ func (u *Users) Auth(pb *presets.Builder) *login.Builder {
 	lb := plogin.New(pb).
 		DB(u.db).
 		UserModel(&models.User{}).
-		Secret(loginSecret).
-		TOTP(false)
+		Secret(u.secrets[0]).
+		TOTP(false).
+		AfterConfirmSendResetPasswordLink(func(r *http.Request, user interface{}, extraVals ...interface{}) error {
+			link, _ := extraVals[0].(string)
+			return u.sendResetPasswordLink(r, user.(*models.User), link, requestLanguage(pb.GetI18n(), r))
+		})
+	u.cookies.configure(lb)
 
 	pb.ProfileFunc(func(ctx *web.EventContext) HTMLComponent {
-		return A(Text("Выход")).Href(lb.LogoutURL).Style("margin-left:100px")
+		msgr := i18n.MustGetModuleMessages(ctx.R, plogin.I18nAdminLoginKey, plogin.Messages_en_US).(*plogin.Messages)
+		return Div(
+			locales.Switcher(pb, ctx),
+			A(Text(msgr.Logout)).Href(lb.LogoutURL).Style("margin-left:100px"),
+		)
 	})
 
 	return lb
 }
 
+// sendResetPasswordLink queues the email with the link, users without address
+// see the same page as unknown accounts, so accounts can not be probed
+func (u *Users) sendResetPasswordLink(r *http.Request, user *models.User, link string, lang language.Tag) error {
+	to := user.Address()
+	if to == "" {
+		u.logger.Warn("reset password link is not sent, user has no email", zap.Uint("user", user.ID))
+		return nil
+	}
+
+	return u.mailer.Send(r.Context(), to, lang, mailer.TemplateResetPassword, mailer.ResetPassword{
+		Name: user.Name,
+		Link: link,
+	})
+}
+
+// requestLanguage is chosen like admin language: by the switcher cookie, then by Accept-Language,
+// the send link handler of login is not wrapped by i18n middleware
+func requestLanguage(ib *i18n.Builder, r *http.Request) language.Tag {
+	tag, _ := language.MatchStrings(language.NewMatcher(ib.GetSupportLanguages()), ib.GetCurrentLangFromCookie(r), r.Header.Get("Accept-Language"))
+	return tag
+}
+
 func (u *Users) Configure(b *presets.Builder) {
 	m := b.Model(&models.User{}).
 		MenuIcon("mdi-account-multiple")
-	// Label("Пользователи")
+	defer u.audit.Register(m, "Password", "SessionSecure", "ResetPasswordToken", "TOTPSecret", "LastUsedTOTPCode")
+
+	m.Listing("ID", "Name", "Account", "Email")
+	m.Detailing("Name", "Account", "Email", "Roles").
+		Field("Roles").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var roles []role.Role
+			u.db.Model(obj).Association("Roles").Find(&roles)
 
-	m.Editing("Name", "Account", "Password").Field("Password").
+			var names []string
+			for _, r := range roles {
+				names = append(names, r.Name)
+			}
+
+			return vx.VXReadonlyField().Label(field.Label).Value(strings.Join(names, ", "))
+		})
+
+	ed := m.Editing("Name", "Account", "Email", "Password", "Roles")
+
+	ed.Field("Password").
 		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+			if current := models.CurrentUser(ctx.R); current == nil || !current.IsAdmin() {
+				return perm.PermissionDenied
+			}
+
 			u := obj.(*models.User)
 			if v := ctx.R.FormValue(field.Name); v != "" {
 				u.Password = v
@@ -51,13 +127,82 @@ func (u *Users) Configure(b *presets.Builder) {
 			}
 			return nil
 		})
+
+	ed.Field("Roles").
+		ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) HTMLComponent {
+			var values []string
+			if user, ok := obj.(*models.User); ok && user.ID != 0 {
+				var roles []role.Role
+				u.db.Model(user).Association("Roles").Find(&roles)
+				for _, r := range roles {
+					values = append(values, fmt.Sprint(r.ID))
+				}
+			}
+
+			var roles []role.Role
+			u.db.Find(&roles)
+			items := []DefaultOptionItem{}
+			for _, r := range roles {
+				items = append(items, DefaultOptionItem{
+					Text:  r.Name,
+					Value: fmt.Sprint(r.ID),
+				})
+			}
+
+			return vx.VXSelect().Label(field.Label).Chips(true).
+				Items(items).ItemTitle("text").ItemValue("value").
+				Multiple(true).Attr(presets.VFieldError(field.Name, values, field.Errors)...).
+				Disabled(field.Disabled)
+		}).
+		SetterFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) (err error) {
+			if current := models.CurrentUser(ctx.R); current == nil || !current.IsAdmin() {
+				return perm.PermissionDenied
+			}
+
+			user := obj.(*models.User)
+			var roles []role.Role
+			for _, id := range ctx.R.Form[field.Name] {
+				rid, err := strconv.Atoi(id)
+				if err != nil {
+					continue
+				}
+				roles = append(roles, role.Role{
+					Model: gorm.Model{ID: uint(rid)},
+				})
+			}
+			user.Roles = roles
+			return nil
+		})
+
+	ed.WrapSaveFunc(func(in presets.SaveFunc) presets.SaveFunc {
+		return func(obj interface{}, id string, ctx *web.EventContext) (err error) {
+			user := obj.(*models.User)
+			return u.db.Transaction(func(tx *gorm.DB) error {
+				ctx.WithContextValue(gorm2op.CtxKeyDB{}, tx)
+				defer ctx.WithContextValue(gorm2op.CtxKeyDB{}, nil)
+
+				if id != "" {
+					if err := tx.Model(user).Association("Roles").Replace(user.Roles); err != nil {
+						return err
+					}
+				}
+				return in(obj, id, ctx)
+			})
+		}
+	})
 }
 
 func (u *Users) Migrate() {
 	err := u.db.AutoMigrate(
 		&models.User{},
+		&role.Role{},
+		&perm.DefaultDBPolicy{},
 	)
 	if err != nil {
 		panic(err)
 	}
+
+	if err := u.bootstrap(); err != nil {
+		panic(err)
+	}
 }
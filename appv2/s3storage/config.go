@@ -1,21 +1,43 @@
 package s3storage
 
-import "github.com/aws/aws-sdk-go/aws"
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
 
 // Config S3 client config
 type Config struct {
 	AccessID         string `yaml:"accessId"`
-	AccessKey        string `yaml:"accessKey"`
+	AccessKey        string `yaml:"accessKey" secret:"true"`
 	Region           string `yaml:"region"`
 	Bucket           string `yaml:"bucket"`
-	SessionToken     string `yaml:"sessionToken"`
+	SessionToken     string `yaml:"sessionToken" secret:"true"`
 	ACL              string `yaml:"acl"`
 	Endpoint         string `yaml:"endpoint"`
 	S3Endpoint       string `yaml:"s3Endpoint"`
 	S3ForcePathStyle bool   `yaml:"s3ForcePathStyle"`
 	CacheControl     string `yaml:"cacheControl"`
+	// PartSize in bytes for multipart upload, minimum and default is 5MB
+	PartSize int64 `yaml:"partSize"`
+	// Concurrency number of parts uploaded in parallel
+	Concurrency int `yaml:"concurrency"`
 
 	AWSConfig *aws.Config
 
 	RoleARN string `yaml:"roleArn"`
 }
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Bucket == "" {
+		errs = append(errs, errors.New("bucket is required"))
+	}
+	if c.Region == "" {
+		errs = append(errs, errors.New("region is required"))
+	}
+	if c.PartSize < 0 || c.Concurrency < 0 {
+		errs = append(errs, errors.New("partSize and concurrency must not be negative"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package s3storage
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+// logging passes request id to S3 and logs every api call
+func logging(handlers *request.Handlers, l *logger.Logger) {
+	handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "logging.RequestID",
+		Fn: func(r *request.Request) {
+			if id := logger.RequestID(r.Context()); id != "" {
+				r.HTTPRequest.Header.Set(logger.RequestIDHeader, id)
+			}
+		},
+	})
+
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "logging.Complete",
+		Fn: func(r *request.Request) {
+			fields := []zap.Field{
+				zap.String("operation", r.Operation.Name),
+				zap.Int("retries", r.RetryCount),
+				zap.Duration("duration", time.Since(r.Time)),
+			}
+
+			log := logger.For(r.Context(), l)
+			if r.Error != nil {
+				log.Warn("s3 call failed", append(fields, zap.Error(r.Error))...)
+				return
+			}
+			log.Debug("s3 call", fields...)
+		},
+	})
+}
@@ -1,10 +1,11 @@
 package s3storage
 
 import (
-	"bytes"
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"iter"
 	"mime"
 	"net/http"
 	"net/url"
@@ -19,17 +20,22 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/qor5/x/v3/oss"
+	"go.opentelemetry.io/otel/trace"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
 )
 
 // Client S3 storage
 type Client struct {
-	S3     *s3.S3
-	Config Config
+	S3       *s3.S3
+	Uploader *s3manager.Uploader
+	Config   Config
 }
 
 // New initialize S3 storage
-func New(config Config) *Client {
+func New(config Config, tp trace.TracerProvider, l *logger.Logger) *Client {
 	if config.ACL == "" {
 		config.ACL = "public-read"
 	}
@@ -55,26 +61,57 @@ func New(config Config) *Client {
 	)
 	sess := session.Must(session.NewSession(awsConfig))
 	client.S3 = s3.New(sess)
+	instrument(&client.S3.Handlers, tp, config.Bucket)
+	logging(&client.S3.Handlers, l)
+
+	// large objects are uploaded by parts, memory usage is limited by PartSize * Concurrency
+	client.Uploader = s3manager.NewUploaderWithClient(client.S3, func(u *s3manager.Uploader) {
+		if config.PartSize > 0 {
+			u.PartSize = config.PartSize
+		}
+		if config.Concurrency > 0 {
+			u.Concurrency = config.Concurrency
+		}
+	})
 
 	return client
 }
 
-// Get receive file with given path
-func (client Client) Get(ctx context.Context, path string) (file *os.File, err error) {
+// Ping checks that bucket exists and is accessible with configured credentials
+func (client Client) Ping(ctx context.Context) error {
+	_, err := client.S3.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(client.Config.Bucket),
+	})
+	return err
+}
+
+// Get receive file with given path, file is spooled into temporary directory.
+// Prefer GetStream or GetRange for large objects
+func (client Client) Get(ctx context.Context, path string) (*os.File, error) {
 	readCloser, err := client.GetStream(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer readCloser.Close()
 
 	ext := filepath.Ext(path)
 	pattern := fmt.Sprintf("s3*%s", ext)
 
-	if err == nil {
-		if file, err = os.CreateTemp("/tmp", pattern); err == nil {
-			defer readCloser.Close()
-			_, err = io.Copy(file, readCloser)
-			file.Seek(0, 0)
-		}
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = io.Copy(file, readCloser); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
 	}
 
-	return file, err
+	return file, nil
 }
 
 // GetStream get file as stream
@@ -90,36 +127,60 @@ func (client Client) GetStream(ctx context.Context, path string) (io.ReadCloser,
 	return getResponse.Body, err
 }
 
-// Put store a reader into given path
+// GetRange get part of the file as stream, negative length means up to the end of file
+func (client Client) GetRange(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	// http range can't be empty, bytes=N-(N-1) is invalid
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+
+	getResponse, err := client.S3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(client.Config.Bucket),
+		Key:    aws.String(client.ToS3Key(path)),
+		Range:  aws.String(rng),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return getResponse.Body, nil
+}
+
+// Put store a reader into given path. Reader is streamed to S3,
+// objects larger than part size are uploaded with multipart upload
 func (client Client) Put(ctx context.Context, urlPath string, reader io.Reader) (*oss.Object, error) {
 	if seeker, ok := reader.(io.ReadSeeker); ok {
 		seeker.Seek(0, 0)
 	}
 
 	urlPath = client.ToS3Key(urlPath)
-	buffer, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, err
-	}
+
+	body := bufio.NewReaderSize(reader, 512)
 
 	fileType := mime.TypeByExtension(path.Ext(urlPath))
 	if fileType == "" {
-		fileType = http.DetectContentType(buffer)
+		// error means that file is shorter than 512 bytes, detect by what we have
+		head, _ := body.Peek(512)
+		fileType = http.DetectContentType(head)
 	}
 
-	params := &s3.PutObjectInput{
-		Bucket:        aws.String(client.Config.Bucket), // required
-		Key:           aws.String(urlPath),              // required
-		ACL:           aws.String(client.Config.ACL),
-		Body:          bytes.NewReader(buffer),
-		ContentLength: aws.Int64(int64(len(buffer))),
-		ContentType:   aws.String(fileType),
+	params := &s3manager.UploadInput{
+		Bucket:      aws.String(client.Config.Bucket), // required
+		Key:         aws.String(urlPath),              // required
+		ACL:         aws.String(client.Config.ACL),
+		Body:        body,
+		ContentType: aws.String(fileType),
 	}
 	if client.Config.CacheControl != "" {
 		params.CacheControl = aws.String(client.Config.CacheControl)
 	}
 
-	_, err = client.S3.PutObjectWithContext(ctx, params)
+	_, err := client.Uploader.UploadWithContext(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -168,29 +229,50 @@ func (client Client) DeleteObjects(ctx context.Context, paths []string) (err err
 // List list all objects under current path
 func (client Client) List(ctx context.Context, path string) ([]*oss.Object, error) {
 	var objects []*oss.Object
-	var prefix string
 
-	if path != "" {
-		prefix = strings.Trim(path, "/") + "/"
+	for object, err := range client.Objects(ctx, path) {
+		if err != nil {
+			return objects, err
+		}
+		objects = append(objects, object)
 	}
 
-	listObjectsResponse, err := client.S3.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
-		Bucket: aws.String(client.Config.Bucket),
-		Prefix: aws.String(prefix),
-	})
+	return objects, nil
+}
 
-	if err == nil {
-		for _, content := range listObjectsResponse.Contents {
-			objects = append(objects, &oss.Object{
-				Path:             "/" + client.ToS3Key(*content.Key),
-				Name:             filepath.Base(*content.Key),
-				LastModified:     content.LastModified,
-				StorageInterface: client,
-			})
+// Objects iterate over all objects under current path, pages are requested lazily
+func (client Client) Objects(ctx context.Context, path string) iter.Seq2[*oss.Object, error] {
+	return func(yield func(*oss.Object, error) bool) {
+		var prefix string
+
+		if path != "" {
+			prefix = strings.Trim(path, "/") + "/"
 		}
-	}
 
-	return objects, err
+		stopped := false
+		err := client.S3.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket: aws.String(client.Config.Bucket),
+			Prefix: aws.String(prefix),
+		}, func(page *s3.ListObjectsV2Output, last bool) bool {
+			for _, content := range page.Contents {
+				object := &oss.Object{
+					Path:             "/" + client.ToS3Key(*content.Key),
+					Name:             filepath.Base(*content.Key),
+					LastModified:     content.LastModified,
+					StorageInterface: client,
+				}
+				if !yield(object, nil) {
+					stopped = true
+					return false
+				}
+			}
+			return true
+		})
+
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
 }
 
 // GetEndpoint get endpoint, FileSystem's endpoint is /
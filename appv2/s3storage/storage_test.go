@@ -0,0 +1,313 @@
+package s3storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+const bucket = "test"
+
+// fakeS3 is in-process S3 compatible server with path style addressing,
+// it supports objects, ranges, listing v2 and multipart uploads
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	// calls counts requests by operation, e.g. "ListObjectsV2"
+	calls map[string]int
+	// pageSize limits keys in one listing page like max-keys of S3
+	pageSize int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *Client) {
+	f := &fakeS3{
+		objects:  map[string][]byte{},
+		uploads:  map[string]map[int][]byte{},
+		calls:    map[string]int{},
+		pageSize: 1000,
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	client := New(Config{
+		AccessID:         "id",
+		AccessKey:        "key",
+		Region:           "ru-central1",
+		Bucket:           bucket,
+		S3Endpoint:       srv.URL,
+		S3ForcePathStyle: true,
+	}, noop.NewTracerProvider(), zap.NewNop())
+
+	return f, client
+}
+
+func (f *fakeS3) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[op]
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+bucket), "/")
+	q := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && q.Get("list-type") == "2":
+		f.calls["ListObjectsV2"]++
+		f.list(w, q.Get("prefix"), q.Get("continuation-token"))
+	case r.Method == http.MethodGet:
+		f.calls["GetObject"]++
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		f.calls["CreateMultipartUpload"]++
+		id := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, bucket, key, id)
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		f.calls["UploadPart"]++
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		data, _ := io.ReadAll(r.Body)
+		f.uploads[q.Get("uploadId")][n] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, n))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		f.calls["CompleteMultipartUpload"]++
+		parts := f.uploads[q.Get("uploadId")]
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, n := range numbers {
+			data = append(data, parts[n]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, q.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"done"</ETag></CompleteMultipartUploadResult>`, bucket, key)
+	case r.Method == http.MethodPut:
+		f.calls["PutObject"]++
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		w.Header().Set("ETag", `"put"`)
+	case r.Method == http.MethodDelete:
+		f.calls["DeleteObject"]++
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+type listResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	NextContinuationToken string `xml:",omitempty"`
+	Contents              []listContent
+}
+
+type listContent struct {
+	Key          string
+	LastModified string
+	Size         int
+}
+
+// list pages keys in order, continuation token is the last key of the previous page
+func (f *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) && k > token {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	res := listResult{Name: bucket, Prefix: prefix, MaxKeys: f.pageSize}
+	if len(keys) > f.pageSize {
+		keys = keys[:f.pageSize]
+		res.IsTruncated = true
+		res.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		res.Contents = append(res.Contents, listContent{
+			Key:          k,
+			LastModified: "2024-01-01T00:00:00.000Z",
+			Size:         len(f.objects[k]),
+		})
+	}
+	res.KeyCount = len(res.Contents)
+
+	xml.NewEncoder(w).Encode(res)
+}
+
+func TestPutMultipart(t *testing.T) {
+	f, client := newFakeS3(t)
+	ctx := context.Background()
+
+	// default part size is 5MB, the object needs two parts
+	data := bytes.Repeat([]byte("0123456789"), 600*1024)
+	if _, err := client.Put(ctx, "/media/big.bin", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := f.count("UploadPart"); n != 2 {
+		t.Errorf("expected 2 parts, got %d", n)
+	}
+	if n := f.count("PutObject"); n != 0 {
+		t.Errorf("expected no single part upload, got %d", n)
+	}
+	if !bytes.Equal(f.objects["media/big.bin"], data) {
+		t.Errorf("uploaded object differs, got %d bytes of %d", len(f.objects["media/big.bin"]), len(data))
+	}
+}
+
+func TestPutSmall(t *testing.T) {
+	f, client := newFakeS3(t)
+
+	if _, err := client.Put(context.Background(), "/media/small.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := f.count("PutObject"); n != 1 {
+		t.Errorf("expected single part upload, got %d", n)
+	}
+	if got := string(f.objects["media/small.txt"]); got != "hello" {
+		t.Errorf("unexpected object %q", got)
+	}
+}
+
+func TestObjectsPagination(t *testing.T) {
+	f, client := newFakeS3(t)
+	for i := 0; i < 2500; i++ {
+		f.objects[fmt.Sprintf("media/%04d.txt", i)] = []byte("x")
+	}
+	f.objects["other/skip.txt"] = []byte("x")
+
+	objects, err := client.List(context.Background(), "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2500 {
+		t.Fatalf("expected 2500 objects, got %d", len(objects))
+	}
+	if objects[0].Path != "/media/0000.txt" || objects[2499].Path != "/media/2499.txt" {
+		t.Errorf("unexpected objects %s ... %s", objects[0].Path, objects[2499].Path)
+	}
+	if n := f.count("ListObjectsV2"); n != 3 {
+		t.Errorf("expected 3 pages, got %d", n)
+	}
+}
+
+func TestObjectsBreak(t *testing.T) {
+	f, client := newFakeS3(t)
+	for i := 0; i < 2500; i++ {
+		f.objects[fmt.Sprintf("media/%04d.txt", i)] = []byte("x")
+	}
+
+	seen := 0
+	for object, err := range client.Objects(context.Background(), "media") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if object == nil {
+			t.Fatal("nil object without error")
+		}
+		seen++
+		if seen == 10 {
+			break
+		}
+	}
+
+	if seen != 10 {
+		t.Errorf("expected 10 objects, got %d", seen)
+	}
+	// next pages are not requested after break
+	if n := f.count("ListObjectsV2"); n != 1 {
+		t.Errorf("expected 1 page, got %d", n)
+	}
+}
+
+func TestGetRange(t *testing.T) {
+	f, client := newFakeS3(t)
+	f.objects["media/file.txt"] = []byte("0123456789")
+	ctx := context.Background()
+
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 4, "0123"},
+		{3, 2, "34"},
+		{6, -1, "6789"},
+		{8, 10, "89"},
+		{5, 0, ""},
+	}
+
+	for _, tt := range tests {
+		rc, err := client.GetRange(ctx, "/media/file.txt", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("range %d+%d: %s", tt.offset, tt.length, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("range %d+%d: got %q, want %q", tt.offset, tt.length, got, tt.want)
+		}
+	}
+
+	// empty range is answered without request
+	if n := f.count("GetObject"); n != len(tests)-1 {
+		t.Errorf("expected %d requests, got %d", len(tests)-1, n)
+	}
+}
+
+func TestGetStream(t *testing.T) {
+	f, client := newFakeS3(t)
+	f.objects["media/file.txt"] = []byte("content")
+
+	rc, err := client.GetStream(context.Background(), "/media/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	got, _ := io.ReadAll(rc)
+	if string(got) != "content" {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	if _, err := client.GetStream(context.Background(), "/media/missing.txt"); err == nil {
+		t.Error("expected error for missing object")
+	}
+}
@@ -0,0 +1,37 @@
+package s3storage
+
+import (
+	"github.com/aws/aws-sdk-go/aws/request"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
+)
+
+// instrument creates span for every S3 api call, retries are included in one span
+func instrument(handlers *request.Handlers, tp trace.TracerProvider, bucket string) {
+	tracer := tp.Tracer("gohome.4gophers.ru/getapp/gohome/appv2/s3storage")
+
+	handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "tracing.Start",
+		Fn: func(r *request.Request) {
+			ctx, _ := tracer.Start(r.Context(), "S3."+r.Operation.Name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.RPCSystemKey.String("aws-api"),
+					semconv.RPCService("S3"),
+					semconv.RPCMethod(r.Operation.Name),
+					semconv.AWSS3Bucket(bucket),
+				),
+			)
+			r.SetContext(ctx)
+		},
+	})
+
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "tracing.End",
+		Fn: func(r *request.Request) {
+			tracing.End(trace.SpanFromContext(r.Context()), r.Error)
+		},
+	})
+}
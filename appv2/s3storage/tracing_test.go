@@ -0,0 +1,67 @@
+package s3storage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestTracing(t *testing.T) {
+	f, client := newFakeS3(t)
+	f.objects["media/file.txt"] = []byte("content")
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client = New(client.Config, tp, zap.NewNop())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+	rc, err := client.GetStream(ctx, "/media/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+
+	if _, err := client.GetStream(ctx, "/media/missing.txt"); err == nil {
+		t.Fatal("expected error for missing object")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	for i, s := range spans[:2] {
+		if s.Name != "S3.GetObject" {
+			t.Errorf("span %d: unexpected name %q", i, s.Name)
+		}
+		if s.SpanKind != trace.SpanKindClient {
+			t.Errorf("span %d: unexpected kind %v", i, s.SpanKind)
+		}
+		if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %d: not a child of request span", i)
+		}
+
+		attrs := map[string]string{}
+		for _, a := range s.Attributes {
+			attrs[string(a.Key)] = a.Value.Emit()
+		}
+		if attrs[string(semconv.AWSS3BucketKey)] != bucket || attrs[string(semconv.RPCMethodKey)] != "GetObject" {
+			t.Errorf("span %d: unexpected attributes %v", i, attrs)
+		}
+	}
+
+	if spans[0].Status.Code == codes.Error {
+		t.Error("successful call has error status")
+	}
+	if spans[1].Status.Code != codes.Error || len(spans[1].Events) == 0 {
+		t.Error("failed call has no recorded error")
+	}
+}
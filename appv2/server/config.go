@@ -1,5 +1,14 @@
 package server
 
+import "errors"
+
 type Config struct {
 	Port string `yaml:"port"`
 }
+
+func (c Config) Validate() error {
+	if c.Port == "" {
+		return errors.New("port is required")
+	}
+	return nil
+}
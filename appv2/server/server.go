@@ -7,15 +7,28 @@ import (
 
 	"github.com/qor5/admin/v3/presets"
 	"github.com/qor5/x/v3/login"
+	"github.com/qor5/x/v3/oss"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/health"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
 type Server struct {
-	config Config
-	logger *zap.Logger
-	lb     *login.Builder
-	pb     *presets.Builder
-	srv    *http.Server
+	config  Config
+	logger  *zap.Logger
+	lb      *login.Builder
+	pb      *presets.Builder
+	storage oss.StorageInterface
+	health  *health.Health
+	tp      trace.TracerProvider
+	srv     *http.Server
+
+	before      []func(http.Handler) http.Handler
+	middlewares []func(http.Handler) http.Handler
+	handlers    map[string]http.Handler
 }
 
 func New(
@@ -23,24 +36,73 @@ func New(
 	logger *zap.Logger,
 	lb *login.Builder,
 	pb *presets.Builder,
+	storage oss.StorageInterface,
+	health *health.Health,
+	tp trace.TracerProvider,
 ) *Server {
 	return &Server{
-		config: config,
-		logger: logger,
-		lb:     lb,
-		pb:     pb,
+		config:   config,
+		logger:   logger,
+		lb:       lb,
+		pb:       pb,
+		storage:  storage,
+		health:   health,
+		tp:       tp,
+		handlers: map[string]http.Handler{},
 	}
 }
 
+// Handle adds handler served behind login middleware
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.handlers[pattern] = handler
+}
+
+// Use adds middlewares applied after login middleware, so they have access to current user
+func (s *Server) Use(middlewares ...func(http.Handler) http.Handler) {
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// UseBefore adds middlewares applied before login middleware, e.g. to prepare auth cookies
+func (s *Server) UseBefore(middlewares ...func(http.Handler) http.Handler) {
+	s.before = append(s.before, middlewares...)
+}
+
 func (s *Server) Serve() error {
 	s.logger.Info("app server", zap.String("host", "http://localhost"+s.config.Port+"/admin"))
 
 	mux := http.NewServeMux()
 	mux.Handle("/", s.pb)
 
+	// local storages serve uploaded files by themselves
+	if h, ok := s.storage.(http.Handler); ok {
+		endpoint := s.storage.GetEndpoint(context.Background())
+		s.logger.Info("serve media", zap.String("endpoint", endpoint))
+		mux.Handle(endpoint+"/", h)
+	}
+
+	for pattern, h := range s.handlers {
+		mux.Handle(pattern, h)
+	}
+
 	s.lb.Mount(mux)
 
-	http.Handle("/", s.lb.Middleware()(mux))
+	// middlewares below pass copies of the request, route pattern is passed to tracing through context
+	var handler http.Handler = tracing.Routes(mux)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+
+	handler = s.lb.Middleware()(handler)
+	for i := len(s.before) - 1; i >= 0; i-- {
+		handler = s.before[i](handler)
+	}
+
+	handler = logger.Middleware(s.logger)(handler)
+	handler = tracing.Middleware(s.tp)(handler)
+	handler = logger.RequestIDMiddleware(handler)
+
+	// probes stay open without login
+	http.Handle("/", s.health.Middleware(handler))
 	// http.Handle("/", mux)
 
 	s.srv = &http.Server{Addr: s.config.Port}
@@ -0,0 +1,32 @@
+package storage
+
+import "fmt"
+
+const (
+	TypeS3         = "s3"
+	TypeFilesystem = "filesystem"
+	TypeMemory     = "memory"
+)
+
+type Config struct {
+	// Type is one of s3, filesystem or memory, s3 is used by default
+	Type string `yaml:"type"`
+	// Path is base directory for filesystem storage
+	Path string `yaml:"path"`
+	// Endpoint is url prefix files are served from by admin server
+	// for filesystem and memory storages
+	Endpoint string `yaml:"endpoint"`
+}
+
+func (c Config) Validate() error {
+	switch c.Type {
+	case "", TypeS3, TypeFilesystem, TypeMemory:
+		return nil
+	}
+	return fmt.Errorf("unknown type %q, must be one of %s, %s, %s", c.Type, TypeS3, TypeFilesystem, TypeMemory)
+}
+
+// S3 reports whether s3 storage is used
+func (c Config) S3() bool {
+	return c.Type == "" || c.Type == TypeS3
+}
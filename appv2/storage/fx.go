@@ -0,0 +1,10 @@
+package storage
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"storage",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/v3/oss"
+)
+
+// Memory keeps files in memory, content is lost on restart
+type Memory struct {
+	endpoint string
+
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data     []byte
+	modified time.Time
+}
+
+func NewMemory(endpoint string) *Memory {
+	return &Memory{
+		endpoint: endpoint,
+		files:    map[string]memoryFile{},
+	}
+}
+
+func (m *Memory) key(p string) string {
+	return path.Clean("/" + p)
+}
+
+func (m *Memory) file(p string) (memoryFile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[m.key(p)]
+	if !ok {
+		return memoryFile{}, os.ErrNotExist
+	}
+
+	return f, nil
+}
+
+// Get receive file with given path
+func (m *Memory) Get(ctx context.Context, p string) (*os.File, error) {
+	f, err := m.file(p)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "memory*"+filepath.Ext(p))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tmp.Write(f.data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+// GetStream get file as stream
+func (m *Memory) GetStream(ctx context.Context, p string) (io.ReadCloser, error) {
+	f, err := m.file(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// Put store a reader into given path
+func (m *Memory) Put(ctx context.Context, p string, reader io.Reader) (*oss.Object, error) {
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		seeker.Seek(0, io.SeekStart)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	m.mu.Lock()
+	m.files[m.key(p)] = memoryFile{data: data, modified: now}
+	m.mu.Unlock()
+
+	return &oss.Object{
+		Path:             p,
+		Name:             filepath.Base(p),
+		LastModified:     &now,
+		StorageInterface: m,
+	}, nil
+}
+
+// Delete delete file
+func (m *Memory) Delete(ctx context.Context, p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, m.key(p))
+	return nil
+}
+
+// List list all objects under current path
+func (m *Memory) List(ctx context.Context, p string) ([]*oss.Object, error) {
+	prefix := strings.TrimSuffix(m.key(p), "/") + "/"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var objects []*oss.Object
+	for k, f := range m.files {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		modified := f.modified
+		objects = append(objects, &oss.Object{
+			Path:             k,
+			Name:             path.Base(k),
+			LastModified:     &modified,
+			StorageInterface: m,
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Path < objects[j].Path
+	})
+
+	return objects, nil
+}
+
+// GetURL get public accessible URL
+func (m *Memory) GetURL(ctx context.Context, p string) (string, error) {
+	return p, nil
+}
+
+// GetEndpoint media urls are prefixed with endpoint
+func (m *Memory) GetEndpoint(ctx context.Context) string {
+	return m.endpoint
+}
+
+func (m *Memory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, err := m.file(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeContent(w, r, path.Base(r.URL.Path), f.modified, bytes.NewReader(f.data))
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/qor5/x/v3/oss"
+	"github.com/qor5/x/v3/oss/filesystem"
+	"go.opentelemetry.io/otel/trace"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/s3storage"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+const (
+	defaultPath     = "public"
+	defaultEndpoint = "/media"
+)
+
+// New build storage by configured type
+func New(config Config, s3config s3storage.Config, tp trace.TracerProvider, l *logger.Logger) (oss.StorageInterface, error) {
+	if config.Path == "" {
+		config.Path = defaultPath
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = defaultEndpoint
+	}
+	config.Endpoint = "/" + strings.Trim(config.Endpoint, "/")
+
+	switch config.Type {
+	case "", TypeS3:
+		return s3storage.New(s3config, tp, l), nil
+	case TypeFilesystem:
+		fsys := filesystem.New(config.Path)
+		return &FileSystem{
+			FileSystem: fsys,
+			endpoint:   config.Endpoint,
+			handler:    http.FileServer(files{http.Dir(fsys.Base)}),
+		}, nil
+	case TypeMemory:
+		return NewMemory(config.Endpoint), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage type %q", config.Type)
+}
+
+// FileSystem local storage served by admin server
+type FileSystem struct {
+	*filesystem.FileSystem
+
+	endpoint string
+	handler  http.Handler
+}
+
+// GetEndpoint media urls are prefixed with endpoint, so files
+// are stored and served under the same path
+func (fs *FileSystem) GetEndpoint(ctx context.Context) string {
+	return fs.endpoint
+}
+
+func (fs *FileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.handler.ServeHTTP(w, r)
+}
+
+// files serves only regular files, directories are not listed
+type files struct {
+	http.FileSystem
+}
+
+func (f files) Open(name string) (http.File, error) {
+	file, err := f.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fs.ErrNotExist
+	}
+
+	return file, nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qor5/x/v3/oss"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/appv2/s3storage"
+)
+
+func newStorage(t *testing.T, config Config) oss.StorageInterface {
+	s, err := New(config, s3storage.Config{}, noop.NewTracerProvider(), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func get(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		config Config
+		check  func(oss.StorageInterface) bool
+	}{
+		{Config{}, func(s oss.StorageInterface) bool { _, ok := s.(*s3storage.Client); return ok }},
+		{Config{Type: TypeS3}, func(s oss.StorageInterface) bool { _, ok := s.(*s3storage.Client); return ok }},
+		{Config{Type: TypeFilesystem, Path: t.TempDir()}, func(s oss.StorageInterface) bool { _, ok := s.(*FileSystem); return ok }},
+		{Config{Type: TypeMemory}, func(s oss.StorageInterface) bool { _, ok := s.(*Memory); return ok }},
+	}
+
+	for _, tt := range tests {
+		if s := newStorage(t, tt.config); !tt.check(s) {
+			t.Errorf("%q: unexpected storage %T", tt.config.Type, s)
+		}
+	}
+
+	if _, err := New(Config{Type: "ftp"}, s3storage.Config{}, noop.NewTracerProvider(), zap.NewNop()); err == nil {
+		t.Error("unknown type is accepted")
+	}
+
+	// local storages serve files under normalized endpoint
+	for endpoint, want := range map[string]string{"": "/media", "files/": "/files", "/a/b/": "/a/b"} {
+		s := newStorage(t, Config{Type: TypeMemory, Endpoint: endpoint})
+		if got := s.GetEndpoint(context.Background()); got != want {
+			t.Errorf("endpoint %q: got %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory("/media")
+
+	for _, p := range []string{"/media/b.txt", "media/a.txt", "/media/dir/c.txt", "/other/d.txt"} {
+		if _, err := m.Put(ctx, p, strings.NewReader("content of "+p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rc, err := m.GetStream(ctx, "/media/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	if string(data) != "content of media/a.txt" {
+		t.Errorf("unexpected stream %q", data)
+	}
+
+	f, err := m.Get(ctx, "/media/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(f)
+	f.Close()
+	if string(data) != "content of /media/b.txt" {
+		t.Errorf("unexpected file %q", data)
+	}
+
+	objects, err := m.List(ctx, "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, o := range objects {
+		paths = append(paths, o.Path)
+	}
+	if got := strings.Join(paths, " "); got != "/media/a.txt /media/b.txt /media/dir/c.txt" {
+		t.Errorf("unexpected listing %s", got)
+	}
+
+	if err := m.Delete(ctx, "/media/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetStream(ctx, "/media/a.txt"); err == nil {
+		t.Error("deleted file is found")
+	}
+}
+
+func TestMemoryServe(t *testing.T) {
+	m := NewMemory("/media")
+	m.Put(context.Background(), "/media/file.txt", strings.NewReader("0123456789"))
+
+	if w := get(m, "/media/file.txt"); w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if w := get(m, "/media/file.txt", "Range", "bytes=2-4"); w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Errorf("range: got %d %q", w.Code, w.Body.String())
+	}
+	if w := get(m, "/media/missing.txt"); w.Code != http.StatusNotFound {
+		t.Errorf("missing: got %d", w.Code)
+	}
+}
+
+func TestFileSystemServe(t *testing.T) {
+	s := newStorage(t, Config{Type: TypeFilesystem, Path: t.TempDir()})
+	if _, err := s.Put(context.Background(), "/media/dir/file.txt", strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+	h := s.(http.Handler)
+
+	if w := get(h, "/media/dir/file.txt"); w.Code != http.StatusOK || w.Body.String() != "content" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	// directories are not listed
+	for _, p := range []string{"/media/dir/", "/media/", "/"} {
+		if w := get(h, p); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d %q", p, w.Code, w.Body.String())
+		}
+	}
+	if w := get(h, "/media/missing.txt"); w.Code != http.StatusNotFound {
+		t.Errorf("missing: got %d", w.Code)
+	}
+}
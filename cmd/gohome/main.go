@@ -1,15 +1,30 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 
 	"gohome.4gophers.ru/getapp/gohome/app/config"
+	"gohome.4gophers.ru/getapp/gohome/app/export"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/badge"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/changelog"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/doc"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/feed"
 	"gohome.4gophers.ru/getapp/gohome/app/handlers/home"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/search"
+	"gohome.4gophers.ru/getapp/gohome/app/handlers/sitemap"
+	"gohome.4gophers.ru/getapp/gohome/app/locales"
 	"gohome.4gophers.ru/getapp/gohome/app/server"
+	"gohome.4gophers.ru/getapp/gohome/appv2/database"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
+	"gohome.4gophers.ru/getapp/gohome/pkg/health"
 	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
 func main() {
@@ -34,33 +49,79 @@ func main() {
 				Aliases: []string{"s"},
 				Usage:   "start service http server",
 				Action: func(ctx *cli.Context) error {
-					setup(ctx).Run()
+					setup(ctx, fx.Invoke(func(s *server.Server) {})).Run()
 
 					return nil
 				},
 			},
+			&cli.Command{
+				Name:  "export-static",
+				Usage: "render pages of packages into directory served by nginx",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "out",
+						Value: "./static",
+						Usage: "output directory",
+					},
+					&cli.BoolFlag{
+						Name:  "full",
+						Usage: "render all packages, not only changed since the previous export",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					var exporter *export.Exporter
+					app := setup(ctx, export.Export, fx.Populate(&exporter))
+					if err := app.Start(ctx.Context); err != nil {
+						return err
+					}
+					defer app.Stop(context.Background())
+
+					return exporter.Export(ctx.Context, ctx.String("out"), ctx.Bool("full"))
+				},
+			},
 		},
 	}
 
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func setup(c *cli.Context) *fx.App {
+func setup(c *cli.Context, options ...fx.Option) *fx.App {
 	env := c.String("env")
 	cfg := c.String("configs")
 
 	opts := []fx.Option{}
 	opts = append(opts, home.Home)
+	opts = append(opts, badge.Badge)
+	opts = append(opts, search.Search)
+	opts = append(opts, sitemap.Sitemap)
+	opts = append(opts, changelog.Changelog)
+	opts = append(opts, doc.Doc)
+	opts = append(opts, feed.Feed)
 	opts = append(opts, fx.Provide(
 		func() config.Config {
 			return config.New(env, cfg)
 		},
 		server.New,
 		logger.New,
+		database.New,
+		database.NewListener,
+		health.New,
+		tracing.New,
+		locales.New,
 	))
-	opts = append(opts, fx.Invoke(func(s *server.Server) {}))
+	opts = append(opts, fx.Invoke(checks))
+	opts = append(opts, options...)
 
 	return fx.New(
 		opts...,
 	)
 }
+
+// checks public server depends on, schema is migrated by admin
+func checks(h *health.Health, db *gorm.DB) {
+	h.Add("postgres", health.Database(db))
+	h.Add("migrations", health.Migrations(db, models.Tables...))
+}
@@ -2,29 +2,63 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/qor5/admin/v3/presets"
 	"github.com/qor5/admin/v3/presets/gorm2op"
-	"github.com/qor5/web/v3"
 	"github.com/qor5/x/v3/login"
-	. "github.com/qor5/x/v3/ui/vuetify"
-	. "github.com/theplant/htmlgo"
+	"github.com/qor5/x/v3/oss"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"gohome.4gophers.ru/getapp/gohome/appv2/config"
 	"gohome.4gophers.ru/getapp/gohome/appv2/database"
-	"gohome.4gophers.ru/getapp/gohome/appv2/logger"
+	"gohome.4gophers.ru/getapp/gohome/appv2/locales"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/audit"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/dashboard"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/jobs"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer"
+	mmodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/mailer/models"
 	"gohome.4gophers.ru/getapp/gohome/appv2/modules/media"
+	"gohome.4gophers.ru/getapp/gohome/appv2/modules/organizations"
 	"gohome.4gophers.ru/getapp/gohome/appv2/modules/packages"
+	pmodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/packages/models"
 	"gohome.4gophers.ru/getapp/gohome/appv2/modules/users"
-	"gohome.4gophers.ru/getapp/gohome/appv2/s3storage"
+	umodels "gohome.4gophers.ru/getapp/gohome/appv2/modules/users/models"
 	"gohome.4gophers.ru/getapp/gohome/appv2/server"
+	"gohome.4gophers.ru/getapp/gohome/appv2/storage"
+	"gohome.4gophers.ru/getapp/gohome/pkg/gitrepo"
+	"gohome.4gophers.ru/getapp/gohome/pkg/health"
+	"gohome.4gophers.ru/getapp/gohome/pkg/httpcache"
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+	"gohome.4gophers.ru/getapp/gohome/pkg/tracing"
 )
 
 func main() {
+	opts, args, err := config.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		if err := command(opts, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fx.New(
+		fx.Supply(opts),
 		fx.Provide(
 			func() prometheus.Registerer {
 				// default prometheus
@@ -35,12 +69,21 @@ func main() {
 		database.Module,
 		logger.Module,
 		server.Module,
-		s3storage.Module,
+		storage.Module,
+		gitrepo.Module,
+		health.Module,
+		tracing.Module,
+		httpcache.Module,
 
 		// modules
 		media.Module,
+		audit.Module,
+		jobs.Module,
+		mailer.Module,
 		users.Module,
 		packages.Module,
+		organizations.Module,
+		dashboard.Module,
 
 		fx.Provide(
 			configure,
@@ -48,22 +91,69 @@ func main() {
 		),
 		fx.Invoke(
 			migrate,
+			checks,
 			serve,
 		),
 	).Run()
 }
 
-func serve(lc fx.Lifecycle, srv *server.Server) {
+func command(opts config.Options, args []string) error {
+	switch cmd := strings.Join(args, " "); cmd {
+	case "config print":
+		cfg, err := config.New(opts)
+		if err != nil {
+			return err
+		}
+		return cfg.Print(os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func serve(
+	lc fx.Lifecycle,
+	srv *server.Server,
+	users *users.Users,
+	audit *audit.Audit,
+	jobs *jobs.Jobs,
+	mailer *mailer.Mailer,
+	packages *packages.Packages,
+	orgs *organizations.Organizations,
+	level zap.AtomicLevel,
+) {
+	srv.UseBefore(users.Rotate)
+	srv.Use(users.Middleware)
+	srv.Handle("/admin/log-level", users.AdminOnly(level))
+	srv.Handle(organizations.APIPrefix, orgs.API())
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			audit.Start()
+			jobs.Start()
+			mailer.Start()
+			packages.Start()
 			return srv.Serve()
 		},
 		OnStop: func(ctx context.Context) error {
-			return srv.Shutdown(ctx)
+			// requests may enqueue jobs and emails, so they are finished first,
+			// every module is stopped even if another one fails
+			err := srv.Shutdown(ctx)
+			audit.Stop()
+			return errors.Join(err, jobs.Stop(ctx), mailer.Stop(ctx), packages.Stop(ctx))
 		},
 	})
 }
 
+func checks(h *health.Health, db *gorm.DB, storage oss.StorageInterface) {
+	h.Add("postgres", health.Database(db))
+	h.Add("migrations", health.Migrations(db, append([]any{&umodels.User{}, &mmodels.Email{}}, pmodels.Tables...)...))
+
+	// only remote storages can be unavailable
+	if p, ok := storage.(health.Pinger); ok {
+		h.Add("storage", p.Ping)
+	}
+}
+
 func auth(users *users.Users, pb *presets.Builder) *login.Builder {
 	return users.Auth(pb)
 }
@@ -71,35 +161,54 @@ func auth(users *users.Users, pb *presets.Builder) *login.Builder {
 func configure(
 	db *gorm.DB,
 	media *media.Media,
+	audit *audit.Audit,
+	jobs *jobs.Jobs,
+	mailer *mailer.Mailer,
 	users *users.Users,
 	packages *packages.Packages,
+	organizations *organizations.Organizations,
+	dashboard *dashboard.Dashboard,
 ) *presets.Builder {
 	b := presets.New()
 
-	// Set up the project name, ORM and Homepage
+	// Set up the project name and ORM, home page is the dashboard
 	b.URIPrefix("/admin").
 		BrandTitle("GoHome").
-		DataOperator(gorm2op.DataOperator(db)).
-		HomePageFunc(func(ctx *web.EventContext) (r web.PageResponse, err error) {
-			r.Body = VContainer(
-				H1("GoHome"),
-				P().Text("Система управления пакетами"))
-			return
-		})
+		DataOperator(gorm2op.DataOperator(db))
+
+	locales.Configure(b)
+	users.Permission(b)
 
 	media.Configure(b)
 	users.Configure(b)
 	packages.Configure(b)
+	organizations.Configure(b)
+	audit.Configure(b)
+	jobs.Configure(b)
+	mailer.Configure(b)
+	dashboard.Configure(b)
 
 	b.MenuOrder(
 		"media-library",
 		"users",
+		"roles",
+		"organizations",
+		"members",
+		"invitations",
+		"transfers",
+		"activity-logs",
+		"workers",
+		"emails",
+		"resolutions",
 	)
 
 	return b
 }
 
-func migrate(users *users.Users, packages *packages.Packages) {
+func migrate(users *users.Users, packages *packages.Packages, audit *audit.Audit, jobs *jobs.Jobs, mailer *mailer.Mailer) {
 	users.Migrate()
 	packages.Migrate()
+	audit.Migrate()
+	jobs.Migrate()
+	mailer.Migrate()
 }
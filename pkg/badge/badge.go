@@ -0,0 +1,95 @@
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"unicode"
+)
+
+const (
+	ColorBlue      = "#007ec6"
+	ColorGreen     = "#97ca00"
+	ColorOrange    = "#fe7d37"
+	ColorLightgrey = "#9f9f9f"
+
+	labelColor = "#555"
+	height     = 20
+	padding    = 5
+)
+
+// Badge is a shields.io style flat badge
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// Unknown badge is rendered when there is no data for the label
+func Unknown(label string) Badge {
+	return Badge{
+		Label:   label,
+		Message: "unknown",
+		Color:   ColorLightgrey,
+	}
+}
+
+// SVG render badge as svg image
+func (b Badge) SVG() []byte {
+	color := b.Color
+	if color == "" {
+		color = ColorLightgrey
+	}
+
+	lw := textWidth(b.Label) + 2*padding
+	mw := textWidth(b.Message) + 2*padding
+	w := lw + mw
+
+	label := html.EscapeString(b.Label)
+	message := html.EscapeString(b.Message)
+
+	// text is rendered with scale(.1) so coordinates are multiplied by 10
+	lx := lw * 10 / 2
+	mx := lw*10 + mw*10/2
+
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s: %s">`, w, height, label, message)
+	fmt.Fprintf(&buf, `<title>%s: %s</title>`, label, message)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&buf, `<clipPath id="r"><rect width="%d" height="%d" rx="3" fill="#fff"/></clipPath>`, w, height)
+	fmt.Fprintf(&buf, `<g clip-path="url(#r)"><rect width="%d" height="%d" fill="%s"/><rect x="%d" width="%d" height="%d" fill="%s"/><rect width="%d" height="%d" fill="url(#s)"/></g>`,
+		lw, height, labelColor, lw, mw, height, html.EscapeString(color), w, height)
+	buf.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">`)
+	fmt.Fprintf(&buf, `<text aria-hidden="true" x="%d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`, lx, (lw-2*padding)*10, label)
+	fmt.Fprintf(&buf, `<text x="%d" y="140" transform="scale(.1)" fill="#fff" textLength="%d">%s</text>`, lx, (lw-2*padding)*10, label)
+	fmt.Fprintf(&buf, `<text aria-hidden="true" x="%d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`, mx, (mw-2*padding)*10, message)
+	fmt.Fprintf(&buf, `<text x="%d" y="140" transform="scale(.1)" fill="#fff" textLength="%d">%s</text>`, mx, (mw-2*padding)*10, message)
+	buf.WriteString(`</g></svg>`)
+
+	return buf.Bytes()
+}
+
+// textWidth approximates width of the text in Verdana 11px
+func textWidth(s string) int {
+	w := 0.0
+	for _, r := range s {
+		switch {
+		case r == ' ':
+			w += 3.9
+		case r == 'i' || r == 'l' || r == 'j' || r == '.' || r == ',' || r == ':' || r == ';' || r == '!' || r == '|' || r == '\'':
+			w += 3.5
+		case r == 'f' || r == 't' || r == 'r' || r == '/' || r == '(' || r == ')' || r == '-':
+			w += 4.6
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			w += 10.2
+		case unicode.IsUpper(r):
+			w += 7.6
+		case unicode.IsDigit(r):
+			w += 7.0
+		default:
+			w += 6.6
+		}
+	}
+
+	return int(w + 0.5)
+}
@@ -0,0 +1,10 @@
+package gitrepo
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"gitrepo",
+	fx.Provide(
+		New,
+	),
+)
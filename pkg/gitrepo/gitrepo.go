@@ -0,0 +1,311 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotSmartHTTP = errors.New("repository does not support git smart http")
+
+// Client works with remote git repositories over smart http
+type Client struct {
+	http *http.Client
+}
+
+func New() *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Refs remote references advertised by the repository
+type Refs struct {
+	// Head is the default branch, e.g. refs/heads/master
+	Head     string
+	Branches map[string]string
+	// Tags point to commits, annotated tags are peeled
+	Tags map[string]string
+}
+
+// Refs checks that repository answers git smart http and returns its references
+func (c *Client) Refs(ctx context.Context, repo string) (*Refs, error) {
+	u := strings.TrimSuffix(repo, "/") + "/info/refs?service=git-upload-pack"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-git-upload-pack-advertisement") {
+		return nil, ErrNotSmartHTTP
+	}
+
+	return parseRefs(resp.Body)
+}
+
+func parseRefs(r io.Reader) (*Refs, error) {
+	refs := &Refs{
+		Branches: map[string]string{},
+		Tags:     map[string]string{},
+	}
+
+	br := bufio.NewReader(r)
+
+	// first section is "# service=git-upload-pack" terminated by flush packet
+	for {
+		line, flush, err := readPktLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if flush {
+			break
+		}
+		if !strings.HasPrefix(line, "# service=") {
+			return nil, ErrNotSmartHTTP
+		}
+	}
+
+	first := true
+	for {
+		line, flush, err := readPktLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if flush {
+			break
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if first {
+			first = false
+			if i := strings.IndexByte(line, 0); i >= 0 {
+				for _, c := range strings.Fields(line[i+1:]) {
+					if v, ok := strings.CutPrefix(c, "symref=HEAD:"); ok {
+						refs.Head = v
+					}
+				}
+				line = line[:i]
+			}
+		}
+
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			refs.Branches[strings.TrimPrefix(name, "refs/heads/")] = hash
+		case strings.HasPrefix(name, "refs/tags/"):
+			tag := strings.TrimPrefix(name, "refs/tags/")
+			if peeled, ok := strings.CutSuffix(tag, "^{}"); ok {
+				refs.Tags[peeled] = hash
+			} else if _, ok := refs.Tags[tag]; !ok {
+				refs.Tags[tag] = hash
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+func readPktLine(r *bufio.Reader) (string, bool, error) {
+	size := make([]byte, 4)
+	if _, err := io.ReadFull(r, size); err != nil {
+		return "", false, err
+	}
+
+	n, err := strconv.ParseUint(string(size), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid pkt-line length %q", size)
+	}
+
+	if n == 0 {
+		return "", true, nil
+	}
+	if n < 4 {
+		return "", false, fmt.Errorf("invalid pkt-line length %q", size)
+	}
+
+	data := make([]byte, n-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", false, err
+	}
+
+	return string(data), false, nil
+}
+
+// Repository is a local bare clone of the remote repository
+type Repository struct {
+	dir string
+}
+
+// Clone makes bare clone of the repository into temporary directory,
+// depth 0 means full history. Repository must be closed after use.
+// Blobs are fetched with the clone, partial clone would fetch every file read separately
+func (c *Client) Clone(ctx context.Context, repo string, depth int) (*Repository, error) {
+	dir, err := os.MkdirTemp("", "gohome-git-*")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"clone", "--bare", "--quiet"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, "--", repo, dir)
+
+	if _, err := git(ctx, "", args...); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &Repository{dir: dir}, nil
+}
+
+// Fetch reads files of the default branch without full clone: shallow clone has only
+// commits and trees, blobs of the files are fetched when they are read. Missing files are omitted
+func (c *Client) Fetch(ctx context.Context, repo string, paths ...string) (map[string][]byte, error) {
+	dir, err := os.MkdirTemp("", "gohome-git-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := git(ctx, "", "clone", "--bare", "--quiet", "--depth", "1", "--filter=blob:none", "--", repo, dir); err != nil {
+		return nil, err
+	}
+
+	r := &Repository{dir: dir}
+	files := map[string][]byte{}
+	for _, path := range paths {
+		if _, err := git(ctx, dir, "cat-file", "-e", "HEAD:"+path); err != nil {
+			continue
+		}
+		data, err := r.File(ctx, "HEAD", path)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = data
+	}
+
+	return files, nil
+}
+
+// File returns content of the file at given revision
+func (r *Repository) File(ctx context.Context, rev, path string) ([]byte, error) {
+	return git(ctx, r.dir, "show", rev+":"+path)
+}
+
+// Time returns committer time of the revision
+func (r *Repository) Time(ctx context.Context, rev string) (time.Time, error) {
+	out, err := git(ctx, r.dir, "log", "-1", "--format=%cI", rev, "--")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
+
+// TagMessage returns message of the annotated tag without signature,
+// lightweight tags have no message
+func (r *Repository) TagMessage(ctx context.Context, tag string) (string, error) {
+	out, err := git(ctx, r.dir, "for-each-ref", "--format=%(objecttype)%00%(contents:subject)%00%(contents:body)", "refs/tags/"+tag)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(string(out), "\n"), "\x00", 3)
+	if len(parts) < 3 || parts[0] != "tag" {
+		return "", nil
+	}
+
+	return strings.TrimSpace(parts[1] + "\n\n" + parts[2]), nil
+}
+
+// Subjects returns subjects of commits reachable from rev but not from since, newest first.
+// Empty since means all history of rev, paths limit commits to the ones changing them.
+// Merge commits are skipped, at most limit subjects are returned
+func (r *Repository) Subjects(ctx context.Context, since, rev string, limit int, paths ...string) ([]string, error) {
+	rng := rev
+	if since != "" {
+		rng = since + ".." + rev
+	}
+
+	args := []string{"log", "--no-merges", "--format=%s", "-n", strconv.Itoa(limit), rng, "--"}
+	out, err := git(ctx, r.dir, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []string
+	for _, s := range strings.Split(string(out), "\n") {
+		if s = strings.TrimSpace(s); s != "" {
+			subjects = append(subjects, s)
+		}
+	}
+	return subjects, nil
+}
+
+// Files lists paths of all files at the revision
+func (r *Repository) Files(ctx context.Context, rev string) ([]string, error) {
+	out, err := git(ctx, r.dir, "ls-tree", "-r", "--name-only", "-z", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Close removes local clone
+func (r *Repository) Close() error {
+	return os.RemoveAll(r.dir)
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+
+	return stdout.Bytes(), nil
+}
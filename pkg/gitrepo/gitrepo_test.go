@@ -0,0 +1,157 @@
+package gitrepo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func pkt(lines ...string) string {
+	var b strings.Builder
+	for _, l := range lines {
+		if l == "" {
+			b.WriteString("0000")
+			continue
+		}
+		fmt.Fprintf(&b, "%04x%s", len(l)+4, l)
+	}
+	return b.String()
+}
+
+func TestReadPktLine(t *testing.T) {
+	tests := []struct {
+		in    string
+		line  string
+		flush bool
+		err   bool
+	}{
+		{in: "000ahello\n", line: "hello\n"},
+		{in: "0004", line: ""},
+		{in: "0000", flush: true},
+		{in: "00", err: true},
+		{in: "zzzz", err: true},
+		{in: "0003", err: true},
+		{in: "0010short", err: true},
+	}
+
+	for _, tt := range tests {
+		line, flush, err := readPktLine(bufio.NewReader(strings.NewReader(tt.in)))
+		if (err != nil) != tt.err {
+			t.Errorf("%q: unexpected error %v", tt.in, err)
+			continue
+		}
+		if line != tt.line || flush != tt.flush {
+			t.Errorf("%q: got %q flush %t, want %q flush %t", tt.in, line, flush, tt.line, tt.flush)
+		}
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	in := pkt(
+		"# service=git-upload-pack\n", "",
+		"aaa HEAD\x00multi_ack symref=HEAD:refs/heads/main agent=git/2.40\n",
+		"aaa refs/heads/main\n",
+		"bbb refs/heads/dev\n",
+		"ccc refs/tags/v1.0.0\n",
+		"ddd refs/tags/v1.1.0\n",
+		"eee refs/tags/v1.1.0^{}\n",
+		"fff refs/tags/v1.2.0^{}\n",
+		"ggg refs/tags/v1.2.0\n",
+		"hhh refs/pull/1/head\n",
+		"",
+	)
+
+	refs, err := parseRefs(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refs.Head != "refs/heads/main" {
+		t.Errorf("unexpected head %q", refs.Head)
+	}
+	if want := map[string]string{"main": "aaa", "dev": "bbb"}; !maps.Equal(refs.Branches, want) {
+		t.Errorf("branches: got %v, want %v", refs.Branches, want)
+	}
+	// annotated tags point to commits
+	if want := map[string]string{"v1.0.0": "ccc", "v1.1.0": "eee", "v1.2.0": "fff"}; !maps.Equal(refs.Tags, want) {
+		t.Errorf("tags: got %v, want %v", refs.Tags, want)
+	}
+}
+
+func TestParseRefsEmpty(t *testing.T) {
+	refs, err := parseRefs(strings.NewReader(pkt("# service=git-upload-pack\n", "", "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs.Head != "" || len(refs.Branches) != 0 || len(refs.Tags) != 0 {
+		t.Errorf("unexpected refs %+v", refs)
+	}
+}
+
+func TestParseRefsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want error
+	}{
+		{"dumb http", "aaa\trefs/heads/main\n", nil},
+		{"no service", pkt("aaa refs/heads/main\n", ""), ErrNotSmartHTTP},
+		{"truncated", pkt("# service=git-upload-pack\n", "", "aaa refs/heads/main\n"), nil},
+	}
+
+	for _, tt := range tests {
+		_, err := parseRefs(strings.NewReader(tt.in))
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	src := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", args[0], out)
+		}
+	}
+
+	run("init", "--quiet")
+	run("config", "uploadpack.allowFilter", "true")
+	if err := os.MkdirAll(filepath.Join(src, "v2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(src, "go.mod"), []byte("module example.com/m\n"), 0o644)
+	os.WriteFile(filepath.Join(src, "v2", "go.mod"), []byte("module example.com/m/v2\n"), 0o644)
+	os.WriteFile(filepath.Join(src, "big.bin"), make([]byte, 1<<20), 0o644)
+	run("add", ".")
+	run("commit", "--quiet", "-m", "init")
+
+	files, err := New().Fetch(context.Background(), "file://"+src, "go.mod", "v2/go.mod", "v3/go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(files["go.mod"]) != "module example.com/m\n" || string(files["v2/go.mod"]) != "module example.com/m/v2\n" {
+		t.Errorf("unexpected files %q", files)
+	}
+	if _, ok := files["v3/go.mod"]; ok {
+		t.Error("missing file is returned")
+	}
+}
@@ -0,0 +1,10 @@
+package health
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"health",
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qor5/x/v3/healthz"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+const (
+	// LivePath answers while the process is running, dependencies are not checked
+	LivePath = healthz.Path
+	// ReadyPath answers when all dependencies are available
+	ReadyPath = "/readyz"
+
+	checkTimeout = 5 * time.Second
+	// migrationsTTL limits how often pending migrations are checked again,
+	// schema does not go back, so up to date schema is not checked again
+	migrationsTTL = time.Minute
+)
+
+var (
+	serving    = grpc_health_v1.HealthCheckResponse_SERVING.String()
+	notServing = grpc_health_v1.HealthCheckResponse_NOT_SERVING.String()
+)
+
+// Check returns error when dependency is not available
+type Check func(ctx context.Context) error
+
+// Pinger is implemented by dependencies that can check themselves, e.g. storages
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Response struct {
+	Status string                    `json:"status"`
+	Checks map[string]*CheckResponse `json:"checks"`
+}
+
+type CheckResponse struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type named struct {
+	name  string
+	check Check
+}
+
+// Health serves liveness and readiness probes
+type Health struct {
+	mu     sync.RWMutex
+	checks []named
+}
+
+func New() *Health {
+	return &Health{}
+}
+
+// Add registers dependency check used by readiness probe
+func (h *Health) Add(name string, check Check) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, named{name: name, check: check})
+	return h
+}
+
+// Middleware intercepts probe requests before any other middleware, e.g. auth
+func (h *Health) Middleware(next http.Handler) http.Handler {
+	live := healthz.HTTPMiddleware()(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimSpace(r.URL.Path) == ReadyPath {
+			h.Ready(w, r)
+			return
+		}
+
+		live.ServeHTTP(w, r)
+	})
+}
+
+// Ready runs all checks concurrently and reports status of every dependency
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	resp := &Response{
+		Status: serving,
+		Checks: make(map[string]*CheckResponse, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.check(ctx)
+
+			cr := &CheckResponse{
+				Status:   serving,
+				Duration: time.Since(start).String(),
+			}
+			if err != nil {
+				cr.Status = notServing
+				cr.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[c.name] = cr
+			if err != nil {
+				resp.Status = notServing
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if resp.Status != serving {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Database checks connectivity through gorm connection pool
+func Database(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Migrations checks that tables and columns of models exist, so schema is not behind the code.
+// Schema is read from the database once it is up to date, probes do not query it every time
+func Migrations(db *gorm.DB, models ...any) Check {
+	return cached(migrations(db, models...), migrationsTTL)
+}
+
+// cached keeps success of the check forever and failure for ttl, concurrent probes wait for one check
+func cached(check Check, ttl time.Duration) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		last    error
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checked.IsZero() && (last == nil || time.Since(checked) < ttl) {
+			return last
+		}
+
+		last = check(ctx)
+		checked = time.Now()
+		return last
+	}
+}
+
+func migrations(db *gorm.DB, models ...any) Check {
+	return func(ctx context.Context) error {
+		tx := db.WithContext(ctx)
+
+		var pending []string
+		for _, model := range models {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+
+			if !tx.Migrator().HasTable(model) {
+				pending = append(pending, stmt.Schema.Table)
+				continue
+			}
+
+			columns, err := tx.Migrator().ColumnTypes(model)
+			if err != nil {
+				return err
+			}
+
+			exists := make(map[string]bool, len(columns))
+			for _, c := range columns {
+				exists[c.Name()] = true
+			}
+
+			for _, f := range stmt.Schema.Fields {
+				if f.DBName != "" && !exists[f.DBName] {
+					pending = append(pending, stmt.Schema.Table+"."+f.DBName)
+				}
+			}
+		}
+
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+		}
+
+		return nil
+	}
+}
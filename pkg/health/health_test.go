@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCached(t *testing.T) {
+	calls := 0
+	result := errors.New("pending migrations: packages")
+	check := cached(func(ctx context.Context) error {
+		calls++
+		return result
+	}, 50*time.Millisecond)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := check(ctx); err == nil {
+			t.Fatal("failure is not returned")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("failure is checked %d times within ttl", calls)
+	}
+
+	// failure is checked again after ttl, success is kept
+	result = nil
+	time.Sleep(60 * time.Millisecond)
+	if err := check(ctx); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	result = errors.New("not checked")
+	time.Sleep(60 * time.Millisecond)
+	if err := check(ctx); err != nil {
+		t.Fatalf("success is checked again: %s", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 checks, got %d", calls)
+	}
+}
+
+func TestReady(t *testing.T) {
+	h := New().
+		Add("db", func(ctx context.Context) error { return nil }).
+		Add("s3", func(ctx context.Context) error { return errors.New("unreachable") })
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("probe reached the handler")
+	})
+
+	w := httptest.NewRecorder()
+	h.Middleware(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status %d", w.Code)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != notServing || resp.Checks["db"].Status != serving || resp.Checks["s3"].Error != "unreachable" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
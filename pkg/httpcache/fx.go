@@ -0,0 +1,10 @@
+package httpcache
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"httpcache",
+	fx.Provide(
+		NewPurger,
+	),
+)
@@ -0,0 +1,74 @@
+// Package httpcache implements validators and conditional requests of public responses
+// and purges shared caches in front of the public server
+package httpcache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag builds strong entity tag from the response body
+func ETag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// Check sets Cache-Control, ETag and Last-Modified and answers 304 when request
+// validators match. It reports true when the response is already written.
+// Zero modified time omits Last-Modified
+func Check(w http.ResponseWriter, r *http.Request, cacheControl, etag string, modified time.Time) bool {
+	// responses setting cookies, e.g. language switch, are personal
+	if w.Header().Get("Set-Cookie") != "" {
+		cacheControl = "private, no-cache"
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if !fresh(r, etag, modified) {
+		return false
+	}
+
+	// 304 must not carry body headers
+	for _, h := range []string{"Content-Type", "Content-Length"} {
+		w.Header().Del(h)
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// fresh reports whether client copy is still valid, If-None-Match takes precedence over If-Modified-Since
+func fresh(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etag == "" {
+			return false
+		}
+		for _, m := range strings.Split(match, ",") {
+			m = strings.TrimPrefix(strings.TrimSpace(m), "W/")
+			if m == etag || m == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+
+	// http dates have second precision
+	return !modified.Truncate(time.Second).After(since)
+}
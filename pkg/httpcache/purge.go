@@ -0,0 +1,141 @@
+package httpcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"gohome.4gophers.ru/getapp/gohome/pkg/logger"
+)
+
+type Config struct {
+	// PurgeURLs are bases of caches in front of public server, e.g. http://127.0.0.1:8080/purge,
+	// PURGE request is sent to base with changed path appended. Empty list disables purging
+	PurgeURLs []string `yaml:"purge_urls"`
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	for _, u := range c.PurgeURLs {
+		p, err := url.Parse(u)
+		if err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+			errs = append(errs, fmt.Errorf("purge url %q must be absolute http url", u))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	purgeTimeout = 10 * time.Second
+	// purgeParallel limits requests sent at once
+	purgeParallel = 8
+)
+
+// Purger asks shared caches to drop responses of changed paths
+type Purger struct {
+	config Config
+	logger *logger.Logger
+	http   *http.Client
+	// timeout bounds all requests of one Purge call
+	timeout time.Duration
+	// wg tracks purges in background, they are waited for on stop
+	wg sync.WaitGroup
+}
+
+func NewPurger(lc fx.Lifecycle, config Config, logger *logger.Logger) *Purger {
+	p := &Purger{
+		config:  config,
+		logger:  logger,
+		http:    &http.Client{},
+		timeout: purgeTimeout,
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: p.Wait,
+	})
+
+	return p
+}
+
+// Purge sends PURGE for every path to every cache in background, so saves and jobs don't wait
+// for caches. Errors are only logged, cached responses expire by max-age anyway
+func (p *Purger) Purge(ctx context.Context, paths ...string) {
+	if len(p.config.PurgeURLs) == 0 || len(paths) == 0 {
+		return
+	}
+
+	// request of the change may end before purges, its trace and request id are kept
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.timeout)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer cancel()
+
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, purgeParallel)
+		)
+		for _, base := range p.config.PurgeURLs {
+			for _, path := range paths {
+				u := strings.TrimSuffix(base, "/") + path
+
+				sem <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+
+					if err := p.purge(ctx, u); err != nil {
+						logger.For(ctx, p.logger).Warn("error on purge cache", zap.String("url", u), zap.Error(err))
+					}
+				}()
+			}
+		}
+		wg.Wait()
+	}()
+}
+
+// Wait blocks until purges started before are finished
+func (p *Purger) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Purger) purge(ctx context.Context, u string) error {
+	req, err := http.NewRequestWithContext(ctx, "PURGE", u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// caches answer 404 when the path is not cached
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// cache records PURGE requests and answers after delay
+type cache struct {
+	mu    sync.Mutex
+	paths []string
+	delay time.Duration
+}
+
+func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-time.After(c.delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	c.mu.Lock()
+	c.paths = append(c.paths, r.Method+" "+r.URL.RequestURI())
+	c.mu.Unlock()
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func newTestPurger(t *testing.T, delay, timeout time.Duration) (*Purger, *cache) {
+	c := &cache{delay: delay}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	return &Purger{
+		config:  Config{PurgeURLs: []string{srv.URL + "/purge/", srv.URL + "/other"}},
+		logger:  zap.NewNop(),
+		http:    &http.Client{},
+		timeout: timeout,
+	}, c
+}
+
+func TestPurge(t *testing.T) {
+	p, c := newTestPurger(t, 200*time.Millisecond, time.Minute)
+
+	// request of the change ends before purges are sent
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	p.Purge(ctx, "/a", "/b?go-get=1", "/c", "/d", "/e", "/f", "/g", "/h")
+	cancel()
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("purge blocks caller for %s", d)
+	}
+
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 16 requests are sent by 8 at once
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("purges are not parallel, took %s", d)
+	}
+
+	sort.Strings(c.paths)
+	if len(c.paths) != 16 || c.paths[0] != "PURGE /other/a" || c.paths[8] != "PURGE /purge/a" || c.paths[9] != "PURGE /purge/b?go-get=1" {
+		t.Errorf("unexpected requests %v", c.paths)
+	}
+}
+
+func TestPurgeTimeout(t *testing.T) {
+	p, c := newTestPurger(t, time.Minute, 100*time.Millisecond)
+
+	start := time.Now()
+	p.Purge(context.Background(), "/a", "/b")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("purges are not bounded by timeout, took %s", d)
+	}
+	if len(c.paths) != 0 {
+		t.Errorf("unexpected requests %v", c.paths)
+	}
+}
+
+func TestPurgeDisabled(t *testing.T) {
+	p := &Purger{logger: zap.NewNop(), http: &http.Client{}, timeout: time.Minute}
+	p.Purge(context.Background(), "/a")
+
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
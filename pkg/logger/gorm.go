@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const slowQuery = 200 * time.Millisecond
+
+// Gorm writes query logs to zap with request id from statement context
+type Gorm struct {
+	logger *Logger
+	level  gormlogger.LogLevel
+}
+
+func NewGorm(l *Logger, level gormlogger.LogLevel) *Gorm {
+	return &Gorm{
+		logger: l.WithOptions(zap.AddCallerSkip(3)),
+		level:  level,
+	}
+}
+
+func (g *Gorm) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &Gorm{
+		logger: g.logger,
+		level:  level,
+	}
+}
+
+func (g *Gorm) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Info {
+		For(ctx, g.logger).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *Gorm) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		For(ctx, g.logger).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *Gorm) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Error {
+		For(ctx, g.logger).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *Gorm) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{
+			zap.String("sql", sql),
+			zap.Int64("rows", rows),
+			zap.Duration("duration", elapsed),
+		}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		For(ctx, g.logger).Error("query error", append(fields(), zap.Error(err))...)
+	case elapsed > slowQuery && g.level >= gormlogger.Warn:
+		For(ctx, g.logger).Warn("slow query", fields()...)
+	case g.level >= gormlogger.Info:
+		For(ctx, g.logger).Info("query", fields()...)
+	}
+}
@@ -1,20 +1,29 @@
 package logger
 
 import (
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type Config struct {
-	Level    string
-	Encoding string
-	Tags     []string
-	Colored  bool
+	Level    string   `yaml:"level"`
+	Encoding string   `yaml:"encoding"`
+	Tags     []string `yaml:"tags"`
+	Colored  bool     `yaml:"colored"`
 }
 
 type Logger = zap.Logger
 
-func New(config Config) *Logger {
+type Result struct {
+	fx.Out
+
+	Logger *Logger
+	// Level can be changed at runtime, it serves GET and PUT requests with {"level":"debug"}
+	Level zap.AtomicLevel
+}
+
+func New(config Config) Result {
 	cfg := zap.NewProductionConfig()
 	var lvl zapcore.Level
 	err := lvl.UnmarshalText([]byte(config.Level))
@@ -22,23 +31,32 @@ func New(config Config) *Logger {
 		lvl = zap.InfoLevel
 	}
 
+	encoding := config.Encoding
+	if encoding == "" {
+		encoding = "json"
+	}
+
 	cfg.Level.SetLevel(lvl)
 	cfg.DisableStacktrace = true
 	cfg.Sampling.Initial = 50
 	cfg.Sampling.Thereafter = 50
-	cfg.Encoding = config.Encoding
+	cfg.Encoding = encoding
 	cfg.OutputPaths = []string{"stdout"}
 	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if config.Colored && encoding == "console" {
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
 	logger, err := cfg.Build()
 	if err != nil {
 		panic(err)
 	}
 
-	logger = logger.WithOptions(zap.Hooks(func(entry zapcore.Entry) error {
-		return nil
-	}))
+	if len(config.Tags) > 0 {
+		logger = logger.With(zap.Strings("tags", config.Tags))
+	}
 
-	return logger.With(
-		zap.Strings("tags", config.Tags),
-	)
+	return Result{
+		Logger: logger,
+		Level:  cfg.Level,
+	}
 }
@@ -0,0 +1,22 @@
+package tracing
+
+import "errors"
+
+type Config struct {
+	// Enabled turns on export, without it spans are not recorded
+	Enabled bool `yaml:"enabled"`
+	// Service name reported to collector
+	Service string `yaml:"service"`
+	// Endpoint of OTLP HTTP collector, e.g. localhost:4318
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+	// Ratio of sampled traces, 0 means all traces
+	Ratio float64 `yaml:"ratio"`
+}
+
+func (c Config) Validate() error {
+	if c.Ratio < 0 || c.Ratio > 1 {
+		return errors.New("ratio must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package tracing
+
+import "go.uber.org/fx"
+
+var Module = fx.Module(
+	"tracing",
+	fx.Provide(
+		New,
+	),
+)
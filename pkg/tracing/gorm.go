@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+type querySpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// Gorm plugin creates span for every query, statement context
+// must be set with db.WithContext to join request trace
+type Gorm struct {
+	tracer trace.Tracer
+}
+
+func NewGorm(tp trace.TracerProvider) *Gorm {
+	return &Gorm{
+		tracer: tp.Tracer(scope),
+	}
+}
+
+func (g *Gorm) Name() string {
+	return "tracing"
+}
+
+func (g *Gorm) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	type register interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+
+	hooks := []struct {
+		op     string
+		before register
+		after  register
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+
+	for _, h := range hooks {
+		if err := h.before.Register("tracing:before_"+h.op, g.before(h.op)); err != nil {
+			return err
+		}
+		if err := h.after.Register("tracing:after_"+h.op, g.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Gorm) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		ctx, span := g.tracer.Start(parent, "gorm."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNamePostgreSQL,
+				semconv.DBOperationName(op),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, querySpan{span: span, parent: parent})
+	}
+}
+
+func (g *Gorm) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	qs := v.(querySpan)
+	span := qs.span
+	// statement may be reused, next query must not be nested into ended span
+	db.Statement.Context = qs.parent
+
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	End(span, err)
+}
@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var errQuery = errors.New("connection refused")
+
+// failingPool fails every query like unreachable database
+type failingPool struct{}
+
+func (failingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errQuery
+}
+
+func (failingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errQuery
+}
+
+func (failingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errQuery
+}
+
+func (failingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+func TestGorm(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: failingPool{}}), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewGorm(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	tx := db.WithContext(ctx)
+
+	var items []item
+	if err := tx.Where("name = ?", "x").Find(&items).Error; !errors.Is(err, errQuery) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := tx.Exec("DELETE FROM items").Error; !errors.Is(err, errQuery) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	parent.End()
+
+	got := spans(t, tp, exporter)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 spans, got %v", names(got))
+	}
+
+	query, raw := got[0], got[1]
+	if query.Name != "gorm.query" || raw.Name != "gorm.raw" {
+		t.Fatalf("unexpected spans %v", names(got))
+	}
+
+	for _, s := range []struct {
+		name, op, text string
+	}{
+		{query.Name, attr(query, semconv.DBOperationNameKey), attr(query, semconv.DBQueryTextKey)},
+		{raw.Name, attr(raw, semconv.DBOperationNameKey), attr(raw, semconv.DBQueryTextKey)},
+	} {
+		if s.op == "" || s.text == "" {
+			t.Errorf("%s: missing operation %q or query %q", s.name, s.op, s.text)
+		}
+	}
+	if table := attr(query, semconv.DBCollectionNameKey); table != "items" {
+		t.Errorf("unexpected table %q", table)
+	}
+
+	// queries join the request trace and record errors
+	for _, s := range got[:2] {
+		if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of request span", s.Name)
+		}
+		if s.Status.Code != codes.Error {
+			t.Errorf("%s: expected error status, got %v", s.Name, s.Status)
+		}
+	}
+}
+
+func TestGormDryRun(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: failingPool{}}), &gorm.Config{
+		DisableAutomaticPing: true,
+		DryRun:               true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewGorm(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Create(&item{Name: "x"})
+	db.Model(&item{ID: 1}).Update("name", "y")
+	db.Delete(&item{ID: 1})
+
+	got := spans(t, tp, exporter)
+	want := []string{"gorm.create", "gorm.update", "gorm.delete"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected spans %v", names(got))
+	}
+	for i, s := range got {
+		if s.Name != want[i] {
+			t.Errorf("span %d: %q, want %q", i, s.Name, want[i])
+		}
+		if s.Status.Code == codes.Error {
+			t.Errorf("%s: unexpected error status", s.Name)
+		}
+	}
+}
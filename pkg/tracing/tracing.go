@@ -0,0 +1,170 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+)
+
+const (
+	defaultService = "gohome"
+
+	scope = "gohome.4gophers.ru/getapp/gohome"
+)
+
+// New creates tracer provider exporting spans with OTLP over HTTP,
+// disabled tracing returns noop provider
+func New(lc fx.Lifecycle, config Config) (trace.TracerProvider, error) {
+	if !config.Enabled {
+		return noop.NewTracerProvider(), nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := NewProvider(config, exporter)
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return tp.Shutdown(ctx)
+		},
+	})
+
+	return tp, nil
+}
+
+// NewProvider creates tracer provider with given exporter and sets it globally,
+// e.g. tests use tracetest.NewInMemoryExporter
+func NewProvider(config Config, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	service := config.Service
+	if service == "" {
+		service = defaultService
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if config.Ratio > 0 && config.Ratio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.Ratio)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp
+}
+
+// Middleware creates server span for every request. Span is named by method and route
+// pattern, paths would make a span name per package
+func Middleware(tp trace.TracerProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		routed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			// route is known only after routing
+			if route := route(r); route != "" {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(spanName(r))
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+		})
+
+		h := otelhttp.NewHandler(routed, "http",
+			otelhttp.WithTracerProvider(tp),
+			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+				return spanName(r)
+			}),
+		)
+
+		// otelhttp names the span again after routing, holder must be in its request too
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), patternKey{}, &pattern{})))
+		})
+	}
+}
+
+type patternKey struct{}
+
+// pattern holds route of http.ServeMux wrapped by Routes
+type pattern struct {
+	route  string
+	routed bool
+}
+
+// Routes passes pattern of http.ServeMux to Middleware. Mux sets pattern on the request it gets,
+// middlewares between Middleware and mux pass it copies of the request, so the pattern is
+// written to the holder Middleware put into context
+func Routes(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := r.Context().Value(patternKey{}).(*pattern); ok {
+			_, p.route = mux.Handler(r)
+			p.routed = true
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// spanName is method with route pattern, only method before routing
+func spanName(r *http.Request) string {
+	if route := route(r); route != "" {
+		return r.Method + " " + route
+	}
+	return r.Method
+}
+
+// route is chi route pattern or pattern of http.ServeMux, empty when the request was not routed
+func route(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if rp := rctx.RoutePattern(); rp != "" {
+			return rp
+		}
+	}
+	// patterns of http.ServeMux may start with method, e.g. GET /badge/{path...}
+	route := r.Pattern
+	if p, ok := r.Context().Value(patternKey{}).(*pattern); ok && p.routed {
+		route = p.route
+	}
+	if _, path, ok := strings.Cut(route, " "); ok {
+		route = path
+	}
+	return route
+}
+
+// End records error if any and ends span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+func newTestProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := NewProvider(Config{Service: "test"}, exporter)
+	t.Cleanup(func() {
+		tp.Shutdown(t.Context())
+	})
+	return tp, exporter
+}
+
+// spans flushes batched spans and returns them
+func spans(t *testing.T, tp *sdktrace.TracerProvider, exporter *tracetest.InMemoryExporter) tracetest.SpanStubs {
+	if err := tp.ForceFlush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	return exporter.GetSpans()
+}
+
+func attr(s tracetest.SpanStub, key attribute.Key) string {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestMiddlewareChiRoute(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware(tp))
+	r.Get("/changelog/*", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/search", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/changelog/kovardin/example", "/changelog/kovardin/other@v1.0.0", "/search?q=x"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	got := spans(t, tp, exporter)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(got))
+	}
+
+	want := []string{"GET /changelog/*", "GET /changelog/*", "GET /search"}
+	for i, s := range got {
+		if s.Name != want[i] {
+			t.Errorf("span %d: name %q, want %q", i, s.Name, want[i])
+		}
+		if route := attr(s, semconv.HTTPRouteKey); s.Name != "GET "+route {
+			t.Errorf("span %d: route attribute %q", i, route)
+		}
+	}
+}
+
+func TestMiddlewareServeMux(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /badge/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+	h := Middleware(tp)(mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/badge/kovardin/example/version.svg", nil))
+
+	got := spans(t, tp, exporter)
+	if len(got) != 1 || got[0].Name != "GET /badge/{path...}" {
+		t.Fatalf("unexpected spans %v", names(got))
+	}
+}
+
+func TestMiddlewareServeMuxWrapped(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /media/", func(w http.ResponseWriter, r *http.Request) {})
+
+	// middlewares between tracing and mux pass copies of the request, like login of admin
+	var h http.Handler = Routes(mux)
+	h = wrap(h)
+	h = Middleware(tp)(h)
+	// admin is mounted on default mux, its pattern must not name spans
+	outer := http.NewServeMux()
+	outer.Handle("/", h)
+
+	for _, path := range []string{"/admin/packages", "/media/a.png", "/unknown"} {
+		outer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	got := spans(t, tp, exporter)
+	want := []string{"GET /admin/", "GET /media/", "GET"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected spans %v", names(got))
+	}
+	for i, s := range got {
+		if s.Name != want[i] {
+			t.Errorf("span %d: name %q, want %q", i, s.Name, want[i])
+		}
+	}
+}
+
+func wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), struct{}{}, true)))
+	})
+}
+
+func TestMiddlewareUnrouted(t *testing.T) {
+	tp, exporter := newTestProvider(t)
+
+	h := Middleware(tp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/kovardin/example", nil))
+
+	got := spans(t, tp, exporter)
+	if len(got) != 1 || got[0].Name != "POST" {
+		t.Fatalf("unexpected spans %v", names(got))
+	}
+	if status := attr(got[0], semconv.HTTPResponseStatusCodeKey); status != "500" {
+		t.Errorf("unexpected status attribute %q", status)
+	}
+}
+
+func names(stubs tracetest.SpanStubs) []string {
+	var names []string
+	for _, s := range stubs {
+		names = append(names, s.Name)
+	}
+	return names
+}